@@ -0,0 +1,218 @@
+// Copyright (c) Jim Lambert
+// SPDX-License-Identifier: MIT
+
+package gldap
+
+import (
+	"encoding/hex"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// sidBaseDNPrefix is the prefix of a SID base DN (for example:
+	// "<SID=S-1-5-21-1-2-3-1104>")
+	sidBaseDNPrefix = "<SID="
+
+	// guidBaseDNPrefix is the prefix of a GUID base DN (for example:
+	// "<GUID=5b5d6b2e-6f3a-4d3c-9c9a-0b8f3f2c1e7d>")
+	guidBaseDNPrefix = "<GUID="
+)
+
+// ParseSIDBaseDN parses a SID base DN (for example:
+// "<SID=S-1-5-21-1-2-3-1104>"), which Active Directory supports as the base
+// DN of search requests.  The SID may be in its string form or its binary form
+// encoded as hex.
+func ParseSIDBaseDN(baseDN string) (*SID, error) {
+	const op = "gldap.ParseSIDBaseDN"
+	value, ok := baseDNValue(baseDN, sidBaseDNPrefix)
+	if !ok {
+		return nil, fmt.Errorf("%s: %q is not a SID base DN: %w", op, baseDN, ErrInvalidParameter)
+	}
+	var sid *SID
+	var err error
+	switch {
+	case strings.HasPrefix(value, "S-"), strings.HasPrefix(value, "s-"):
+		sid, err = ParseSID(value)
+	default:
+		var b []byte
+		if b, err = hex.DecodeString(value); err == nil {
+			sid, err = ParseSIDBytes(b)
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: invalid SID in base DN %q: %w", op, baseDN, ErrInvalidParameter)
+	}
+	return sid, nil
+}
+
+// ParseGUIDBaseDN parses a GUID base DN (for example:
+// "<GUID=5b5d6b2e-6f3a-4d3c-9c9a-0b8f3f2c1e7d>"), which Active Directory
+// supports as the base DN of search requests.  The GUID may be in its string
+// form or its binary form encoded as hex.
+func ParseGUIDBaseDN(baseDN string) (GUID, error) {
+	const op = "gldap.ParseGUIDBaseDN"
+	value, ok := baseDNValue(baseDN, guidBaseDNPrefix)
+	if !ok {
+		return GUID{}, fmt.Errorf("%s: %q is not a GUID base DN: %w", op, baseDN, ErrInvalidParameter)
+	}
+	var guid GUID
+	var err error
+	switch {
+	case len(value) == 2*guidLen:
+		var b []byte
+		if b, err = hex.DecodeString(value); err == nil {
+			guid, err = ParseGUIDBytes(b)
+		}
+	default:
+		guid, err = ParseGUID(value)
+	}
+	if err != nil {
+		return GUID{}, fmt.Errorf("%s: invalid GUID in base DN %q: %w", op, baseDN, ErrInvalidParameter)
+	}
+	return guid, nil
+}
+
+// baseDNValue returns the value of a base DN with the prefix (for example:
+// "S-1-1" for "<SID=S-1-1>").  The prefix is not case sensitive.
+func baseDNValue(baseDN, prefix string) (string, bool) {
+	baseDN = strings.TrimSpace(baseDN)
+	if len(baseDN) <= len(prefix) || !strings.EqualFold(baseDN[:len(prefix)], prefix) || !strings.HasSuffix(baseDN, ">") {
+		return "", false
+	}
+	return baseDN[len(prefix) : len(baseDN)-1], true
+}
+
+const (
+	// fileTimeUnixOffset is the number of seconds between the FILETIME epoch
+	// (1601-01-01) and the unix epoch (1970-01-01)
+	fileTimeUnixOffset = 11644473600
+
+	// fileTimeIntervalsPerSecond is the number of FILETIME 100-nanosecond
+	// intervals in a second
+	fileTimeIntervalsPerSecond = 10000000
+)
+
+// FileTimeToTime converts a FILETIME (the number of 100-nanosecond intervals
+// since 1601-01-01 UTC) to a time.  FILETIMEs are used for values like
+// pwdLastSet, lastLogonTimestamp and accountExpires.
+// see: https://learn.microsoft.com/en-us/windows/win32/api/minwinbase/ns-minwinbase-filetime
+func FileTimeToTime(fileTime int64) time.Time {
+	seconds := fileTime / fileTimeIntervalsPerSecond
+	nanoseconds := (fileTime % fileTimeIntervalsPerSecond) * 100
+	return time.Unix(seconds-fileTimeUnixOffset, nanoseconds).UTC()
+}
+
+// TimeToFileTime converts a time to a FILETIME (the number of 100-nanosecond
+// intervals since 1601-01-01 UTC).
+func TimeToFileTime(t time.Time) int64 {
+	return (t.Unix()+fileTimeUnixOffset)*fileTimeIntervalsPerSecond + int64(t.Nanosecond())/100
+}
+
+// ParseFileTime parses a FILETIME attribute value (for example: a
+// pwdLastSet value of "132514560000000000") to a time.
+func ParseFileTime(s string) (time.Time, error) {
+	const op = "gldap.ParseFileTime"
+	fileTime, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%s: invalid FILETIME %q: %w", op, s, ErrInvalidParameter)
+	}
+	return FileTimeToTime(fileTime), nil
+}
+
+// AccountNeverExpires is the accountExpires value of an account which never
+// expires.  Active Directory also uses "0" for accounts which never expire.
+const AccountNeverExpires = "9223372036854775807"
+
+// ParseAccountExpires parses an accountExpires value and returns when the
+// account expires.  It returns false when the account never expires.
+// see: https://learn.microsoft.com/en-us/windows/win32/adschema/a-accountexpires
+func ParseAccountExpires(s string) (time.Time, bool, error) {
+	const op = "gldap.ParseAccountExpires"
+	fileTime, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("%s: invalid accountExpires %q: %w", op, s, ErrInvalidParameter)
+	}
+	if fileTime == 0 || fileTime == math.MaxInt64 {
+		return time.Time{}, false, nil
+	}
+	return FileTimeToTime(fileTime), true, nil
+}
+
+// AccountExpiresValue returns the accountExpires value for when an account
+// expires.  The zero time returns AccountNeverExpires.
+func AccountExpiresValue(expires time.Time) string {
+	if expires.IsZero() {
+		return AccountNeverExpires
+	}
+	return strconv.FormatInt(TimeToFileTime(expires), 10)
+}
+
+// UserAccountControl is the value of a userAccountControl attribute, which
+// is a set of flags (for example: UACAccountDisable)
+// see: https://learn.microsoft.com/en-us/troubleshoot/windows-server/active-directory/useraccountcontrol-manipulate-account-properties
+type UserAccountControl uint32
+
+// UserAccountControl flags
+const (
+	UACScript                       UserAccountControl = 0x0001
+	UACAccountDisable               UserAccountControl = 0x0002
+	UACHomedirRequired              UserAccountControl = 0x0008
+	UACLockout                      UserAccountControl = 0x0010
+	UACPasswordNotRequired          UserAccountControl = 0x0020
+	UACPasswordCantChange           UserAccountControl = 0x0040
+	UACEncryptedTextPasswordAllowed UserAccountControl = 0x0080
+	UACTempDuplicateAccount         UserAccountControl = 0x0100
+	UACNormalAccount                UserAccountControl = 0x0200
+	UACInterdomainTrustAccount      UserAccountControl = 0x0800
+	UACWorkstationTrustAccount      UserAccountControl = 0x1000
+	UACServerTrustAccount           UserAccountControl = 0x2000
+	UACDontExpirePassword           UserAccountControl = 0x10000
+	UACMNSLogonAccount              UserAccountControl = 0x20000
+	UACSmartcardRequired            UserAccountControl = 0x40000
+	UACTrustedForDelegation         UserAccountControl = 0x80000
+	UACNotDelegated                 UserAccountControl = 0x100000
+	UACUseDESKeyOnly                UserAccountControl = 0x200000
+	UACDontRequirePreauth           UserAccountControl = 0x400000
+	UACPasswordExpired              UserAccountControl = 0x800000
+	UACTrustedToAuthForDelegation   UserAccountControl = 0x1000000
+	UACPartialSecretsAccount        UserAccountControl = 0x4000000
+)
+
+// ParseUserAccountControl parses a userAccountControl value (for example:
+// "514").  Negative values are supported, since some directories return the
+// value as a signed 32-bit integer.
+func ParseUserAccountControl(s string) (UserAccountControl, error) {
+	const op = "gldap.ParseUserAccountControl"
+	if v, err := strconv.ParseUint(s, 10, 32); err == nil {
+		return UserAccountControl(v), nil
+	}
+	v, err := strconv.ParseInt(s, 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("%s: invalid userAccountControl %q: %w", op, s, ErrInvalidParameter)
+	}
+	return UserAccountControl(uint32(int32(v))), nil
+}
+
+// Has returns true when all the flags are set
+func (u UserAccountControl) Has(flags UserAccountControl) bool {
+	return u&flags == flags
+}
+
+// Set returns the value with the flags set
+func (u UserAccountControl) Set(flags UserAccountControl) UserAccountControl {
+	return u | flags
+}
+
+// Clear returns the value with the flags cleared
+func (u UserAccountControl) Clear(flags UserAccountControl) UserAccountControl {
+	return u &^ flags
+}
+
+// String returns the userAccountControl value (for example: "514")
+func (u UserAccountControl) String() string {
+	return strconv.FormatUint(uint64(u), 10)
+}
@@ -0,0 +1,254 @@
+// Copyright (c) Jim Lambert
+// SPDX-License-Identifier: MIT
+
+package gldap
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSIDBaseDN(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name            string
+		baseDN          string
+		want            string
+		wantErrContains string
+	}{
+		{
+			name:   "string",
+			baseDN: "<SID=S-1-5-21-1-2-3-1104>",
+			want:   "S-1-5-21-1-2-3-1104",
+		},
+		{
+			name:   "lower-case",
+			baseDN: "<sid=s-1-5-32-544>",
+			want:   "S-1-5-32-544",
+		},
+		{
+			name:   "hex",
+			baseDN: "<SID=01020000000000052000000020020000>",
+			want:   "S-1-5-32-544",
+		},
+		{
+			name:            "not-sid",
+			baseDN:          "cn=alice,ou=people,dc=example,dc=org",
+			wantErrContains: "is not a SID base DN",
+		},
+		{
+			name:            "missing-suffix",
+			baseDN:          "<SID=S-1-5-32-544",
+			wantErrContains: "is not a SID base DN",
+		},
+		{
+			name:            "guid",
+			baseDN:          "<GUID=00112233-4455-6677-8899-aabbccddeeff>",
+			wantErrContains: "is not a SID base DN",
+		},
+		{
+			name:            "invalid-sid",
+			baseDN:          "<SID=S-1>",
+			wantErrContains: "invalid SID in base DN",
+		},
+		{
+			name:            "invalid-hex",
+			baseDN:          "<SID=0102>",
+			wantErrContains: "invalid SID in base DN",
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			assert, require := assert.New(t), require.New(t)
+			got, err := ParseSIDBaseDN(tc.baseDN)
+			if tc.wantErrContains != "" {
+				require.Error(err)
+				assert.ErrorIs(err, ErrInvalidParameter)
+				assert.Contains(err.Error(), tc.wantErrContains)
+				return
+			}
+			require.NoError(err)
+			assert.Equal(tc.want, got.String())
+			assert.Equal("<SID="+tc.want+">", got.BaseDN())
+		})
+	}
+}
+
+func TestParseGUIDBaseDN(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name            string
+		baseDN          string
+		want            string
+		wantErrContains string
+	}{
+		{
+			name:   "string",
+			baseDN: "<GUID=00112233-4455-6677-8899-aabbccddeeff>",
+			want:   "00112233-4455-6677-8899-aabbccddeeff",
+		},
+		{
+			name:   "hex",
+			baseDN: "<GUID=33221100554477668899aabbccddeeff>",
+			want:   "00112233-4455-6677-8899-aabbccddeeff",
+		},
+		{
+			name:            "not-guid",
+			baseDN:          "<SID=S-1-5-32-544>",
+			wantErrContains: "is not a GUID base DN",
+		},
+		{
+			name:            "invalid-guid",
+			baseDN:          "<GUID=00112233>",
+			wantErrContains: "invalid GUID in base DN",
+		},
+		{
+			name:            "invalid-hex",
+			baseDN:          "<GUID=33221100554477668899aabbccddeegg>",
+			wantErrContains: "invalid GUID in base DN",
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			assert, require := assert.New(t), require.New(t)
+			got, err := ParseGUIDBaseDN(tc.baseDN)
+			if tc.wantErrContains != "" {
+				require.Error(err)
+				assert.ErrorIs(err, ErrInvalidParameter)
+				assert.Contains(err.Error(), tc.wantErrContains)
+				return
+			}
+			require.NoError(err)
+			assert.Equal(tc.want, got.String())
+		})
+	}
+}
+
+func TestFileTime(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		fileTime int64
+		want     time.Time
+	}{
+		{
+			name:     "unix-epoch",
+			fileTime: 116444736000000000,
+			want:     time.Unix(0, 0).UTC(),
+		},
+		{
+			name:     "filetime-epoch",
+			fileTime: 0,
+			want:     time.Date(1601, time.January, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "with-intervals",
+			fileTime: 132514560000000001,
+			want:     time.Date(2020, time.December, 3, 8, 0, 0, 100, time.UTC),
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			assert, require := assert.New(t), require.New(t)
+			assert.Equal(tc.want, FileTimeToTime(tc.fileTime))
+			assert.Equal(tc.fileTime, TimeToFileTime(tc.want))
+
+			got, err := ParseFileTime(strconv.FormatInt(tc.fileTime, 10))
+			require.NoError(err)
+			assert.Equal(tc.want, got)
+		})
+	}
+	t.Run("invalid", func(t *testing.T) {
+		_, err := ParseFileTime("not-a-number")
+		require.Error(t, err)
+		assert.ErrorIs(t, err, ErrInvalidParameter)
+	})
+}
+
+func TestParseAccountExpires(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name            string
+		value           string
+		want            time.Time
+		wantExpires     bool
+		wantErrContains string
+	}{
+		{
+			name:  "never",
+			value: AccountNeverExpires,
+		},
+		{
+			name:  "zero",
+			value: "0",
+		},
+		{
+			name:        "expires",
+			value:       "132514560000000000",
+			want:        time.Date(2020, time.December, 3, 8, 0, 0, 0, time.UTC),
+			wantExpires: true,
+		},
+		{
+			name:            "invalid",
+			value:           "tomorrow",
+			wantErrContains: "invalid accountExpires",
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			assert, require := assert.New(t), require.New(t)
+			got, expires, err := ParseAccountExpires(tc.value)
+			if tc.wantErrContains != "" {
+				require.Error(err)
+				assert.ErrorIs(err, ErrInvalidParameter)
+				assert.Contains(err.Error(), tc.wantErrContains)
+				return
+			}
+			require.NoError(err)
+			assert.Equal(tc.wantExpires, expires)
+			assert.Equal(tc.want, got)
+			if expires {
+				assert.Equal(tc.value, AccountExpiresValue(got))
+			} else {
+				assert.Equal(AccountNeverExpires, AccountExpiresValue(got))
+			}
+		})
+	}
+}
+
+func TestUserAccountControl(t *testing.T) {
+	t.Parallel()
+	assert, require := assert.New(t), require.New(t)
+
+	uac, err := ParseUserAccountControl("514")
+	require.NoError(err)
+	assert.True(uac.Has(UACNormalAccount))
+	assert.True(uac.Has(UACNormalAccount | UACAccountDisable))
+	assert.False(uac.Has(UACLockout))
+
+	uac = uac.Clear(UACAccountDisable).Set(UACDontExpirePassword)
+	assert.False(uac.Has(UACAccountDisable))
+	assert.True(uac.Has(UACDontExpirePassword))
+	assert.Equal("66048", uac.String())
+
+	negative, err := ParseUserAccountControl("-2147483136")
+	require.NoError(err)
+	assert.Equal(UserAccountControl(0x80000200), negative)
+
+	_, err = ParseUserAccountControl("disabled")
+	require.Error(err)
+	assert.ErrorIs(err, ErrInvalidParameter)
+	assert.Contains(err.Error(), "invalid userAccountControl")
+}
@@ -26,19 +26,48 @@ type Attribute struct {
 	Type string
 	// Vals are the LDAP attribute values
 	Vals []string
+	// ByteValues are the raw LDAP attribute values, which should be used for
+	// binary values (for example: objectSid, objectGUID or jpegPhoto).  When
+	// decoded from a request, it contains the same values as Vals.
+	ByteValues [][]byte
 }
 
 func (a *Attribute) encode() *ber.Packet {
-	seq := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "Attribute")
-	seq.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, a.Type, "Type"))
+	return encodeAttribute("Attribute", a.Type, a.Vals, a.ByteValues)
+}
+
+// encodeAttribute encodes an attribute's type and values.  The string values
+// are encoded when there are any, otherwise the byte values are encoded.
+func encodeAttribute(description, attrType string, values []string, byteValues [][]byte) *ber.Packet {
+	seq := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, description)
+	seq.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, attrType, "Type"))
 	set := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSet, nil, "AttributeValue")
-	for _, value := range a.Vals {
-		set.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, value, "Vals"))
+	if len(values) > 0 {
+		for _, value := range values {
+			set.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, value, "Vals"))
+		}
+	} else {
+		for _, value := range byteValues {
+			set.AppendChild(encodeByteValue(value))
+		}
 	}
 	seq.AppendChild(set)
 	return seq
 }
 
+// encodeByteValue encodes a raw attribute value as an octet string
+func encodeByteValue(v []byte) *ber.Packet {
+	p := ber.Encode(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, nil, "Vals")
+	p.Value = string(v)
+	p.Data.Write(v)
+	return p
+}
+
+// decodeByteValue returns a copy of an attribute value's raw bytes
+func decodeByteValue(p *ber.Packet) []byte {
+	return append([]byte{}, p.Data.Bytes()...)
+}
+
 func decodeAttribute(berPacket *ber.Packet) (*Attribute, error) {
 	const op = "gldap.decodeAttribute"
 	const (
@@ -70,11 +99,13 @@ func decodeAttribute(berPacket *ber.Packet) (*Attribute, error) {
 		Packet: seq.Children[childVals],
 	}
 	decodedAttribute.Vals = make([]string, 0, len(valuesPacket.Children))
+	decodedAttribute.ByteValues = make([][]byte, 0, len(valuesPacket.Children))
 	for idx := range valuesPacket.Children {
 		if err := valuesPacket.assert(ber.ClassUniversal, ber.TypePrimitive, withTag(ber.TagOctetString), withAssertChild(idx)); err != nil {
 			return nil, fmt.Errorf("%s: invalid attribute values packet: %w", op, err)
 		}
 		decodedAttribute.Vals = append(decodedAttribute.Vals, valuesPacket.Children[idx].Data.String())
+		decodedAttribute.ByteValues = append(decodedAttribute.ByteValues, decodeByteValue(valuesPacket.Children[idx]))
 	}
 
 	return &decodedAttribute, nil
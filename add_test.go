@@ -89,8 +89,24 @@ func TestAttribute_decode(t *testing.T) {
 				return attr.encode()
 			}(),
 			want: &Attribute{
-				Type: "email",
-				Vals: []string{"alice@example.com"},
+				Type:       "email",
+				Vals:       []string{"alice@example.com"},
+				ByteValues: [][]byte{[]byte("alice@example.com")},
+			},
+		},
+		{
+			name: "binary",
+			packet: func() *ber.Packet {
+				attr := Attribute{
+					Type:       "objectGUID",
+					ByteValues: [][]byte{{0x00, 0xff, 0x04, 0x10}},
+				}
+				return attr.encode()
+			}(),
+			want: &Attribute{
+				Type:       "objectGUID",
+				Vals:       []string{"\x00\xff\x04\x10"},
+				ByteValues: [][]byte{{0x00, 0xff, 0x04, 0x10}},
 			},
 		},
 	}
@@ -109,7 +125,7 @@ func TestAttribute_decode(t *testing.T) {
 				return
 			}
 			require.NoError(err)
-			assert.NotNil(got)
+			assert.Equal(tc.want, got)
 		})
 	}
 }
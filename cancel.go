@@ -0,0 +1,145 @@
+// Copyright (c) Jim Lambert
+// SPDX-License-Identifier: MIT
+
+package gldap
+
+import (
+	"context"
+	"fmt"
+
+	ber "github.com/go-asn1-ber/asn1-ber"
+)
+
+// CancelMessage is a cancel extended operation request message as defined in
+// https://datatracker.ietf.org/doc/html/rfc3909.  Cancel requests are handled
+// by the server, which cancels the identified in-flight request, and they're
+// never routed to a handler.
+type CancelMessage struct {
+	baseMessage
+	// Value is the request's raw cancelRequestValue, which is decoded when the
+	// request is handled (see: DecodeCancelRequestValue), so an invalid value
+	// gets a ResultProtocolError response.
+	Value []byte
+}
+
+// DecodeCancelRequestValue decodes the cancelID from the request value of an
+// ExtendedOperationCancel request, which is defined as:
+//
+//	cancelRequestValue ::= SEQUENCE {
+//		cancelID        MessageID
+//	}
+func DecodeCancelRequestValue(value []byte) (int64, error) {
+	const (
+		op = "gldap.DecodeCancelRequestValue"
+
+		childCancelID = 0
+	)
+	if len(value) == 0 {
+		return 0, fmt.Errorf("%s: missing request value: %w", op, ErrInvalidParameter)
+	}
+	berPacket, err := ber.DecodePacketErr(value)
+	if err != nil {
+		return 0, fmt.Errorf("%s: unable to decode request value: %w", op, ErrInvalidParameter)
+	}
+	seq := &packet{Packet: berPacket}
+	if err := seq.assert(ber.ClassUniversal, ber.TypeConstructed, withTag(ber.TagSequence)); err != nil {
+		return 0, fmt.Errorf("%s: invalid request value sequence: %w", op, ErrInvalidParameter)
+	}
+	if err := seq.assert(ber.ClassUniversal, ber.TypePrimitive, withTag(ber.TagInteger), withAssertChild(childCancelID)); err != nil {
+		return 0, fmt.Errorf("%s: missing/invalid cancel id: %w", op, ErrInvalidParameter)
+	}
+	cancelID, ok := seq.Children[childCancelID].Value.(int64)
+	if !ok {
+		return 0, fmt.Errorf("%s: cancel id %v is not the expected int64 type: %w", op, seq.Children[childCancelID].Value, ErrInvalidParameter)
+	}
+	return cancelID, nil
+}
+
+// cancelRequest handles cancel requests for the conn.  The cancelled request
+// will finish with a ResultCanceled response before the response to the
+// cancel request is written.  Requests with an invalid cancelRequestValue get
+// a ResultProtocolError response.  No response is written when the cancel
+// request's context is done (it was abandoned or the server is stopping).
+// see: https://datatracker.ietf.org/doc/html/rfc3909#section-2.2
+func (c *conn) cancelRequest(w *ResponseWriter, r *Request) {
+	const op = "gldap.(Conn).cancelRequest"
+	if r.Context().Err() != nil {
+		c.logger.Debug("cancel request is done", "op", op, "conn", c.connID, "requestID", r.ID, "err", context.Cause(r.Context()))
+		return
+	}
+	resp := r.NewExtendedResponse(WithResponseCode(ResultNoSuchOperation))
+	defer func() {
+		if r.Context().Err() != nil {
+			c.logger.Debug("cancel request is done", "op", op, "conn", c.connID, "requestID", r.ID, "err", context.Cause(r.Context()))
+			return
+		}
+		if err := w.Write(resp); err != nil {
+			c.logger.Error("error writing cancel response", "op", op, "conn", c.connID, "requestID", r.ID, "err", err)
+		}
+	}()
+	m, ok := r.message.(*CancelMessage)
+	if !ok {
+		resp.SetResultCode(ResultProtocolError)
+		return
+	}
+	cancelID, err := DecodeCancelRequestValue(m.Value)
+	if err != nil {
+		c.logger.Debug("invalid cancel request value", "op", op, "conn", c.connID, "requestID", r.ID, "err", err)
+		resp.SetResultCode(ResultProtocolError)
+		resp.SetDiagnosticMessage("invalid cancel request value")
+		return
+	}
+	c.requestsMu.Lock()
+	target, ok := c.requests[cancelID]
+	c.requestsMu.Unlock()
+	switch {
+	case !ok:
+		c.logger.Debug("no in-flight request to cancel", "op", op, "conn", c.connID, "cancelID", cancelID)
+		return
+	case target.r.routeOp == bindRouteOperation, target.r.extendedName == ExtendedOperationCancel:
+		resp.SetResultCode(ResultCannotCancel)
+		return
+	}
+
+	// make the check for a response and the cancellation atomic with respect
+	// to writing the target's response
+	c.writerMu.Lock()
+	responded := target.w.responded
+	if !responded {
+		target.r.cancel(ErrCanceled)
+	}
+	c.writerMu.Unlock()
+	if responded {
+		resp.SetResultCode(ResultTooLate)
+		return
+	}
+	c.dropPagingCursors(cancelID)
+
+	c.logger.Debug("cancelled request", "op", op, "conn", c.connID, "cancelID", cancelID, "requestID", target.r.ID)
+	select {
+	case <-target.done:
+		resp.SetResultCode(ResultSuccess)
+	case <-r.Context().Done():
+	}
+}
+
+// newCanceledResponse creates a ResultCanceled response for the request's
+// operation
+func newCanceledResponse(r *Request) Response {
+	applicationCode := ApplicationExtendedResponse
+	switch r.routeOp {
+	case searchRouteOperation:
+		applicationCode = ApplicationSearchResultDone
+	case modifyRouteOperation:
+		applicationCode = ApplicationModifyResponse
+	case addRouteOperation:
+		applicationCode = ApplicationAddResponse
+	case deleteRouteOperation:
+		applicationCode = ApplicationDelResponse
+	case compareRouteOperation:
+		applicationCode = ApplicationCompareResponse
+	case modifyDNRouteOperation:
+		applicationCode = ApplicationModifyDNResponse
+	}
+	return r.NewResponse(WithApplicationCode(applicationCode), WithResponseCode(ResultCanceled))
+}
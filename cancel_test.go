@@ -0,0 +1,82 @@
+// Copyright (c) Jim Lambert
+// SPDX-License-Identifier: MIT
+
+package gldap
+
+import (
+	"testing"
+
+	ber "github.com/go-asn1-ber/asn1-ber"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeCancelRequestValue(t *testing.T) {
+	t.Parallel()
+	encode := func(children ...*ber.Packet) []byte {
+		seq := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "cancelRequestValue")
+		for _, c := range children {
+			seq.AppendChild(c)
+		}
+		return seq.Bytes()
+	}
+	tests := []struct {
+		name            string
+		value           []byte
+		want            int64
+		wantErr         bool
+		wantErrIs       error
+		wantErrContains string
+	}{
+		{
+			name:  "valid",
+			value: encode(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagInteger, int64(42), "cancelID")),
+			want:  42,
+		},
+		{
+			name:            "missing-value",
+			wantErr:         true,
+			wantErrIs:       ErrInvalidParameter,
+			wantErrContains: "missing request value",
+		},
+		{
+			name:            "not-ber",
+			value:           []byte{0x30},
+			wantErr:         true,
+			wantErrIs:       ErrInvalidParameter,
+			wantErrContains: "unable to decode request value",
+		},
+		{
+			name:            "missing-cancel-id",
+			value:           encode(),
+			wantErr:         true,
+			wantErrIs:       ErrInvalidParameter,
+			wantErrContains: "missing/invalid cancel id",
+		},
+		{
+			name:            "invalid-cancel-id",
+			value:           encode(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "42", "cancelID")),
+			wantErr:         true,
+			wantErrIs:       ErrInvalidParameter,
+			wantErrContains: "missing/invalid cancel id",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert, require := assert.New(t), require.New(t)
+			got, err := DecodeCancelRequestValue(tc.value)
+			if tc.wantErr {
+				require.Error(err)
+				if tc.wantErrIs != nil {
+					assert.ErrorIs(err, tc.wantErrIs)
+				}
+				if tc.wantErrContains != "" {
+					assert.Contains(err.Error(), tc.wantErrContains)
+				}
+				return
+			}
+			require.NoError(err)
+			assert.Equal(tc.want, got)
+		})
+	}
+}
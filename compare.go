@@ -0,0 +1,78 @@
+// Copyright (c) Jim Lambert
+// SPDX-License-Identifier: MIT
+
+package gldap
+
+import (
+	"fmt"
+
+	ber "github.com/go-asn1-ber/asn1-ber"
+)
+
+// CompareMessage is a compare request message as defined in
+// https://datatracker.ietf.org/doc/html/rfc4511#section-4.10
+type CompareMessage struct {
+	baseMessage
+	// DN identifies the entry being compared
+	DN string
+	// Assertion is the attribute value assertion to compare against the entry
+	Assertion AttributeValueAssertion
+	// Controls hold optional controls to send with the request
+	Controls []Control
+}
+
+// AttributeValueAssertion is an attribute description and an assertion value
+// as defined in https://datatracker.ietf.org/doc/html/rfc4511#section-4.1.8
+type AttributeValueAssertion struct {
+	// AttributeDesc is the attribute description (name)
+	AttributeDesc string
+	// AssertionValue is the value to be asserted
+	AssertionValue string
+}
+
+func (a *AttributeValueAssertion) encode() *ber.Packet {
+	seq := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "AttributeValueAssertion")
+	seq.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, a.AttributeDesc, "AttributeDesc"))
+	seq.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, a.AssertionValue, "AssertionValue"))
+	return seq
+}
+
+func decodeAttributeValueAssertion(berPacket *ber.Packet) (*AttributeValueAssertion, error) {
+	const op = "gldap.decodeAttributeValueAssertion"
+	const (
+		childAttributeDesc  = 0
+		childAssertionValue = 1
+	)
+	if berPacket == nil {
+		return nil, fmt.Errorf("%s: missing ber packet: %w", op, ErrInvalidParameter)
+	}
+	seq := &packet{
+		Packet: berPacket,
+	}
+	if err := seq.assert(ber.ClassUniversal, ber.TypePrimitive, withTag(ber.TagOctetString), withAssertChild(childAttributeDesc)); err != nil {
+		return nil, fmt.Errorf("%s: missing/invalid attribute description: %w", op, ErrInvalidParameter)
+	}
+	if err := seq.assert(ber.ClassUniversal, ber.TypePrimitive, withTag(ber.TagOctetString), withAssertChild(childAssertionValue)); err != nil {
+		return nil, fmt.Errorf("%s: missing/invalid assertion value: %w", op, ErrInvalidParameter)
+	}
+	return &AttributeValueAssertion{
+		AttributeDesc:  seq.Children[childAttributeDesc].Data.String(),
+		AssertionValue: seq.Children[childAssertionValue].Data.String(),
+	}, nil
+}
+
+// CompareResponse is a response to a compare request.
+type CompareResponse struct {
+	*GeneralResponse
+}
+
+// SetCompareResult is a convenience func which sets the response's result
+// code to either ResultCompareTrue or ResultCompareFalse
+func (r *CompareResponse) SetCompareResult(match bool) {
+	switch match {
+	case true:
+		r.SetResultCode(ResultCompareTrue)
+	default:
+		r.SetResultCode(ResultCompareFalse)
+	}
+}
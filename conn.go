@@ -6,6 +6,8 @@ package gldap
 import (
 	"bufio"
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"errors"
 	"fmt"
 	"io"
@@ -32,6 +34,18 @@ type conn struct {
 	reader   *bufio.Reader
 	writer   *bufio.Writer
 	writerMu sync.Mutex // shared lock across all ResponseWriter's to prevent write data races
+
+	stateMu  sync.Mutex // mutex for the conn's bind state (mu is held while reading requests)
+	saslBind *saslBindState
+	authzID  string // authorization identity of the conn's last successful bind
+
+	requestsMu sync.Mutex                 // mutex for the conn's in-flight requests
+	requests   map[int64]*inFlightRequest // in-flight requests by message ID
+
+	pagingMu         sync.Mutex               // mutex for the conn's paging cursors
+	pagingCursors    map[string]*pagingCursor // paged search cursors by cookie
+	pagingSeq        uint64                   // sequence of the last stored paging cursor
+	maxPagingCursors int                      // maximum number of paging cursors (see: WithMaxPagingCursors)
 }
 
 // newConn will create a new Conn from an accepted net.Conn which will be used
@@ -71,6 +85,14 @@ func newConn(shutdownCtx context.Context, connID int, netConn net.Conn, logger h
 func (c *conn) serveRequests() error {
 	const op = "gldap.serveRequests"
 
+	// connCtx is the parent of every request's context and it's cancelled
+	// when the server is stopped or we stop serving requests for the conn
+	// (the client disconnected, unbind, etc)
+	connCtx, connCancel := context.WithCancel(c.shutdownCtx)
+	defer connCancel()
+	// paged search cursors are dropped when the client disconnects
+	defer c.resetPaging()
+
 	requestID := 0
 	for {
 		requestID++
@@ -110,6 +132,8 @@ func (c *conn) serveRequests() error {
 			}
 			return fmt.Errorf("%s: error reading request: %w", op, err)
 		}
+		r.initContext(connCtx)
+		w.ctx = r.ctx
 
 		switch {
 		// TODO: rate limit in-flight requests per conn and send a
@@ -118,31 +142,66 @@ func (c *conn) serveRequests() error {
 
 		case r.routeOp == unbindRouteOperation:
 			// support an optional unbind route
-			if c.router.unbindRoute != nil {
-				c.router.unbindRoute.handler()(w, r)
-			}
+			c.router.serveUnbind(w, r)
+			c.resetBind()
+			r.cancel(nil)
 			// stop serving requests when UnbindRequest is received
 			return nil
 
+		// Abandon requests are handled by the conn and there's no response.
+		// see: https://datatracker.ietf.org/doc/html/rfc4511#section-4.11
+		case r.routeOp == abandonRouteOperation:
+			if m, ok := r.message.(*AbandonMessage); ok {
+				c.abandonRequest(m.MessageID)
+			}
+			r.cancel(nil)
+
+		// Extended requests with an invalid requestValue get a protocolError
+		// response, and the connection is still usable.
+		case isInvalidExtendedOperation(r):
+			c.logger.Debug("invalid extended operation request value", "op", op, "conn", c.connID, "requestID", w.requestID, "err", r.message.(*ExtendedOperationMessage).valueErr)
+			resp := r.NewExtendedResponse(WithResponseCode(ResultProtocolError))
+			resp.SetDiagnosticMessage("invalid extended operation request value")
+			if err := w.Write(resp); err != nil {
+				return fmt.Errorf("%s: %w", op, err)
+			}
+			r.cancel(nil)
+
 		// If it's a StartTLS request, then we can't dispatch it concurrently,
 		// since the conn needs to complete it's TLS negotiation before handling
 		// any other requests.
 		// see: https://datatracker.ietf.org/doc/html/rfc4511#section-4.14.1
 		case r.extendedName == ExtendedOperationStartTLS:
 			c.router.serve(w, r)
+			r.cancel(nil)
 		default:
+			inFlight := c.trackRequest(w, r)
 			c.requestsWg.Add(1)
 			go func() {
 				defer func() {
+					c.untrackRequest(inFlight)
 					c.logger.Debug("requestsWg done", "op", op, "conn", c.connID, "requestID", w.requestID)
 					c.requestsWg.Done()
 				}()
+				// Cancel requests are handled by the conn.
+				// see: https://datatracker.ietf.org/doc/html/rfc3909
+				if r.extendedName == ExtendedOperationCancel {
+					c.cancelRequest(w, r)
+					return
+				}
 				c.router.serve(w, r)
 			}()
 		}
 	}
 }
 
+// isInvalidExtendedOperation returns true when the request is an extended
+// operation with an invalid requestValue
+func isInvalidExtendedOperation(r *Request) bool {
+	m, ok := r.message.(*ExtendedOperationMessage)
+	return ok && m.valueErr != nil
+}
+
 func (c *conn) readRequest(requestID int) (*Request, error) {
 	const op = "gldap.(Conn).readRequest"
 
@@ -205,6 +264,107 @@ func (c *conn) initConn(netConn net.Conn) error {
 	return nil
 }
 
+// inFlightRequest is a request which is being handled for the conn
+type inFlightRequest struct {
+	r    *Request
+	w    *ResponseWriter
+	done chan struct{} // closed once the request is finished
+}
+
+// trackRequest adds the request to the conn's in-flight requests, so it can be
+// abandoned or cancelled using its message ID
+func (c *conn) trackRequest(w *ResponseWriter, r *Request) *inFlightRequest {
+	inFlight := &inFlightRequest{
+		r:    r,
+		w:    w,
+		done: make(chan struct{}),
+	}
+	c.requestsMu.Lock()
+	defer c.requestsMu.Unlock()
+	if c.requests == nil {
+		c.requests = map[int64]*inFlightRequest{}
+	}
+	c.requests[r.message.GetID()] = inFlight
+	return inFlight
+}
+
+// untrackRequest removes the request from the conn's in-flight requests once
+// its handler has returned.  Cancelled requests that haven't written a
+// response are finished with a ResultCanceled response.
+func (c *conn) untrackRequest(inFlight *inFlightRequest) {
+	const op = "gldap.(Conn).untrackRequest"
+	defer close(inFlight.done)
+	c.requestsMu.Lock()
+	if c.requests[inFlight.r.message.GetID()] == inFlight {
+		delete(c.requests, inFlight.r.message.GetID())
+	}
+	c.requestsMu.Unlock()
+
+	canceled := errors.Is(context.Cause(inFlight.r.ctx), ErrCanceled)
+	inFlight.r.cancel(nil)
+	if !canceled {
+		return
+	}
+	c.writerMu.Lock()
+	responded := inFlight.w.responded
+	c.writerMu.Unlock()
+	if !responded {
+		if err := inFlight.w.Write(newCanceledResponse(inFlight.r)); err != nil {
+			c.logger.Error("error writing canceled response", "op", op, "conn", c.connID, "requestID", inFlight.r.ID, "err", err)
+		}
+	}
+}
+
+// abandonRequest cancels the in-flight request with the message ID and drops
+// its paged search cursor.  Canceling is a no-op when there's no matching
+// in-flight request, since it may have already completed.
+func (c *conn) abandonRequest(messageID int64) {
+	const op = "gldap.(Conn).abandonRequest"
+	// drop the cursors after the request is cancelled, so it can't store a
+	// cursor afterwards (see: storePagingCursor)
+	defer c.dropPagingCursors(messageID)
+	c.requestsMu.Lock()
+	inFlight, ok := c.requests[messageID]
+	switch {
+	case !ok:
+		c.requestsMu.Unlock()
+		c.logger.Debug("no in-flight request to abandon", "op", op, "conn", c.connID, "messageID", messageID)
+		return
+	case inFlight.r.routeOp == bindRouteOperation:
+		// bind requests can't be abandoned.
+		// see: https://datatracker.ietf.org/doc/html/rfc4511#section-4.11
+		c.requestsMu.Unlock()
+		c.logger.Debug("bind requests can't be abandoned", "op", op, "conn", c.connID, "messageID", messageID)
+		return
+	}
+	delete(c.requests, messageID)
+	c.requestsMu.Unlock()
+	c.logger.Debug("abandoning request", "op", op, "conn", c.connID, "messageID", messageID, "requestID", inFlight.r.ID)
+	inFlight.r.cancel(ErrAbandoned)
+}
+
+// resetBind resets the conn to anonymous and clears any in-progress SASL bind
+func (c *conn) resetBind() {
+	c.stateMu.Lock()
+	defer c.stateMu.Unlock()
+	c.authzID = ""
+	c.saslBind = nil
+}
+
+// peerCertificates returns the verified client certificate chain (leaf first)
+// for the connection or nil if there isn't one.
+func (c *conn) peerCertificates() []*x509.Certificate {
+	tlsConn, ok := c.netConn.(*tls.Conn)
+	if !ok {
+		return nil
+	}
+	state := tlsConn.ConnectionState()
+	if len(state.VerifiedChains) == 0 {
+		return nil
+	}
+	return state.VerifiedChains[0]
+}
+
 func (c *conn) close() error {
 	const op = "gldap.(Conn).close"
 	c.requestsWg.Wait()
@@ -4,10 +4,15 @@
 package gldap
 
 import (
+	"bufio"
+	"bytes"
 	"context"
+	"fmt"
 	"net"
 	"testing"
+	"time"
 
+	ber "github.com/go-asn1-ber/asn1-ber"
 	"github.com/hashicorp/go-hclog"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -152,3 +157,487 @@ func Test_initConn(t *testing.T) {
 		})
 	}
 }
+
+func Test_conn_abandon(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+	server, client := net.Pipe()
+	t.Cleanup(func() { server.Close(); client.Close() })
+
+	mux, err := NewMux()
+	require.NoError(err)
+	searchErr := make(chan error, 1)
+	require.NoError(mux.Search(func(w *ResponseWriter, r *Request) {
+		for i := 0; ; i++ {
+			entry := r.NewSearchResponseEntry(fmt.Sprintf("cn=entry-%d,dc=example,dc=org", i))
+			if err := w.Write(entry); err != nil {
+				searchErr <- err
+				return
+			}
+		}
+	}))
+
+	c, err := newConn(context.Background(), 1, server, hclog.NewNullLogger(), mux)
+	require.NoError(err)
+	served := make(chan error, 1)
+	go func() { served <- c.serveRequests() }()
+
+	search := testSearchRequestPacket(t, SearchMessage{
+		baseMessage: baseMessage{id: 1},
+		BaseDN:      "dc=example,dc=org",
+		Scope:       WholeSubtree,
+		Filter:      "(objectClass=*)",
+	})
+	_, err = client.Write(search.Bytes())
+	require.NoError(err)
+
+	// wait for the search to start streaming entries
+	p, err := ber.ReadPacket(client)
+	require.NoError(err)
+	assert.Equal(ber.Tag(ApplicationSearchResultEntry), p.Children[1].Tag)
+
+	// keep draining the entries written before the abandon was processed
+	go func() {
+		for {
+			if _, err := ber.ReadPacket(client); err != nil {
+				return
+			}
+		}
+	}()
+
+	abandon := testAbandonRequestPacket(t, AbandonMessage{
+		baseMessage: baseMessage{id: 2},
+		MessageID:   1,
+	})
+	_, err = client.Write(abandon.Bytes())
+	require.NoError(err)
+
+	select {
+	case err := <-searchErr:
+		assert.ErrorIs(err, ErrAbandoned)
+	case <-time.After(5 * time.Second):
+		t.Fatal("search was not abandoned")
+	}
+
+	unbind := testUnbindRequestPacket(t, UnbindMessage{baseMessage: baseMessage{id: 3}})
+	_, err = client.Write(unbind.Bytes())
+	require.NoError(err)
+	require.NoError(<-served)
+
+	c.requestsMu.Lock()
+	defer c.requestsMu.Unlock()
+	assert.Empty(c.requests)
+}
+
+func Test_conn_requestContext(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name       string
+		timeLimit  int64
+		disconnect bool
+		shutdown   bool
+		wantErr    error
+	}{
+		{
+			name:       "client-disconnect",
+			disconnect: true,
+			wantErr:    context.Canceled,
+		},
+		{
+			name:     "server-shutdown",
+			shutdown: true,
+			wantErr:  context.Canceled,
+		},
+		{
+			name:      "search-time-limit",
+			timeLimit: 1,
+			wantErr:   context.DeadlineExceeded,
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			assert, require := assert.New(t), require.New(t)
+			server, client := net.Pipe()
+			t.Cleanup(func() { server.Close(); client.Close() })
+
+			mux, err := NewMux()
+			require.NoError(err)
+			started := make(chan struct{})
+			ctxErr := make(chan error, 1)
+			require.NoError(mux.Search(func(w *ResponseWriter, r *Request) {
+				close(started)
+				<-r.Context().Done()
+				ctxErr <- r.Context().Err()
+			}))
+
+			shutdownCtx, shutdownCancel := context.WithCancel(context.Background())
+			defer shutdownCancel()
+			c, err := newConn(shutdownCtx, 1, server, hclog.NewNullLogger(), mux)
+			require.NoError(err)
+			go func() { _ = c.serveRequests() }()
+
+			search := testSearchRequestPacket(t, SearchMessage{
+				baseMessage: baseMessage{id: 1},
+				BaseDN:      "dc=example,dc=org",
+				Scope:       WholeSubtree,
+				TimeLimit:   tc.timeLimit,
+				Filter:      "(objectClass=*)",
+			})
+			_, err = client.Write(search.Bytes())
+			require.NoError(err)
+			<-started
+
+			switch {
+			case tc.disconnect:
+				require.NoError(client.Close())
+			case tc.shutdown:
+				shutdownCancel()
+			}
+			select {
+			case err := <-ctxErr:
+				assert.ErrorIs(err, tc.wantErr)
+			case <-time.After(5 * time.Second):
+				t.Fatal("request context was not cancelled")
+			}
+		})
+	}
+}
+
+func Test_conn_cancel(t *testing.T) {
+	t.Parallel()
+	search := func(t *testing.T) *packet {
+		return testSearchRequestPacket(t, SearchMessage{
+			baseMessage: baseMessage{id: 1},
+			BaseDN:      "dc=example,dc=org",
+			Scope:       WholeSubtree,
+			Filter:      "(objectClass=*)",
+		})
+	}
+	type response struct {
+		messageID int64
+		code      int64
+	}
+	tests := []struct {
+		name     string
+		register func(t *testing.T, mux *Mux, started, release chan struct{})
+		request  func(t *testing.T) *packet
+		cancelID int64
+		want     []response
+		// releaseAt is the index of the wanted response which requires blocked
+		// handlers to be released (they're released after all the responses
+		// by default)
+		releaseAt int
+	}{
+		{
+			name: "canceled-without-response",
+			register: func(t *testing.T, mux *Mux, started, release chan struct{}) {
+				require.NoError(t, mux.Search(func(w *ResponseWriter, r *Request) {
+					close(started)
+					<-r.Context().Done()
+				}))
+			},
+			request:  search,
+			cancelID: 1,
+			want:     []response{{1, ResultCanceled}, {2, ResultSuccess}},
+		},
+		{
+			name: "canceled-with-response",
+			register: func(t *testing.T, mux *Mux, started, release chan struct{}) {
+				require.NoError(t, mux.Search(func(w *ResponseWriter, r *Request) {
+					close(started)
+					<-r.Context().Done()
+					_ = w.Write(r.NewSearchResponseEntry("cn=alice,dc=example,dc=org"))
+					_ = w.Write(r.NewSearchDoneResponse(WithResponseCode(ResultSuccess)))
+				}))
+			},
+			request:  search,
+			cancelID: 1,
+			want:     []response{{1, ResultCanceled}, {2, ResultSuccess}},
+		},
+		{
+			name:     "no-such-operation",
+			cancelID: 1,
+			want:     []response{{2, ResultNoSuchOperation}},
+		},
+		{
+			name: "cannot-cancel-bind",
+			register: func(t *testing.T, mux *Mux, started, release chan struct{}) {
+				require.NoError(t, mux.Bind(func(w *ResponseWriter, r *Request) {
+					close(started)
+					<-release
+					_ = w.Write(r.NewBindResponse(WithResponseCode(ResultSuccess)))
+				}))
+			},
+			request: func(t *testing.T) *packet {
+				return testSimpleBindRequestPacket(t, SimpleBindMessage{baseMessage: baseMessage{id: 1}, UserName: "alice", Password: "fido"})
+			},
+			cancelID:  1,
+			want:      []response{{2, ResultCannotCancel}, {1, ResultSuccess}},
+			releaseAt: 1,
+		},
+		{
+			name: "too-late",
+			register: func(t *testing.T, mux *Mux, started, release chan struct{}) {
+				require.NoError(t, mux.Search(func(w *ResponseWriter, r *Request) {
+					_ = w.Write(r.NewSearchDoneResponse(WithResponseCode(ResultSuccess)))
+					close(started)
+					<-release
+				}))
+			},
+			request:  search,
+			cancelID: 1,
+			want:     []response{{1, ResultSuccess}, {2, ResultTooLate}},
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			assert, require := assert.New(t), require.New(t)
+			server, client := net.Pipe()
+			t.Cleanup(func() { server.Close(); client.Close() })
+
+			mux, err := NewMux()
+			require.NoError(err)
+			started, release := make(chan struct{}), make(chan struct{})
+			if tc.register != nil {
+				tc.register(t, mux, started, release)
+			}
+			c, err := newConn(context.Background(), 1, server, hclog.NewNullLogger(), mux)
+			require.NoError(err)
+			go func() { _ = c.serveRequests() }()
+
+			got := make(chan response, len(tc.want))
+			go func() {
+				for {
+					p, err := ber.ReadPacket(client)
+					if err != nil {
+						return
+					}
+					got <- response{
+						messageID: p.Children[0].Value.(int64),
+						code:      p.Children[1].Children[0].Value.(int64),
+					}
+				}
+			}()
+
+			if tc.request != nil {
+				_, err = client.Write(tc.request(t).Bytes())
+				require.NoError(err)
+				<-started
+			}
+			cancel := testCancelRequestPacket(t, CancelMessage{
+				baseMessage: baseMessage{id: 2},
+				Value:       testCancelRequestValue(t, tc.cancelID),
+			})
+			_, err = client.Write(cancel.Bytes())
+			require.NoError(err)
+
+			if tc.releaseAt == 0 {
+				defer close(release)
+			}
+			for i, want := range tc.want {
+				if i > 0 && i == tc.releaseAt {
+					close(release)
+				}
+				select {
+				case resp := <-got:
+					assert.Equal(want, resp)
+				case <-time.After(5 * time.Second):
+					t.Fatalf("missing response %d", i)
+				}
+			}
+		})
+	}
+}
+
+func Test_conn_cancel_invalidValue(t *testing.T) {
+	t.Parallel()
+	assert, require := assert.New(t), require.New(t)
+	server, client := net.Pipe()
+	t.Cleanup(func() { server.Close(); client.Close() })
+
+	mux, err := NewMux()
+	require.NoError(err)
+	require.NoError(mux.Search(func(w *ResponseWriter, r *Request) {
+		_ = w.Write(r.NewSearchDoneResponse(WithResponseCode(ResultSuccess)))
+	}))
+	c, err := newConn(context.Background(), 1, server, hclog.NewNullLogger(), mux)
+	require.NoError(err)
+	go func() { _ = c.serveRequests() }()
+
+	readResponse := func() (int64, int64) {
+		t.Helper()
+		type response struct{ messageID, code int64 }
+		got := make(chan response, 1)
+		go func() {
+			p, err := ber.ReadPacket(client)
+			if err != nil {
+				return
+			}
+			got <- response{p.Children[0].Value.(int64), p.Children[1].Children[0].Value.(int64)}
+		}()
+		select {
+		case resp := <-got:
+			return resp.messageID, resp.code
+		case <-time.After(5 * time.Second):
+			t.Fatal("missing response")
+			return 0, 0
+		}
+	}
+
+	cancel := testCancelRequestPacket(t, CancelMessage{
+		baseMessage: baseMessage{id: 1},
+		Value:       []byte("garbage"),
+	})
+	_, err = client.Write(cancel.Bytes())
+	require.NoError(err)
+	messageID, code := readResponse()
+	assert.Equal(int64(1), messageID)
+	assert.Equal(int64(ResultProtocolError), code)
+
+	// the connection is still usable after the protocol error
+	search := testSearchRequestPacket(t, SearchMessage{
+		baseMessage: baseMessage{id: 2},
+		BaseDN:      "dc=example,dc=org",
+		Scope:       WholeSubtree,
+		Filter:      "(objectClass=*)",
+	})
+	_, err = client.Write(search.Bytes())
+	require.NoError(err)
+	messageID, code = readResponse()
+	assert.Equal(int64(2), messageID)
+	assert.Equal(int64(ResultSuccess), code)
+}
+
+func Test_conn_cancelRequest_done(t *testing.T) {
+	t.Parallel()
+	newRequest := func(t *testing.T, c *conn, r *Request) (*ResponseWriter, *bytes.Buffer) {
+		t.Helper()
+		var buf bytes.Buffer
+		w, err := newResponseWriter(bufio.NewWriter(&buf), &c.writerMu, hclog.NewNullLogger(), 1, int(r.message.GetID()))
+		require.NoError(t, err)
+		r.initContext(context.Background())
+		return w, &buf
+	}
+	newCancel := func(t *testing.T, c *conn, cancelID int64) (*ResponseWriter, *Request, *bytes.Buffer) {
+		t.Helper()
+		r := &Request{
+			conn:         c,
+			routeOp:      extendedRouteOperation,
+			extendedName: ExtendedOperationCancel,
+			message:      &CancelMessage{baseMessage: baseMessage{id: 2}, Value: testCancelRequestValue(t, cancelID)},
+		}
+		w, buf := newRequest(t, c, r)
+		return w, r, buf
+	}
+
+	t.Run("done-before-cancel", func(t *testing.T) {
+		c := &conn{logger: hclog.NewNullLogger()}
+		w, r, buf := newCancel(t, c, 1)
+		r.cancel(ErrAbandoned)
+		c.cancelRequest(w, r)
+		assert.Zero(t, buf.Len())
+	})
+	t.Run("done-while-waiting", func(t *testing.T) {
+		assert, require := assert.New(t), require.New(t)
+		c := &conn{logger: hclog.NewNullLogger()}
+
+		// the target never finishes, so the cancel request waits until its own
+		// context is done
+		target := &Request{
+			conn:    c,
+			routeOp: searchRouteOperation,
+			message: &SearchMessage{baseMessage: baseMessage{id: 1}},
+		}
+		targetW, _ := newRequest(t, c, target)
+		c.trackRequest(targetW, target)
+
+		w, r, buf := newCancel(t, c, 1)
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			c.cancelRequest(w, r)
+		}()
+		select {
+		case <-target.Context().Done():
+		case <-time.After(5 * time.Second):
+			require.FailNow("target wasn't cancelled")
+		}
+		r.cancel(ErrAbandoned)
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			require.FailNow("cancel request didn't return")
+		}
+		assert.Zero(buf.Len())
+	})
+}
+
+func Test_conn_extendedOperation_invalidValue(t *testing.T) {
+	t.Parallel()
+	assert, require := assert.New(t), require.New(t)
+	server, client := net.Pipe()
+	t.Cleanup(func() { server.Close(); client.Close() })
+
+	mux, err := NewMux()
+	require.NoError(err)
+	var routed bool
+	require.NoError(mux.ExtendedOperation(func(w *ResponseWriter, r *Request) {
+		routed = true
+		_ = w.Write(r.NewExtendedResponse(WithResponseCode(ResultSuccess)))
+	}, ExtendedOperationPasswordModify))
+	require.NoError(mux.Search(func(w *ResponseWriter, r *Request) {
+		_ = w.Write(r.NewSearchDoneResponse(WithResponseCode(ResultSuccess)))
+	}))
+	c, err := newConn(context.Background(), 1, server, hclog.NewNullLogger(), mux)
+	require.NoError(err)
+	go func() { _ = c.serveRequests() }()
+
+	readResponse := func() (int64, int64) {
+		t.Helper()
+		type response struct{ messageID, code int64 }
+		got := make(chan response, 1)
+		go func() {
+			p, err := ber.ReadPacket(client)
+			if err != nil {
+				return
+			}
+			got <- response{p.Children[0].Value.(int64), p.Children[1].Children[0].Value.(int64)}
+		}()
+		select {
+		case resp := <-got:
+			return resp.messageID, resp.code
+		case <-time.After(5 * time.Second):
+			t.Fatal("missing response")
+			return 0, 0
+		}
+	}
+
+	// a request value with the wrong tag is invalid
+	extended := testRequestEnvelope(t, 1)
+	request := ber.Encode(ber.ClassApplication, ber.TypeConstructed, ApplicationExtendedRequest, nil, "Extended Request")
+	request.AppendChild(ber.NewString(ber.ClassContext, ber.TypePrimitive, 0, string(ExtendedOperationPasswordModify), "Request Name"))
+	request.AppendChild(ber.NewString(ber.ClassContext, ber.TypePrimitive, 2, "value", "Request Value"))
+	extended.AppendChild(request)
+	_, err = client.Write(extended.Bytes())
+	require.NoError(err)
+	messageID, code := readResponse()
+	assert.Equal(int64(1), messageID)
+	assert.Equal(int64(ResultProtocolError), code)
+	assert.False(routed)
+
+	// the connection is still usable after the protocol error
+	search := testSearchRequestPacket(t, SearchMessage{
+		baseMessage: baseMessage{id: 2},
+		BaseDN:      "dc=example,dc=org",
+		Scope:       WholeSubtree,
+		Filter:      "(objectClass=*)",
+	})
+	_, err = client.Write(search.Bytes())
+	require.NoError(err)
+	messageID, code = readResponse()
+	assert.Equal(int64(2), messageID)
+	assert.Equal(int64(ResultSuccess), code)
+}
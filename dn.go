@@ -0,0 +1,174 @@
+// Copyright (c) Jim Lambert
+// SPDX-License-Identifier: MIT
+
+package gldap
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+// DN is a parsed distinguished name.  Its RDNs are ordered from the entry's
+// RDN to the RDN closest to the root (which is the order of its string
+// representation).
+// see: https://datatracker.ietf.org/doc/html/rfc4514
+type DN struct {
+	RDNs []*RDN
+}
+
+// RDN is a relative distinguished name, which may be multi-valued (for
+// example: "cn=alice+uid=alice")
+type RDN struct {
+	Attributes []*AttributeTypeAndValue
+}
+
+// AttributeTypeAndValue is a single attribute type and value of an RDN
+type AttributeTypeAndValue struct {
+	// Type is the attribute's type (for example: "cn")
+	Type string
+	// Value is the attribute's unescaped value
+	Value string
+}
+
+// ParseDN parses a distinguished name's string representation, including
+// escaped characters, hex encoded values and multi-valued RDNs.  An empty
+// string is parsed as the empty (root) DN.
+func ParseDN(dn string) (*DN, error) {
+	const op = "gldap.ParseDN"
+	parsed, err := ldap.ParseDN(dn)
+	if err != nil {
+		return nil, fmt.Errorf("%s: unable to parse dn %q: %s: %w", op, dn, err, ErrInvalidParameter)
+	}
+	d := &DN{RDNs: make([]*RDN, 0, len(parsed.RDNs))}
+	for _, parsedRDN := range parsed.RDNs {
+		rdn := &RDN{Attributes: make([]*AttributeTypeAndValue, 0, len(parsedRDN.Attributes))}
+		for _, a := range parsedRDN.Attributes {
+			rdn.Attributes = append(rdn.Attributes, &AttributeTypeAndValue{Type: a.Type, Value: a.Value})
+		}
+		d.RDNs = append(d.RDNs, rdn)
+	}
+	return d, nil
+}
+
+// String returns the DN's string representation with any special characters
+// in its values escaped
+func (d *DN) String() string {
+	rdns := make([]string, 0, len(d.RDNs))
+	for _, rdn := range d.RDNs {
+		rdns = append(rdns, rdn.String())
+	}
+	return strings.Join(rdns, ",")
+}
+
+// Normalize returns the DN's normalized string representation, which can be
+// used to compare DNs.  Attribute types and values are lower cased,
+// insignificant whitespace is removed and multi-valued RDNs are sorted.
+func (d *DN) Normalize() string {
+	rdns := make([]string, 0, len(d.RDNs))
+	for _, rdn := range d.RDNs {
+		rdns = append(rdns, rdn.Normalize())
+	}
+	return strings.Join(rdns, ",")
+}
+
+// Equal returns true when the DNs are equal once they're normalized
+func (d *DN) Equal(other *DN) bool {
+	if d == nil || other == nil {
+		return d == other
+	}
+	return d.Normalize() == other.Normalize()
+}
+
+// IsEmpty returns true when the DN is the empty (root) DN
+func (d *DN) IsEmpty() bool {
+	return len(d.RDNs) == 0
+}
+
+// Parent returns the DN's immediate parent or nil when the DN is empty.
+func (d *DN) Parent() *DN {
+	if d.IsEmpty() {
+		return nil
+	}
+	return &DN{RDNs: d.RDNs[1:]}
+}
+
+// IsChildOf returns true when the DN is an immediate child of the parent
+func (d *DN) IsChildOf(parent *DN) bool {
+	if parent == nil || len(d.RDNs) != len(parent.RDNs)+1 {
+		return false
+	}
+	return d.Parent().Equal(parent)
+}
+
+// IsDescendantOf returns true when the DN is below the ancestor (at any
+// depth).  A DN is not a descendant of itself.
+func (d *DN) IsDescendantOf(ancestor *DN) bool {
+	if ancestor == nil || len(d.RDNs) <= len(ancestor.RDNs) {
+		return false
+	}
+	return (&DN{RDNs: d.RDNs[len(d.RDNs)-len(ancestor.RDNs):]}).Equal(ancestor)
+}
+
+// String returns the RDN's string representation with any special characters
+// in its values escaped
+func (r *RDN) String() string {
+	attrs := make([]string, 0, len(r.Attributes))
+	for _, a := range r.Attributes {
+		attrs = append(attrs, a.Type+"="+escapeDNValue(a.Value))
+	}
+	return strings.Join(attrs, "+")
+}
+
+// Normalize returns the RDN's normalized string representation (see:
+// DN.Normalize)
+func (r *RDN) Normalize() string {
+	attrs := make([]string, 0, len(r.Attributes))
+	for _, a := range r.Attributes {
+		attrs = append(attrs, strings.ToLower(strings.TrimSpace(a.Type))+"="+escapeDNValue(normalizeValue(a.Value)))
+	}
+	sort.Strings(attrs)
+	return strings.Join(attrs, "+")
+}
+
+// Equal returns true when the RDNs are equal once they're normalized
+func (r *RDN) Equal(other *RDN) bool {
+	if r == nil || other == nil {
+		return r == other
+	}
+	return r.Normalize() == other.Normalize()
+}
+
+// escapeDNValue escapes an attribute value for a DN's string representation.
+// see: https://datatracker.ietf.org/doc/html/rfc4514#section-2.4
+func escapeDNValue(v string) string {
+	var b strings.Builder
+	for i := 0; i < len(v); i++ {
+		c := v[i]
+		switch {
+		case c == 0:
+			b.WriteString(`\00`)
+		case strings.IndexByte(`"+,;<>\`, c) >= 0,
+			i == 0 && (c == ' ' || c == '#'),
+			i == len(v)-1 && c == ' ':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// equalDNs compares the string representations of two DNs, which falls back to
+// a case-insensitive comparison when either of them can't be parsed.
+func equalDNs(a, b string) bool {
+	aDN, aErr := ParseDN(a)
+	bDN, bErr := ParseDN(b)
+	if aErr != nil || bErr != nil {
+		return strings.EqualFold(a, b)
+	}
+	return aDN.Equal(bDN)
+}
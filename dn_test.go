@@ -0,0 +1,195 @@
+// Copyright (c) Jim Lambert
+// SPDX-License-Identifier: MIT
+
+package gldap
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDN(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name            string
+		dn              string
+		want            *DN
+		wantString      string
+		wantNormalized  string
+		wantErr         bool
+		wantErrContains string
+	}{
+		{
+			name:       "empty",
+			dn:         "",
+			want:       &DN{RDNs: []*RDN{}},
+			wantString: "",
+		},
+		{
+			name: "simple",
+			dn:   "uid=alice,ou=people,dc=example,dc=org",
+			want: &DN{RDNs: []*RDN{
+				{Attributes: []*AttributeTypeAndValue{{Type: "uid", Value: "alice"}}},
+				{Attributes: []*AttributeTypeAndValue{{Type: "ou", Value: "people"}}},
+				{Attributes: []*AttributeTypeAndValue{{Type: "dc", Value: "example"}}},
+				{Attributes: []*AttributeTypeAndValue{{Type: "dc", Value: "org"}}},
+			}},
+			wantString:     "uid=alice,ou=people,dc=example,dc=org",
+			wantNormalized: "uid=alice,ou=people,dc=example,dc=org",
+		},
+		{
+			name: "whitespace-and-case",
+			dn:   "CN=Alice  Smith , DC=Example",
+			want: &DN{RDNs: []*RDN{
+				{Attributes: []*AttributeTypeAndValue{{Type: "CN", Value: "Alice  Smith"}}},
+				{Attributes: []*AttributeTypeAndValue{{Type: "DC", Value: "Example"}}},
+			}},
+			wantString:     "CN=Alice  Smith,DC=Example",
+			wantNormalized: "cn=alice smith,dc=example",
+		},
+		{
+			name: "escaped",
+			dn:   `cn=Smith\, Alice,cn=\#admins\+ops\20,dc=example`,
+			want: &DN{RDNs: []*RDN{
+				{Attributes: []*AttributeTypeAndValue{{Type: "cn", Value: "Smith, Alice"}}},
+				{Attributes: []*AttributeTypeAndValue{{Type: "cn", Value: "#admins+ops "}}},
+				{Attributes: []*AttributeTypeAndValue{{Type: "dc", Value: "example"}}},
+			}},
+			wantString:     `cn=Smith\, Alice,cn=\#admins\+ops\ ,dc=example`,
+			wantNormalized: `cn=smith\, alice,cn=\#admins\+ops,dc=example`,
+		},
+		{
+			name: "hex-escaped",
+			dn:   `cn=Zo\c3\ab,dc=example`,
+			want: &DN{RDNs: []*RDN{
+				{Attributes: []*AttributeTypeAndValue{{Type: "cn", Value: "Zoë"}}},
+				{Attributes: []*AttributeTypeAndValue{{Type: "dc", Value: "example"}}},
+			}},
+			wantString:     "cn=Zoë,dc=example",
+			wantNormalized: "cn=zoë,dc=example",
+		},
+		{
+			name: "hex-value",
+			dn:   "cn=#04056164616d73,dc=example",
+			want: &DN{RDNs: []*RDN{
+				{Attributes: []*AttributeTypeAndValue{{Type: "cn", Value: "adams"}}},
+				{Attributes: []*AttributeTypeAndValue{{Type: "dc", Value: "example"}}},
+			}},
+			wantString:     "cn=adams,dc=example",
+			wantNormalized: "cn=adams,dc=example",
+		},
+		{
+			name: "multi-valued",
+			dn:   "uid=alice+cn=Alice,dc=example",
+			want: &DN{RDNs: []*RDN{
+				{Attributes: []*AttributeTypeAndValue{{Type: "uid", Value: "alice"}, {Type: "cn", Value: "Alice"}}},
+				{Attributes: []*AttributeTypeAndValue{{Type: "dc", Value: "example"}}},
+			}},
+			wantString:     "uid=alice+cn=Alice,dc=example",
+			wantNormalized: "cn=alice+uid=alice,dc=example",
+		},
+		{
+			name:            "invalid",
+			dn:              "uid=alice,dc",
+			wantErr:         true,
+			wantErrContains: "unable to parse dn",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert, require := assert.New(t), require.New(t)
+			got, err := ParseDN(tc.dn)
+			if tc.wantErr {
+				require.Error(err)
+				assert.Nil(got)
+				assert.ErrorIs(err, ErrInvalidParameter)
+				assert.Contains(err.Error(), tc.wantErrContains)
+				return
+			}
+			require.NoError(err)
+			assert.Equal(tc.want, got)
+			assert.Equal(tc.wantString, got.String())
+			assert.Equal(tc.wantNormalized, got.Normalize())
+
+			// the string representation should round trip
+			reparsed, err := ParseDN(got.String())
+			require.NoError(err)
+			assert.True(got.Equal(reparsed))
+		})
+	}
+}
+
+func TestDN_Equal(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name string
+		a    string
+		b    string
+		want bool
+	}{
+		{name: "same", a: "cn=foo,dc=x", b: "cn=foo,dc=x", want: true},
+		{name: "case-and-whitespace", a: "cn=Foo, dc=x", b: "CN=foo,DC=x", want: true},
+		{name: "multi-valued-order", a: "cn=foo+uid=bar,dc=x", b: "uid=bar+cn=foo,dc=x", want: true},
+		{name: "escaped-and-hex", a: `cn=a\2cb,dc=x`, b: `cn=a\,b,dc=x`, want: true},
+		{name: "different-value", a: "cn=foo,dc=x", b: "cn=bar,dc=x"},
+		{name: "different-length", a: "cn=foo,dc=x", b: "dc=x"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert, require := assert.New(t), require.New(t)
+			a, err := ParseDN(tc.a)
+			require.NoError(err)
+			b, err := ParseDN(tc.b)
+			require.NoError(err)
+			assert.Equal(tc.want, a.Equal(b))
+			assert.Equal(tc.want, b.Equal(a))
+			assert.Equal(tc.want, equalDNs(tc.a, tc.b))
+		})
+	}
+	t.Run("nil", func(t *testing.T) {
+		d, err := ParseDN("dc=x")
+		require.NoError(t, err)
+		assert.False(t, d.Equal(nil))
+		assert.True(t, (*DN)(nil).Equal(nil))
+	})
+	t.Run("unparsable-falls-back", func(t *testing.T) {
+		assert.True(t, equalDNs("NOT A DN", "not a dn"))
+		assert.False(t, equalDNs("NOT A DN", "dc=x"))
+	})
+}
+
+func TestDN_hierarchy(t *testing.T) {
+	t.Parallel()
+	assert, require := assert.New(t), require.New(t)
+	parse := func(dn string) *DN {
+		d, err := ParseDN(dn)
+		require.NoError(err)
+		return d
+	}
+	alice := parse("uid=alice,ou=People,dc=example,dc=org")
+	people := parse("ou=people, dc=example, dc=org")
+	root := parse("DC=Example,DC=Org")
+	other := parse("ou=groups,dc=example,dc=org")
+	empty := parse("")
+
+	assert.True(alice.Parent().Equal(people))
+	assert.True(people.Parent().Equal(root))
+	assert.True(parse("dc=org").Parent().IsEmpty())
+	assert.Nil(empty.Parent())
+
+	assert.True(alice.IsChildOf(people))
+	assert.False(alice.IsChildOf(root))
+	assert.False(alice.IsChildOf(other))
+	assert.False(alice.IsChildOf(alice))
+	assert.False(alice.IsChildOf(nil))
+
+	assert.True(alice.IsDescendantOf(people))
+	assert.True(alice.IsDescendantOf(root))
+	assert.True(alice.IsDescendantOf(empty))
+	assert.False(alice.IsDescendantOf(alice))
+	assert.False(alice.IsDescendantOf(other))
+	assert.False(root.IsDescendantOf(alice))
+	assert.False(alice.IsDescendantOf(nil))
+}
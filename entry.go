@@ -30,6 +30,17 @@ func (e *Entry) GetAttributeValues(attribute string) []string {
 	return []string{}
 }
 
+// GetAttributeByteValues returns the raw values for the named attribute, or an
+// empty list
+func (e *Entry) GetAttributeByteValues(attribute string) [][]byte {
+	for _, attr := range e.Attributes {
+		if attr.Name == attribute {
+			return attr.ByteValues
+		}
+	}
+	return [][]byte{}
+}
+
 // NewEntry returns an Entry object with the specified distinguished name and attribute key-value pairs.
 // The map of attributes is accessed in alphabetical order of the keys in order to ensure that, for the
 // same input map of attributes, the output entry will contain the same order of attributes
@@ -63,17 +74,25 @@ func (e *Entry) PrettyPrint(indent int, opt ...Option) {
 	}
 }
 
-// PrettyPrint outputs a human-readable description with indenting.  Supported
-// options: WithWriter
+// PrettyPrint outputs a human-readable description with indenting.  The values
+// of binary attributes are hex encoded.  Supported options: WithWriter
 func (e *EntryAttribute) PrettyPrint(indent int, opt ...Option) {
 	opts := getGeneralOpts(opt...)
 	if opts.withWriter == nil {
 		opts.withWriter = os.Stdout
 	}
+	if e.isBinary() {
+		// binary values are hex encoded, since they're not printable
+		fmt.Fprintf(opts.withWriter, "%s%s: %x\n", strings.Repeat(" ", indent), e.Name, e.ByteValues)
+		return
+	}
 	fmt.Fprintf(opts.withWriter, "%s%s: %s\n", strings.Repeat(" ", indent), e.Name, e.Values)
 }
 
-// EntryAttribute holds a single attribute
+// EntryAttribute holds a single attribute.  Binary attributes (for example:
+// objectSid, objectGUID or jpegPhoto) should only have ByteValues (see:
+// NewEntryAttributeBytes), otherwise the string Values are used when the
+// attribute is encoded.
 type EntryAttribute struct {
 	// Name is the name of the attribute
 	Name string
@@ -96,21 +115,44 @@ func NewEntryAttribute(name string, values []string) *EntryAttribute {
 	}
 }
 
-// AddValue to an existing EntryAttribute
+// NewEntryAttributeBytes returns a new binary EntryAttribute with the desired
+// raw values, which only has ByteValues
+func NewEntryAttributeBytes(name string, values [][]byte) *EntryAttribute {
+	return &EntryAttribute{
+		Name:       name,
+		ByteValues: values,
+	}
+}
+
+// AddValue to an existing EntryAttribute.  The values are only added to the
+// ByteValues of a binary attribute (see: NewEntryAttributeBytes).
 func (e *EntryAttribute) AddValue(value ...string) {
+	binary := e.isBinary()
 	for _, v := range value {
 		e.ByteValues = append(e.ByteValues, []byte(v))
-		e.Values = append(e.Values, v)
+		if !binary {
+			e.Values = append(e.Values, v)
+		}
 	}
 }
 
-func (e *EntryAttribute) encode() *ber.Packet {
-	seq := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "Attribute")
-	seq.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, e.Name, "Type"))
-	set := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSet, nil, "AttributeValue")
-	for _, value := range e.Values {
-		set.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, value, "Vals"))
+// AddByteValue adds raw values to an existing EntryAttribute.  The values are
+// also added to the string Values of an attribute which isn't binary.
+func (e *EntryAttribute) AddByteValue(value ...[]byte) {
+	binary := e.isBinary()
+	for _, v := range value {
+		e.ByteValues = append(e.ByteValues, v)
+		if !binary {
+			e.Values = append(e.Values, string(v))
+		}
 	}
-	seq.AppendChild(set)
-	return seq
+}
+
+// isBinary returns true when the attribute only has ByteValues
+func (e *EntryAttribute) isBinary() bool {
+	return len(e.Values) == 0 && len(e.ByteValues) > 0
+}
+
+func (e *EntryAttribute) encode() *ber.Packet {
+	return encodeAttribute("Attribute", e.Name, e.Values, e.ByteValues)
 }
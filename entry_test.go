@@ -7,7 +7,9 @@ import (
 	"strings"
 	"testing"
 
+	ber "github.com/go-asn1-ber/asn1-ber"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestEntry_GetAttributes(t *testing.T) {
@@ -44,6 +46,22 @@ func TestEntry_GetAttributes(t *testing.T) {
 	}
 }
 
+func TestEntry_GetAttributeByteValues(t *testing.T) {
+	t.Parallel()
+	assert := assert.New(t)
+	e := &Entry{
+		DN: "uid=alice",
+		Attributes: []*EntryAttribute{
+			NewEntryAttribute("cn", []string{"alice"}),
+			NewEntryAttributeBytes("objectSid", [][]byte{{0x01, 0x05}}),
+		},
+	}
+	assert.Equal([][]byte{[]byte("alice")}, e.GetAttributeByteValues("cn"))
+	assert.Equal([][]byte{{0x01, 0x05}}, e.GetAttributeByteValues("objectSid"))
+	assert.Empty(e.GetAttributeValues("objectSid"))
+	assert.Equal([][]byte{}, e.GetAttributeByteValues("missing"))
+}
+
 func TestEntryAttribute_AddValue(t *testing.T) {
 	tests := []struct {
 		name   string
@@ -57,6 +75,12 @@ func TestEntryAttribute_AddValue(t *testing.T) {
 			values: []string{"v2", "v3"},
 			want:   NewEntryAttribute("simple", []string{"v1", "v2", "v3"}),
 		},
+		{
+			name:   "binary",
+			attr:   NewEntryAttributeBytes("binary", [][]byte{{0x01}}),
+			values: []string{"v2"},
+			want:   NewEntryAttributeBytes("binary", [][]byte{{0x01}, []byte("v2")}),
+		},
 	}
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
@@ -67,6 +91,74 @@ func TestEntryAttribute_AddValue(t *testing.T) {
 	}
 }
 
+func TestEntryAttribute_AddByteValue(t *testing.T) {
+	tests := []struct {
+		name   string
+		attr   *EntryAttribute
+		values [][]byte
+		want   *EntryAttribute
+	}{
+		{
+			name:   "simple",
+			attr:   NewEntryAttribute("simple", []string{"v1"}),
+			values: [][]byte{[]byte("v2")},
+			want:   NewEntryAttribute("simple", []string{"v1", "v2"}),
+		},
+		{
+			name:   "binary",
+			attr:   NewEntryAttributeBytes("binary", [][]byte{{0x01}}),
+			values: [][]byte{{0x02}, {0x03}},
+			want:   NewEntryAttributeBytes("binary", [][]byte{{0x01}, {0x02}, {0x03}}),
+		},
+		{
+			name:   "empty",
+			attr:   NewEntryAttributeBytes("empty", nil),
+			values: [][]byte{{0x02}},
+			want:   &EntryAttribute{Name: "empty", Values: []string{"\x02"}, ByteValues: [][]byte{{0x02}}},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := assert.New(t)
+			tc.attr.AddByteValue(tc.values...)
+			assert.Equal(tc.want, tc.attr)
+		})
+	}
+}
+
+func TestEntryAttribute_encode(t *testing.T) {
+	tests := []struct {
+		name       string
+		attr       *EntryAttribute
+		wantValues [][]byte
+	}{
+		{
+			name:       "string",
+			attr:       NewEntryAttribute("cn", []string{"alice", "bob"}),
+			wantValues: [][]byte{[]byte("alice"), []byte("bob")},
+		},
+		{
+			name:       "binary",
+			attr:       NewEntryAttributeBytes("objectSid", [][]byte{{0x01, 0x00, 0xff}}),
+			wantValues: [][]byte{{0x01, 0x00, 0xff}},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert, require := assert.New(t), require.New(t)
+			p, err := ber.DecodePacketErr(tc.attr.encode().Bytes())
+			require.NoError(err)
+			require.Len(p.Children, 2)
+			assert.Equal(tc.attr.Name, p.Children[0].Data.String())
+			var got [][]byte
+			for _, v := range p.Children[1].Children {
+				got = append(got, v.Data.Bytes())
+			}
+			assert.Equal(tc.wantValues, got)
+		})
+	}
+}
+
 func TestEntry_PrettyPrint(t *testing.T) {
 	tests := []struct {
 		name   string
@@ -85,6 +177,17 @@ func TestEntry_PrettyPrint(t *testing.T) {
 			writer: new(strings.Builder),
 			want:   " DN: uid=alice\n   cn: [alice]\n",
 		},
+		{
+			name: "binary",
+			entry: &Entry{
+				DN: "uid=alice",
+				Attributes: []*EntryAttribute{
+					NewEntryAttributeBytes("objectSid", [][]byte{{0x01, 0x05}, {0xff}}),
+				},
+			},
+			writer: new(strings.Builder),
+			want:   " DN: uid=alice\n   objectSid: [0105 ff]\n",
+		},
 		{
 			name: "stdout",
 			entry: &Entry{
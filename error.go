@@ -17,4 +17,12 @@ var (
 
 	// ErrInternal is an internal error
 	ErrInternal = errors.New("internal error")
+
+	// ErrAbandoned is an error for requests which have been abandoned by the
+	// client
+	ErrAbandoned = errors.New("abandoned")
+
+	// ErrCanceled is an error for requests which have been cancelled by the
+	// client using a cancel extended operation request
+	ErrCanceled = errors.New("canceled")
 )
@@ -0,0 +1,324 @@
+// Copyright (c) Jim Lambert
+// SPDX-License-Identifier: MIT
+
+package gldap
+
+import (
+	"fmt"
+	"strings"
+
+	ber "github.com/go-asn1-ber/asn1-ber"
+	"github.com/go-ldap/ldap/v3"
+)
+
+// Filter is a parsed search request filter, which is one of: *AndFilter,
+// *OrFilter, *NotFilter, *EqualityFilter, *SubstringsFilter,
+// *GreaterOrEqualFilter, *LessOrEqualFilter, *PresentFilter,
+// *ApproxMatchFilter or *ExtensibleMatchFilter.
+// see: https://datatracker.ietf.org/doc/html/rfc4511#section-4.5.1.7
+type Filter interface {
+	// String returns the filter's string representation (see:
+	// https://datatracker.ietf.org/doc/html/rfc4515)
+	String() string
+
+	// Match returns true when the filter evaluates to TRUE for the entry.  A
+	// filter which evaluates to FALSE or Undefined doesn't match.
+	Match(e *Entry) bool
+
+	evaluate(e *Entry) filterResult
+}
+
+// Search filter choices.
+// see: https://datatracker.ietf.org/doc/html/rfc4511#section-4.5.1.7
+const (
+	filterAnd             = 0
+	filterOr              = 1
+	filterNot             = 2
+	filterEqualityMatch   = 3
+	filterSubstrings      = 4
+	filterGreaterOrEqual  = 5
+	filterLessOrEqual     = 6
+	filterPresent         = 7
+	filterApproxMatch     = 8
+	filterExtensibleMatch = 9
+)
+
+// AndFilter matches when all of its Filters match
+type AndFilter struct {
+	Filters []Filter
+}
+
+// OrFilter matches when any of its Filters match
+type OrFilter struct {
+	Filters []Filter
+}
+
+// NotFilter matches when its Filter doesn't match
+type NotFilter struct {
+	Filter Filter
+}
+
+// EqualityFilter matches when an Attribute has a Value
+type EqualityFilter struct {
+	Attribute string
+	Value     string
+}
+
+// SubstringsFilter matches when an Attribute has a value which starts with
+// Initial, contains all of Any (in order) and ends with Final.  Initial and
+// Final are empty when they're not part of the filter.
+type SubstringsFilter struct {
+	Attribute string
+	Initial   string
+	Any       []string
+	Final     string
+}
+
+// GreaterOrEqualFilter matches when an Attribute has a value which is greater
+// than or equal to the Value
+type GreaterOrEqualFilter struct {
+	Attribute string
+	Value     string
+}
+
+// LessOrEqualFilter matches when an Attribute has a value which is less than
+// or equal to the Value
+type LessOrEqualFilter struct {
+	Attribute string
+	Value     string
+}
+
+// PresentFilter matches when an Attribute is present
+type PresentFilter struct {
+	Attribute string
+}
+
+// ApproxMatchFilter matches when an Attribute has a value which approximately
+// matches the Value
+type ApproxMatchFilter struct {
+	Attribute string
+	Value     string
+}
+
+// ExtensibleMatchFilter matches the Value using the MatchingRule.  Either the
+// MatchingRule or the Attribute may be empty.  When DNAttributes is true, the
+// attributes of the entry's DN are also matched.
+type ExtensibleMatchFilter struct {
+	MatchingRule string
+	Attribute    string
+	Value        string
+	DNAttributes bool
+}
+
+// String returns the filter's string representation
+func (f *AndFilter) String() string {
+	return "(&" + filtersString(f.Filters) + ")"
+}
+
+// String returns the filter's string representation
+func (f *OrFilter) String() string {
+	return "(|" + filtersString(f.Filters) + ")"
+}
+
+// String returns the filter's string representation
+func (f *NotFilter) String() string {
+	var s string
+	if f.Filter != nil {
+		s = f.Filter.String()
+	}
+	return "(!" + s + ")"
+}
+
+// String returns the filter's string representation
+func (f *EqualityFilter) String() string {
+	return "(" + f.Attribute + "=" + ldap.EscapeFilter(f.Value) + ")"
+}
+
+// String returns the filter's string representation
+func (f *SubstringsFilter) String() string {
+	var b strings.Builder
+	b.WriteString("(" + f.Attribute + "=")
+	b.WriteString(ldap.EscapeFilter(f.Initial) + "*")
+	for _, s := range f.Any {
+		b.WriteString(ldap.EscapeFilter(s) + "*")
+	}
+	b.WriteString(ldap.EscapeFilter(f.Final) + ")")
+	return b.String()
+}
+
+// String returns the filter's string representation
+func (f *GreaterOrEqualFilter) String() string {
+	return "(" + f.Attribute + ">=" + ldap.EscapeFilter(f.Value) + ")"
+}
+
+// String returns the filter's string representation
+func (f *LessOrEqualFilter) String() string {
+	return "(" + f.Attribute + "<=" + ldap.EscapeFilter(f.Value) + ")"
+}
+
+// String returns the filter's string representation
+func (f *PresentFilter) String() string {
+	return "(" + f.Attribute + "=*)"
+}
+
+// String returns the filter's string representation
+func (f *ApproxMatchFilter) String() string {
+	return "(" + f.Attribute + "~=" + ldap.EscapeFilter(f.Value) + ")"
+}
+
+// String returns the filter's string representation
+func (f *ExtensibleMatchFilter) String() string {
+	var b strings.Builder
+	b.WriteString("(" + f.Attribute)
+	if f.DNAttributes {
+		b.WriteString(":dn")
+	}
+	if f.MatchingRule != "" {
+		b.WriteString(":" + f.MatchingRule)
+	}
+	b.WriteString(":=" + ldap.EscapeFilter(f.Value) + ")")
+	return b.String()
+}
+
+func filtersString(filters []Filter) string {
+	var b strings.Builder
+	for _, f := range filters {
+		b.WriteString(f.String())
+	}
+	return b.String()
+}
+
+// ParseFilter parses a filter's string representation (see:
+// https://datatracker.ietf.org/doc/html/rfc4515)
+func ParseFilter(filter string) (Filter, error) {
+	const op = "gldap.ParseFilter"
+	compiled, err := ldap.CompileFilter(filter)
+	if err != nil {
+		return nil, fmt.Errorf("%s: unable to compile filter: %w", op, err)
+	}
+	// round trip the compiled filter, so it's decoded just like a filter
+	// that's read from a request.
+	p, err := ber.DecodePacketErr(compiled.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("%s: unable to decode filter: %w", op, err)
+	}
+	f, err := decodeFilter(p)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return f, nil
+}
+
+// decodeFilter decodes a filter from a search request's filter packet.
+func decodeFilter(p *ber.Packet) (Filter, error) {
+	const op = "gldap.decodeFilter"
+	if p == nil {
+		return nil, fmt.Errorf("%s: missing filter: %w", op, ErrInvalidParameter)
+	}
+	if p.ClassType != ber.ClassContext {
+		return nil, fmt.Errorf("%s: invalid filter class %d: %w", op, p.ClassType, ErrInvalidParameter)
+	}
+	switch p.Tag {
+	case filterAnd, filterOr:
+		filters := make([]Filter, 0, len(p.Children))
+		for _, c := range p.Children {
+			f, err := decodeFilter(c)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", op, err)
+			}
+			filters = append(filters, f)
+		}
+		if p.Tag == filterAnd {
+			return &AndFilter{Filters: filters}, nil
+		}
+		return &OrFilter{Filters: filters}, nil
+
+	case filterNot:
+		if len(p.Children) != 1 {
+			return nil, fmt.Errorf("%s: not filter requires 1 filter and got %d: %w", op, len(p.Children), ErrInvalidParameter)
+		}
+		f, err := decodeFilter(p.Children[0])
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		return &NotFilter{Filter: f}, nil
+
+	case filterEqualityMatch, filterGreaterOrEqual, filterLessOrEqual, filterApproxMatch:
+		if len(p.Children) != 2 {
+			return nil, fmt.Errorf("%s: attribute value assertion requires 2 children and got %d: %w", op, len(p.Children), ErrInvalidParameter)
+		}
+		attr, value := p.Children[0].Data.String(), p.Children[1].Data.String()
+		switch p.Tag {
+		case filterEqualityMatch:
+			return &EqualityFilter{Attribute: attr, Value: value}, nil
+		case filterGreaterOrEqual:
+			return &GreaterOrEqualFilter{Attribute: attr, Value: value}, nil
+		case filterLessOrEqual:
+			return &LessOrEqualFilter{Attribute: attr, Value: value}, nil
+		default:
+			return &ApproxMatchFilter{Attribute: attr, Value: value}, nil
+		}
+
+	case filterSubstrings:
+		const (
+			substringInitial = 0
+			substringAny     = 1
+			substringFinal   = 2
+		)
+		if len(p.Children) != 2 {
+			return nil, fmt.Errorf("%s: substrings filter requires 2 children and got %d: %w", op, len(p.Children), ErrInvalidParameter)
+		}
+		f := &SubstringsFilter{Attribute: p.Children[0].Data.String()}
+		substrings := p.Children[1].Children
+		if len(substrings) == 0 {
+			return nil, fmt.Errorf("%s: substrings filter is missing substrings: %w", op, ErrInvalidParameter)
+		}
+		for idx, s := range substrings {
+			switch {
+			case s.Tag == substringInitial && idx == 0:
+				f.Initial = s.Data.String()
+			case s.Tag == substringAny:
+				f.Any = append(f.Any, s.Data.String())
+			case s.Tag == substringFinal && idx == len(substrings)-1:
+				f.Final = s.Data.String()
+			default:
+				return nil, fmt.Errorf("%s: invalid substring %d with tag %d: %w", op, idx, s.Tag, ErrInvalidParameter)
+			}
+		}
+		return f, nil
+
+	case filterPresent:
+		return &PresentFilter{Attribute: p.Data.String()}, nil
+
+	case filterExtensibleMatch:
+		const (
+			matchingRule = 1
+			matchType    = 2
+			matchValue   = 3
+			dnAttributes = 4
+		)
+		f := &ExtensibleMatchFilter{}
+		for _, c := range p.Children {
+			switch c.Tag {
+			case matchingRule:
+				f.MatchingRule = c.Data.String()
+			case matchType:
+				f.Attribute = c.Data.String()
+			case matchValue:
+				f.Value = c.Data.String()
+			case dnAttributes:
+				b := c.Data.Bytes()
+				f.DNAttributes = len(b) > 0 && b[0] != 0
+			default:
+				return nil, fmt.Errorf("%s: invalid extensible match tag %d: %w", op, c.Tag, ErrInvalidParameter)
+			}
+		}
+		if f.MatchingRule == "" && f.Attribute == "" {
+			return nil, fmt.Errorf("%s: extensible match requires a matching rule or attribute: %w", op, ErrInvalidParameter)
+		}
+		return f, nil
+
+	default:
+		return nil, fmt.Errorf("%s: unknown filter tag %d: %w", op, p.Tag, ErrInvalidParameter)
+	}
+}
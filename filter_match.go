@@ -0,0 +1,308 @@
+// Copyright (c) Jim Lambert
+// SPDX-License-Identifier: MIT
+
+package gldap
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+// filterResult is the three-valued result of evaluating a filter against an
+// entry.
+// see: https://datatracker.ietf.org/doc/html/rfc4511#section-4.5.1.7
+type filterResult int
+
+const (
+	filterFalse filterResult = iota
+	filterTrue
+	filterUndefined
+)
+
+// Supported extensible match rules (by name and OID).
+// see: https://datatracker.ietf.org/doc/html/rfc4517#section-4.2
+const (
+	matchRuleCaseIgnore     = "caseIgnoreMatch"
+	matchRuleCaseIgnoreOID  = "2.5.13.2"
+	matchRuleCaseExact      = "caseExactMatch"
+	matchRuleCaseExactOID   = "2.5.13.5"
+	matchRuleOctetString    = "octetStringMatch"
+	matchRuleOctetStringOID = "2.5.13.17"
+)
+
+// objectClassAttribute is present in every entry.
+// see: https://datatracker.ietf.org/doc/html/rfc4512#section-2.4.1
+const objectClassAttribute = "objectClass"
+
+// MatchFilter returns true when the filter matches the entry (see:
+// Filter.Match).  A nil filter doesn't match.
+func MatchFilter(f Filter, e *Entry) bool {
+	return f != nil && f.Match(e)
+}
+
+// Match returns true when all of the filters match the entry
+func (f *AndFilter) Match(e *Entry) bool {
+	return e != nil && f.evaluate(e) == filterTrue
+}
+
+// Match returns true when any of the filters match the entry
+func (f *OrFilter) Match(e *Entry) bool {
+	return e != nil && f.evaluate(e) == filterTrue
+}
+
+// Match returns true when the filter evaluates to FALSE for the entry
+func (f *NotFilter) Match(e *Entry) bool {
+	return e != nil && f.evaluate(e) == filterTrue
+}
+
+// Match returns true when the entry's attribute has the value
+func (f *EqualityFilter) Match(e *Entry) bool {
+	return e != nil && f.evaluate(e) == filterTrue
+}
+
+// Match returns true when the entry's attribute has a matching value
+func (f *SubstringsFilter) Match(e *Entry) bool {
+	return e != nil && f.evaluate(e) == filterTrue
+}
+
+// Match returns true when the entry's attribute has a value >= the value
+func (f *GreaterOrEqualFilter) Match(e *Entry) bool {
+	return e != nil && f.evaluate(e) == filterTrue
+}
+
+// Match returns true when the entry's attribute has a value <= the value
+func (f *LessOrEqualFilter) Match(e *Entry) bool {
+	return e != nil && f.evaluate(e) == filterTrue
+}
+
+// Match returns true when the entry has the attribute
+func (f *PresentFilter) Match(e *Entry) bool {
+	return e != nil && f.evaluate(e) == filterTrue
+}
+
+// Match returns true when the entry's attribute has an approximate value
+func (f *ApproxMatchFilter) Match(e *Entry) bool {
+	return e != nil && f.evaluate(e) == filterTrue
+}
+
+// Match returns true when the entry has a value which matches using the
+// matching rule
+func (f *ExtensibleMatchFilter) Match(e *Entry) bool {
+	return e != nil && f.evaluate(e) == filterTrue
+}
+
+// evaluate is FALSE if any filter is FALSE, otherwise it's Undefined if any
+// filter is Undefined and TRUE if all filters are TRUE
+func (f *AndFilter) evaluate(e *Entry) filterResult {
+	result := filterTrue
+	for _, sub := range f.Filters {
+		switch sub.evaluate(e) {
+		case filterFalse:
+			return filterFalse
+		case filterUndefined:
+			result = filterUndefined
+		}
+	}
+	return result
+}
+
+// evaluate is TRUE if any filter is TRUE, otherwise it's Undefined if any
+// filter is Undefined and FALSE if all filters are FALSE
+func (f *OrFilter) evaluate(e *Entry) filterResult {
+	result := filterFalse
+	for _, sub := range f.Filters {
+		switch sub.evaluate(e) {
+		case filterTrue:
+			return filterTrue
+		case filterUndefined:
+			result = filterUndefined
+		}
+	}
+	return result
+}
+
+// evaluate is the negation of the filter, and Undefined stays Undefined
+func (f *NotFilter) evaluate(e *Entry) filterResult {
+	if f.Filter == nil {
+		return filterUndefined
+	}
+	switch f.Filter.evaluate(e) {
+	case filterTrue:
+		return filterFalse
+	case filterFalse:
+		return filterTrue
+	default:
+		return filterUndefined
+	}
+}
+
+func (f *EqualityFilter) evaluate(e *Entry) filterResult {
+	return matchAny(f.Attribute, entryValues(e, f.Attribute), func(v string) bool {
+		return normalizeValue(v) == normalizeValue(f.Value)
+	})
+}
+
+func (f *SubstringsFilter) evaluate(e *Entry) filterResult {
+	return matchAny(f.Attribute, entryValues(e, f.Attribute), func(v string) bool {
+		v = strings.ToLower(v)
+		initial := strings.ToLower(f.Initial)
+		if !strings.HasPrefix(v, initial) {
+			return false
+		}
+		v = v[len(initial):]
+		for _, sub := range f.Any {
+			sub = strings.ToLower(sub)
+			idx := strings.Index(v, sub)
+			if idx < 0 {
+				return false
+			}
+			v = v[idx+len(sub):]
+		}
+		return strings.HasSuffix(v, strings.ToLower(f.Final))
+	})
+}
+
+func (f *GreaterOrEqualFilter) evaluate(e *Entry) filterResult {
+	return matchAny(f.Attribute, entryValues(e, f.Attribute), func(v string) bool {
+		return compareValues(v, f.Value) >= 0
+	})
+}
+
+func (f *LessOrEqualFilter) evaluate(e *Entry) filterResult {
+	return matchAny(f.Attribute, entryValues(e, f.Attribute), func(v string) bool {
+		return compareValues(v, f.Value) <= 0
+	})
+}
+
+// evaluate is TRUE when the entry has a value for the attribute.  Every
+// entry has an objectClass, so (objectClass=*) is always TRUE.
+func (f *PresentFilter) evaluate(e *Entry) filterResult {
+	switch {
+	case f.Attribute == "":
+		return filterUndefined
+	case strings.EqualFold(f.Attribute, objectClassAttribute), len(entryValues(e, f.Attribute)) > 0:
+		return filterTrue
+	default:
+		return filterFalse
+	}
+}
+
+// evaluate approximates values by ignoring case and whitespace
+func (f *ApproxMatchFilter) evaluate(e *Entry) filterResult {
+	approx := func(v string) string {
+		return strings.Join(strings.Fields(strings.ToLower(v)), "")
+	}
+	return matchAny(f.Attribute, entryValues(e, f.Attribute), func(v string) bool {
+		return approx(v) == approx(f.Value)
+	})
+}
+
+// evaluate is Undefined for unsupported matching rules.  When there's no
+// attribute, all of the entry's attributes are matched.
+func (f *ExtensibleMatchFilter) evaluate(e *Entry) filterResult {
+	var match func(a, b string) bool
+	switch {
+	case f.MatchingRule == "",
+		strings.EqualFold(f.MatchingRule, matchRuleCaseIgnore),
+		f.MatchingRule == matchRuleCaseIgnoreOID:
+		match = func(a, b string) bool { return normalizeValue(a) == normalizeValue(b) }
+	case strings.EqualFold(f.MatchingRule, matchRuleCaseExact),
+		f.MatchingRule == matchRuleCaseExactOID,
+		strings.EqualFold(f.MatchingRule, matchRuleOctetString),
+		f.MatchingRule == matchRuleOctetStringOID:
+		match = func(a, b string) bool { return a == b }
+	default:
+		return filterUndefined
+	}
+
+	var values []string
+	for _, a := range e.Attributes {
+		if f.Attribute == "" || strings.EqualFold(a.Name, f.Attribute) {
+			values = append(values, attributeValues(a)...)
+		}
+	}
+	if f.DNAttributes {
+		// entries with a DN that can't be parsed simply have no DN values to
+		// match.
+		if dn, err := ldap.ParseDN(e.DN); err == nil {
+			for _, rdn := range dn.RDNs {
+				for _, a := range rdn.Attributes {
+					if f.Attribute == "" || strings.EqualFold(a.Type, f.Attribute) {
+						values = append(values, a.Value)
+					}
+				}
+			}
+		}
+	}
+	for _, v := range values {
+		if match(v, f.Value) {
+			return filterTrue
+		}
+	}
+	return filterFalse
+}
+
+// matchAny is TRUE when any of the values match, FALSE when none of them
+// match and Undefined when the filter is missing its attribute.
+func matchAny(attribute string, values []string, match func(v string) bool) filterResult {
+	if attribute == "" {
+		return filterUndefined
+	}
+	for _, v := range values {
+		if match(v) {
+			return filterTrue
+		}
+	}
+	return filterFalse
+}
+
+// entryValues returns all of the values of the entry's attribute, where the
+// attribute's name is matched case-insensitively
+func entryValues(e *Entry, attribute string) []string {
+	var values []string
+	for _, a := range e.Attributes {
+		if strings.EqualFold(a.Name, attribute) {
+			values = append(values, attributeValues(a)...)
+		}
+	}
+	return values
+}
+
+// attributeValues returns the attribute's string values or its byte values
+// when it doesn't have any string values.
+func attributeValues(a *EntryAttribute) []string {
+	if len(a.Values) > 0 || len(a.ByteValues) == 0 {
+		return a.Values
+	}
+	values := make([]string, 0, len(a.ByteValues))
+	for _, v := range a.ByteValues {
+		values = append(values, string(v))
+	}
+	return values
+}
+
+// normalizeValue normalizes a value for a case-insensitive comparison, which
+// ignores leading, trailing and repeated whitespace
+func normalizeValue(v string) string {
+	return strings.Join(strings.Fields(strings.ToLower(v)), " ")
+}
+
+// compareValues compares two values as integers when they're both integers,
+// otherwise they're compared as normalized strings.
+func compareValues(a, b string) int {
+	ai, aErr := strconv.ParseInt(strings.TrimSpace(a), 10, 64)
+	bi, bErr := strconv.ParseInt(strings.TrimSpace(b), 10, 64)
+	if aErr == nil && bErr == nil {
+		switch {
+		case ai < bi:
+			return -1
+		case ai > bi:
+			return 1
+		default:
+			return 0
+		}
+	}
+	return strings.Compare(normalizeValue(a), normalizeValue(b))
+}
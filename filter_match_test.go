@@ -0,0 +1,112 @@
+// Copyright (c) Jim Lambert
+// SPDX-License-Identifier: MIT
+
+package gldap
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFilter_Match(t *testing.T) {
+	t.Parallel()
+	entry := NewEntry("uid=alice,ou=people,dc=example,dc=org", map[string][]string{
+		"uid":         {"alice"},
+		"cn":          {"Alice  Eve Smith"},
+		"mail":        {"alice@example.org", "asmith@example.org"},
+		"uidNumber":   {"1000"},
+		"description": {"friend of Rivest, Shamir and Adleman"},
+	})
+	entry.Attributes = append(entry.Attributes, &EntryAttribute{Name: "photo", ByteValues: [][]byte{[]byte("jpeg")}})
+
+	tests := []struct {
+		name       string
+		filter     string
+		want       bool
+		wantResult filterResult
+	}{
+		{name: "equality", filter: "(uid=alice)", want: true},
+		{name: "equality-case-insensitive-name", filter: "(UID=alice)", want: true},
+		{name: "equality-case-insensitive-value", filter: "(uid=ALICE)", want: true},
+		{name: "equality-normalized-whitespace", filter: "(cn=alice eve smith)", want: true},
+		{name: "equality-multi-valued", filter: "(mail=asmith@example.org)", want: true},
+		{name: "equality-byte-values", filter: "(photo=jpeg)", want: true},
+		{name: "equality-no-match", filter: "(uid=bob)"},
+		{name: "equality-missing-attribute", filter: "(sn=smith)"},
+		{name: "substrings-initial", filter: "(cn=ali*)", want: true},
+		{name: "substrings-final", filter: "(cn=*SMITH)", want: true},
+		{name: "substrings-any", filter: "(description=*rivest*adleman*)", want: true},
+		{name: "substrings-any-out-of-order", filter: "(description=*adleman*rivest*)"},
+		{name: "substrings-all", filter: "(mail=a*@*.org)", want: true},
+		{name: "substrings-overlapping", filter: "(uid=alic*ice)"},
+		{name: "greater-or-equal-numeric", filter: "(uidNumber>=999)", want: true},
+		{name: "greater-or-equal-numeric-no-match", filter: "(uidNumber>=10000)"},
+		{name: "less-or-equal-numeric", filter: "(uidNumber<=1000)", want: true},
+		{name: "less-or-equal-string", filter: "(uid<=bob)", want: true},
+		{name: "greater-or-equal-string", filter: "(uid>=bob)"},
+		{name: "present", filter: "(mail=*)", want: true},
+		{name: "present-case-insensitive", filter: "(UIDNUMBER=*)", want: true},
+		{name: "present-missing", filter: "(sn=*)"},
+		{name: "present-object-class", filter: "(objectClass=*)", want: true},
+		{name: "approx", filter: "(cn~=aliceevesmith)", want: true},
+		{name: "approx-no-match", filter: "(cn~=bob)"},
+		{name: "extensible-case-exact", filter: "(cn:caseExactMatch:=Alice  Eve Smith)", want: true},
+		{name: "extensible-case-exact-no-match", filter: "(cn:2.5.13.5:=alice eve smith)"},
+		{name: "extensible-case-ignore", filter: "(cn:caseIgnoreMatch:=ALICE EVE SMITH)", want: true},
+		{name: "extensible-any-attribute", filter: "(:caseExactMatch:=1000)", want: true},
+		{name: "extensible-dn-attributes", filter: "(ou:dn:=people)", want: true},
+		{name: "extensible-without-dn-attributes", filter: "(ou:=people)"},
+		{
+			name:       "extensible-unknown-rule",
+			filter:     "(cn:1.2.3.4:=alice)",
+			wantResult: filterUndefined,
+		},
+		{name: "and", filter: "(&(uid=alice)(mail=*))", want: true},
+		{name: "and-false", filter: "(&(uid=alice)(sn=*))"},
+		{name: "or", filter: "(|(uid=bob)(uid=alice))", want: true},
+		{name: "or-false", filter: "(|(uid=bob)(uid=eve))"},
+		{name: "not", filter: "(!(uid=bob))", want: true},
+		{name: "not-false", filter: "(!(uid=alice))"},
+		{
+			name:       "and-undefined",
+			filter:     "(&(uid=alice)(cn:1.2.3.4:=alice))",
+			wantResult: filterUndefined,
+		},
+		{name: "and-false-beats-undefined", filter: "(&(uid=bob)(cn:1.2.3.4:=alice))"},
+		{name: "or-true-beats-undefined", filter: "(|(cn:1.2.3.4:=alice)(uid=alice))", want: true},
+		{
+			name:       "or-undefined",
+			filter:     "(|(cn:1.2.3.4:=alice)(uid=bob))",
+			wantResult: filterUndefined,
+		},
+		{
+			name:       "not-undefined",
+			filter:     "(!(cn:1.2.3.4:=alice))",
+			wantResult: filterUndefined,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert, require := assert.New(t), require.New(t)
+			f, err := ParseFilter(tc.filter)
+			require.NoError(err)
+			assert.Equal(tc.want, f.Match(entry))
+			assert.Equal(tc.want, MatchFilter(f, entry))
+
+			wantResult := tc.wantResult
+			if tc.want {
+				wantResult = filterTrue
+			}
+			assert.Equal(wantResult, f.evaluate(entry))
+		})
+	}
+	t.Run("nil-entry", func(t *testing.T) {
+		f, err := ParseFilter("(!(uid=alice))")
+		require.NoError(t, err)
+		assert.False(t, f.Match(nil))
+		assert.False(t, MatchFilter(f, nil))
+		assert.False(t, MatchFilter(nil, entry))
+	})
+}
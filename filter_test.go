@@ -0,0 +1,227 @@
+// Copyright (c) Jim Lambert
+// SPDX-License-Identifier: MIT
+
+package gldap
+
+import (
+	"testing"
+
+	ber "github.com/go-asn1-ber/asn1-ber"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFilter(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name            string
+		filter          string
+		want            Filter
+		wantString      string
+		wantErr         bool
+		wantErrContains string
+	}{
+		{
+			name:   "equality",
+			filter: "(uid=alice)",
+			want:   &EqualityFilter{Attribute: "uid", Value: "alice"},
+		},
+		{
+			name:   "escaped-value",
+			filter: `(cn=alice \28admin\29 \2a)`,
+			want:   &EqualityFilter{Attribute: "cn", Value: "alice (admin) *"},
+		},
+		{
+			name:   "greater-or-equal",
+			filter: "(uidNumber>=1000)",
+			want:   &GreaterOrEqualFilter{Attribute: "uidNumber", Value: "1000"},
+		},
+		{
+			name:   "less-or-equal",
+			filter: "(uidNumber<=1000)",
+			want:   &LessOrEqualFilter{Attribute: "uidNumber", Value: "1000"},
+		},
+		{
+			name:   "present",
+			filter: "(objectClass=*)",
+			want:   &PresentFilter{Attribute: "objectClass"},
+		},
+		{
+			name:   "approx",
+			filter: "(cn~=alice)",
+			want:   &ApproxMatchFilter{Attribute: "cn", Value: "alice"},
+		},
+		{
+			name:   "substrings-initial",
+			filter: "(cn=al*)",
+			want:   &SubstringsFilter{Attribute: "cn", Initial: "al"},
+		},
+		{
+			name:   "substrings-final",
+			filter: "(cn=*ce)",
+			want:   &SubstringsFilter{Attribute: "cn", Final: "ce"},
+		},
+		{
+			name:   "substrings-any",
+			filter: "(cn=*li*)",
+			want:   &SubstringsFilter{Attribute: "cn", Any: []string{"li"}},
+		},
+		{
+			name:   "substrings-all",
+			filter: "(cn=a*l*i*ce)",
+			want:   &SubstringsFilter{Attribute: "cn", Initial: "a", Any: []string{"l", "i"}, Final: "ce"},
+		},
+		{
+			name:   "extensible-attribute",
+			filter: "(cn:caseExactMatch:=Alice)",
+			want:   &ExtensibleMatchFilter{Attribute: "cn", MatchingRule: "caseExactMatch", Value: "Alice"},
+		},
+		{
+			name:   "extensible-dn-attributes",
+			filter: "(ou:dn:=people)",
+			want:   &ExtensibleMatchFilter{Attribute: "ou", DNAttributes: true, Value: "people"},
+		},
+		{
+			name:   "extensible-matching-rule",
+			filter: "(:dn:2.5.13.5:=alice)",
+			want:   &ExtensibleMatchFilter{MatchingRule: "2.5.13.5", DNAttributes: true, Value: "alice"},
+		},
+		{
+			name:   "nested",
+			filter: "(&(objectClass=person)(|(uid=alice)(uid=bob))(!(disabled=TRUE)))",
+			want: &AndFilter{Filters: []Filter{
+				&EqualityFilter{Attribute: "objectClass", Value: "person"},
+				&OrFilter{Filters: []Filter{
+					&EqualityFilter{Attribute: "uid", Value: "alice"},
+					&EqualityFilter{Attribute: "uid", Value: "bob"},
+				}},
+				&NotFilter{Filter: &EqualityFilter{Attribute: "disabled", Value: "TRUE"}},
+			}},
+		},
+		{
+			name:       "unescaped-non-ascii",
+			filter:     "(cn=Zoë)",
+			want:       &EqualityFilter{Attribute: "cn", Value: "Zoë"},
+			wantString: `(cn=Zo\c3\ab)`,
+		},
+		{
+			name:            "invalid",
+			filter:          "(uid=alice",
+			wantErr:         true,
+			wantErrContains: "unable to compile filter",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert, require := assert.New(t), require.New(t)
+			got, err := ParseFilter(tc.filter)
+			if tc.wantErr {
+				require.Error(err)
+				assert.Nil(got)
+				if tc.wantErrContains != "" {
+					assert.Contains(err.Error(), tc.wantErrContains)
+				}
+				return
+			}
+			require.NoError(err)
+			assert.Equal(tc.want, got)
+
+			// the filter should round trip back to text
+			wantString := tc.wantString
+			if wantString == "" {
+				wantString = tc.filter
+			}
+			assert.Equal(wantString, got.String())
+			reparsed, err := ParseFilter(got.String())
+			require.NoError(err)
+			assert.Equal(got, reparsed)
+		})
+	}
+}
+
+func Test_decodeFilter(t *testing.T) {
+	t.Parallel()
+	attrValue := func(tag ber.Tag, children ...*ber.Packet) *ber.Packet {
+		p := ber.Encode(ber.ClassContext, ber.TypeConstructed, tag, nil, "filter")
+		for _, c := range children {
+			p.AppendChild(c)
+		}
+		return p
+	}
+	str := func(class ber.Class, tag ber.Tag, v string) *ber.Packet {
+		return ber.NewString(class, ber.TypePrimitive, tag, v, "value")
+	}
+	tests := []struct {
+		name            string
+		packet          *ber.Packet
+		wantErrContains string
+	}{
+		{
+			name:            "missing",
+			wantErrContains: "missing filter",
+		},
+		{
+			name:            "invalid-class",
+			packet:          str(ber.ClassUniversal, ber.TagOctetString, "uid=alice"),
+			wantErrContains: "invalid filter class",
+		},
+		{
+			name:            "unknown-tag",
+			packet:          attrValue(10),
+			wantErrContains: "unknown filter tag 10",
+		},
+		{
+			name:            "invalid-and-child",
+			packet:          attrValue(filterAnd, attrValue(10)),
+			wantErrContains: "unknown filter tag 10",
+		},
+		{
+			name:            "not-without-filter",
+			packet:          attrValue(filterNot),
+			wantErrContains: "not filter requires 1 filter",
+		},
+		{
+			name:            "equality-missing-value",
+			packet:          attrValue(filterEqualityMatch, str(ber.ClassUniversal, ber.TagOctetString, "uid")),
+			wantErrContains: "attribute value assertion requires 2 children",
+		},
+		{
+			name: "substrings-missing-substrings",
+			packet: attrValue(filterSubstrings,
+				str(ber.ClassUniversal, ber.TagOctetString, "cn"),
+				ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "substrings"),
+			),
+			wantErrContains: "substrings filter is missing substrings",
+		},
+		{
+			name: "substrings-initial-not-first",
+			packet: func() *ber.Packet {
+				substrings := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "substrings")
+				substrings.AppendChild(str(ber.ClassContext, 1, "any"))
+				substrings.AppendChild(str(ber.ClassContext, 0, "initial"))
+				return attrValue(filterSubstrings, str(ber.ClassUniversal, ber.TagOctetString, "cn"), substrings)
+			}(),
+			wantErrContains: "invalid substring 1 with tag 0",
+		},
+		{
+			name:            "extensible-missing-rule-and-attribute",
+			packet:          attrValue(filterExtensibleMatch, str(ber.ClassContext, 3, "alice")),
+			wantErrContains: "extensible match requires a matching rule or attribute",
+		},
+		{
+			name:            "extensible-unknown-tag",
+			packet:          attrValue(filterExtensibleMatch, str(ber.ClassContext, 5, "alice")),
+			wantErrContains: "invalid extensible match tag 5",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert, require := assert.New(t), require.New(t)
+			got, err := decodeFilter(tc.packet)
+			require.Error(err)
+			assert.Nil(got)
+			assert.ErrorIs(err, ErrInvalidParameter)
+			assert.Contains(err.Error(), tc.wantErrContains)
+		})
+	}
+}
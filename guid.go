@@ -0,0 +1,82 @@
+// Copyright (c) Jim Lambert
+// SPDX-License-Identifier: MIT
+
+package gldap
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// guidLen is the length of a GUID's binary form
+const guidLen = 16
+
+// GUID is a globally unique identifier (for example:
+// "5b5d6b2e-6f3a-4d3c-9c9a-0b8f3f2c1e7d"), which is used for objectGUID
+// values.  The GUID's bytes are in the order of its string form, and Bytes
+// returns the mixed-endian binary form used by objectGUID values.
+// see: https://learn.microsoft.com/en-us/openspecs/windows_protocols/ms-dtyp/49e490b8-f972-45d6-a3a4-99f924998d97
+type GUID [guidLen]byte
+
+// ParseGUID parses a GUID's string form (for example:
+// "5b5d6b2e-6f3a-4d3c-9c9a-0b8f3f2c1e7d").  The string may be enclosed in
+// braces and is not case sensitive.
+func ParseGUID(s string) (GUID, error) {
+	const op = "gldap.ParseGUID"
+	var g GUID
+	trimmed := s
+	if strings.HasPrefix(trimmed, "{") && strings.HasSuffix(trimmed, "}") {
+		trimmed = trimmed[1 : len(trimmed)-1]
+	}
+	parts := strings.Split(trimmed, "-")
+	if len(parts) != 5 || len(parts[0]) != 8 || len(parts[1]) != 4 || len(parts[2]) != 4 || len(parts[3]) != 4 || len(parts[4]) != 12 {
+		return g, fmt.Errorf("%s: invalid GUID %q: %w", op, s, ErrInvalidParameter)
+	}
+	if _, err := hex.Decode(g[:], []byte(strings.Join(parts, ""))); err != nil {
+		return g, fmt.Errorf("%s: invalid GUID %q: %w", op, s, ErrInvalidParameter)
+	}
+	return g, nil
+}
+
+// ParseGUIDBytes parses a GUID's mixed-endian binary form (for example: an
+// objectGUID value)
+func ParseGUIDBytes(b []byte) (GUID, error) {
+	const op = "gldap.ParseGUIDBytes"
+	var g GUID
+	if len(b) != guidLen {
+		return g, fmt.Errorf("%s: GUID must be %d bytes and got %d: %w", op, guidLen, len(b), ErrInvalidParameter)
+	}
+	// the first three groups are little-endian and the rest are big-endian
+	binary.BigEndian.PutUint32(g[0:4], binary.LittleEndian.Uint32(b[0:4]))
+	binary.BigEndian.PutUint16(g[4:6], binary.LittleEndian.Uint16(b[4:6]))
+	binary.BigEndian.PutUint16(g[6:8], binary.LittleEndian.Uint16(b[6:8]))
+	copy(g[8:], b[8:])
+	return g, nil
+}
+
+// Bytes returns the GUID's mixed-endian binary form (for example: an
+// objectGUID value)
+func (g GUID) Bytes() []byte {
+	b := make([]byte, guidLen)
+	// the first three groups are little-endian and the rest are big-endian
+	binary.LittleEndian.PutUint32(b[0:4], binary.BigEndian.Uint32(g[0:4]))
+	binary.LittleEndian.PutUint16(b[4:6], binary.BigEndian.Uint16(g[4:6]))
+	binary.LittleEndian.PutUint16(b[6:8], binary.BigEndian.Uint16(g[6:8]))
+	copy(b[8:], g[8:])
+	return b
+}
+
+// String returns the GUID's string form (for example:
+// "5b5d6b2e-6f3a-4d3c-9c9a-0b8f3f2c1e7d")
+func (g GUID) String() string {
+	return fmt.Sprintf("%x-%x-%x-%x-%x", g[0:4], g[4:6], g[6:8], g[8:10], g[10:])
+}
+
+// BaseDN returns the GUID's base DN form (for example:
+// "<GUID=5b5d6b2e-6f3a-4d3c-9c9a-0b8f3f2c1e7d>"), which can be used as the
+// base DN of a search request.  See: ParseGUIDBaseDN
+func (g GUID) BaseDN() string {
+	return fmt.Sprintf("<GUID=%s>", g)
+}
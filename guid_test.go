@@ -0,0 +1,92 @@
+// Copyright (c) Jim Lambert
+// SPDX-License-Identifier: MIT
+
+package gldap
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseGUID(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name            string
+		guid            string
+		want            GUID
+		wantBytes       []byte
+		wantString      string
+		wantErrContains string
+	}{
+		{
+			name:      "valid",
+			guid:      "00112233-4455-6677-8899-aabbccddeeff",
+			want:      GUID{0x00, 0x11, 0x22, 0x33, 0x44, 0x55, 0x66, 0x77, 0x88, 0x99, 0xaa, 0xbb, 0xcc, 0xdd, 0xee, 0xff},
+			wantBytes: []byte{0x33, 0x22, 0x11, 0x00, 0x55, 0x44, 0x77, 0x66, 0x88, 0x99, 0xaa, 0xbb, 0xcc, 0xdd, 0xee, 0xff},
+		},
+		{
+			name:       "braces-and-upper-case",
+			guid:       "{00112233-4455-6677-8899-AABBCCDDEEFF}",
+			want:       GUID{0x00, 0x11, 0x22, 0x33, 0x44, 0x55, 0x66, 0x77, 0x88, 0x99, 0xaa, 0xbb, 0xcc, 0xdd, 0xee, 0xff},
+			wantBytes:  []byte{0x33, 0x22, 0x11, 0x00, 0x55, 0x44, 0x77, 0x66, 0x88, 0x99, 0xaa, 0xbb, 0xcc, 0xdd, 0xee, 0xff},
+			wantString: "00112233-4455-6677-8899-aabbccddeeff",
+		},
+		{
+			name:            "missing-group",
+			guid:            "00112233-4455-6677-8899",
+			wantErrContains: "invalid GUID",
+		},
+		{
+			name:            "wrong-group-len",
+			guid:            "0011223-34455-6677-8899-aabbccddeeff",
+			wantErrContains: "invalid GUID",
+		},
+		{
+			name:            "not-hex",
+			guid:            "00112233-4455-6677-8899-aabbccddeegg",
+			wantErrContains: "invalid GUID",
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			assert, require := assert.New(t), require.New(t)
+			got, err := ParseGUID(tc.guid)
+			if tc.wantErrContains != "" {
+				require.Error(err)
+				assert.ErrorIs(err, ErrInvalidParameter)
+				assert.Contains(err.Error(), tc.wantErrContains)
+				return
+			}
+			require.NoError(err)
+			assert.Equal(tc.want, got)
+			assert.Equal(tc.wantBytes, got.Bytes())
+			wantString := tc.guid
+			if tc.wantString != "" {
+				wantString = tc.wantString
+			}
+			assert.Equal(wantString, got.String())
+			assert.Equal("<GUID="+wantString+">", got.BaseDN())
+
+			fromBytes, err := ParseGUIDBytes(got.Bytes())
+			require.NoError(err)
+			assert.Equal(got, fromBytes)
+		})
+	}
+}
+
+func TestParseGUIDBytes(t *testing.T) {
+	t.Parallel()
+	assert, require := assert.New(t), require.New(t)
+	got, err := ParseGUIDBytes([]byte{0x33, 0x22, 0x11, 0x00, 0x55, 0x44, 0x77, 0x66, 0x88, 0x99, 0xaa, 0xbb, 0xcc, 0xdd, 0xee, 0xff})
+	require.NoError(err)
+	assert.Equal("00112233-4455-6677-8899-aabbccddeeff", got.String())
+
+	_, err = ParseGUIDBytes([]byte{0x33, 0x22, 0x11})
+	require.Error(err)
+	assert.ErrorIs(err, ErrInvalidParameter)
+	assert.Contains(err.Error(), "GUID must be 16 bytes and got 3")
+}
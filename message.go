@@ -33,9 +33,15 @@ const (
 // AuthChoice defines the authentication choice for bind message
 type AuthChoice string
 
-// SimpleAuthChoice specifies a simple user/password authentication choice for
-// the bind message
-const SimpleAuthChoice AuthChoice = "simple"
+const (
+	// SimpleAuthChoice specifies a simple user/password authentication choice
+	// for the bind message
+	SimpleAuthChoice AuthChoice = "simple"
+
+	// SASLAuthChoice specifies a SASL authentication choice for the bind
+	// message
+	SASLAuthChoice AuthChoice = "sasl"
+)
 
 type requestType string
 
@@ -48,6 +54,9 @@ const (
 	addRequestType      requestType = "add"
 	deleteRequestType   requestType = "delete"
 	unbindRequestType   requestType = "unbind"
+	abandonRequestType  requestType = "abandon"
+	compareRequestType  requestType = "compare"
+	modifyDNRequestType requestType = "modifyDN"
 )
 
 // Message defines a common interface for all messages
@@ -81,6 +90,10 @@ type SearchMessage struct {
 	TypesOnly bool
 	// Filter for the request
 	Filter string
+	// ParsedFilter is the request's Filter as a parsed filter tree, which
+	// can be inspected, evaluated against entries via Match(...) or
+	// converted back to its string representation via String()
+	ParsedFilter Filter
 	// Attributes requested
 	Attributes []string
 	// Controls requested
@@ -105,8 +118,19 @@ type ExtendedOperationMessage struct {
 	baseMessage
 	// Name of the extended operation
 	Name ExtendedOperationName
-	// Value of the extended operation
+	// Value of the extended operation, which is empty when the request
+	// doesn't have a value.  See ByteValue for the value's raw bytes.
 	Value string
+	// ByteValue is the raw value of the extended operation, which is nil when
+	// the request doesn't have a value.  See the Decode*RequestValue(...)
+	// funcs for decoding the values of known extended operations.
+	ByteValue []byte
+	// Controls hold optional controls to send with the request
+	Controls []Control
+
+	// valueErr is the error decoding the request's value.  Requests with an
+	// invalid value get a ResultProtocolError response and aren't routed.
+	valueErr error
 }
 
 // DeleteMessage is an delete request message
@@ -124,6 +148,18 @@ type UnbindMessage struct {
 	baseMessage
 }
 
+// AbandonMessage is an abandon request message.  Abandon requests are handled
+// by the server, which cancels the abandoned request, and they're never routed
+// to a handler.
+type AbandonMessage struct {
+	baseMessage
+	// MessageID of the request being abandoned
+	MessageID int64
+
+	// Controls hold optional controls to send with the request
+	Controls []Control
+}
+
 // newMessage will create a new message from the packet.
 func newMessage(p *packet) (Message, error) {
 	const op = "gldap.NewMessage"
@@ -145,7 +181,39 @@ func newMessage(p *packet) (Message, error) {
 				id: msgID,
 			},
 		}, nil
+	case abandonRequestType:
+		abandonID, controls, err := p.abandonParameters()
+		if err != nil {
+			return nil, fmt.Errorf("%s: invalid abandon message: %w", op, err)
+		}
+		return &AbandonMessage{
+			baseMessage: baseMessage{
+				id: msgID,
+			},
+			MessageID: abandonID,
+			Controls:  controls,
+		}, nil
 	case bindRequestType:
+		authChoice, err := p.bindAuthChoice()
+		if err != nil {
+			return nil, fmt.Errorf("%s: invalid bind message: %w", op, err)
+		}
+		if authChoice == SASLAuthChoice {
+			parameters, err := p.saslBindParameters()
+			if err != nil {
+				return nil, fmt.Errorf("%s: invalid sasl bind message: %w", op, err)
+			}
+			return &SASLBindMessage{
+				baseMessage: baseMessage{
+					id: msgID,
+				},
+				AuthChoice:  SASLAuthChoice,
+				UserName:    parameters.userName,
+				Mechanism:   parameters.mechanism,
+				Credentials: parameters.credentials,
+				Controls:    parameters.controls,
+			}, nil
+		}
 		u, pass, controls, err := p.simpleBindParameters()
 		if err != nil {
 			return nil, fmt.Errorf("%s: invalid bind message: %w", op, err)
@@ -175,6 +243,7 @@ func newMessage(p *packet) (Message, error) {
 			TimeLimit:    parameters.timeLimit,
 			TypesOnly:    parameters.typesOnly,
 			Filter:       parameters.filter,
+			ParsedFilter: parameters.parsedFilter,
 			Attributes:   parameters.attributes,
 			Controls:     parameters.controls,
 		}, nil
@@ -183,11 +252,32 @@ func newMessage(p *packet) (Message, error) {
 		if err != nil {
 			return nil, fmt.Errorf("%s: %w", op, err)
 		}
+		// an invalid value is answered with a protocolError instead of
+		// failing the request, which would close the connection.  Cancel
+		// requests without a valid value get a protocolError when they're
+		// handled.
+		value, valueErr := p.extendedOperationValue()
+		controls, err := p.extendedOperationControls()
+		if err != nil {
+			return nil, fmt.Errorf("%s: invalid extended operation message: %w", op, err)
+		}
+		if opName == ExtendedOperationCancel {
+			return &CancelMessage{
+				baseMessage: baseMessage{
+					id: msgID,
+				},
+				Value: value,
+			}, nil
+		}
 		return &ExtendedOperationMessage{
 			baseMessage: baseMessage{
 				id: msgID,
 			},
-			Name: opName,
+			Name:      opName,
+			Value:     string(value),
+			ByteValue: value,
+			Controls:  controls,
+			valueErr:  valueErr,
 		}, nil
 	case modifyRequestType:
 		parameters, err := p.modifyParameters()
@@ -227,6 +317,34 @@ func newMessage(p *packet) (Message, error) {
 			DN:       dn,
 			Controls: controls,
 		}, nil
+	case compareRequestType:
+		parameters, err := p.compareParameters()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		return &CompareMessage{
+			baseMessage: baseMessage{
+				id: msgID,
+			},
+			DN:        parameters.dn,
+			Assertion: parameters.assertion,
+			Controls:  parameters.controls,
+		}, nil
+	case modifyDNRequestType:
+		parameters, err := p.modifyDNParameters()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		return &ModifyDNMessage{
+			baseMessage: baseMessage{
+				id: msgID,
+			},
+			DN:           parameters.dn,
+			NewRDN:       parameters.newRDN,
+			DeleteOldRDN: parameters.deleteOldRDN,
+			NewSuperior:  parameters.newSuperior,
+			Controls:     parameters.controls,
+		}, nil
 	default:
 		return &ExtendedOperationMessage{
 			baseMessage: baseMessage{
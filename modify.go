@@ -35,6 +35,11 @@ type PartialAttribute struct {
 	Type string
 	// Vals are the values of the partial attribute
 	Vals []string
+	// ByteValues are the raw values of the partial attribute, which should be
+	// used for binary values (for example: objectSid, objectGUID or
+	// jpegPhoto).  When decoded from a request, it contains the same values as
+	// Vals.
+	ByteValues [][]byte
 }
 
 func (c *Change) encode() *ber.Packet {
@@ -45,12 +50,5 @@ func (c *Change) encode() *ber.Packet {
 }
 
 func (p *PartialAttribute) encode() *ber.Packet {
-	seq := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "PartialAttribute")
-	seq.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, p.Type, "Type"))
-	set := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSet, nil, "AttributeValue")
-	for _, value := range p.Vals {
-		set.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, value, "Vals"))
-	}
-	seq.AppendChild(set)
-	return seq
+	return encodeAttribute("PartialAttribute", p.Type, p.Vals, p.ByteValues)
 }
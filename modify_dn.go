@@ -0,0 +1,27 @@
+// Copyright (c) Jim Lambert
+// SPDX-License-Identifier: MIT
+
+package gldap
+
+// ModifyDNMessage is a modify DN (rename/move) request message as defined in
+// https://datatracker.ietf.org/doc/html/rfc4511#section-4.9
+type ModifyDNMessage struct {
+	baseMessage
+	// DN identifies the entry being renamed/moved
+	DN string
+	// NewRDN is the new RDN of the entry
+	NewRDN string
+	// DeleteOldRDN specifies whether the old RDN attribute values are to be
+	// retained as attributes of the entry or deleted from the entry
+	DeleteOldRDN bool
+	// NewSuperior is the optional DN of the new immediate superior (parent) of
+	// the entry.  It will be empty when the entry is only being renamed.
+	NewSuperior string
+	// Controls hold optional controls to send with the request
+	Controls []Control
+}
+
+// ModifyDNResponse is a response to a modify DN request.
+type ModifyDNResponse struct {
+	*GeneralResponse
+}
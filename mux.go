@@ -10,23 +10,79 @@ import (
 
 // Mux is an ldap request multiplexer. It matches the inbound request against a
 // list of registered route handlers. Routes are matched in the order they're
-// added and only one route is called per request.
+// added and only one route is called per request.  Requests which target a DN
+// within a mounted child Mux's suffix are served by the child (see: Mount).
 type Mux struct {
 	mu           sync.Mutex
 	routes       []route
+	mounts       []*mount
+	middleware   []Middleware
 	defaultRoute route
 	unbindRoute  route
+
+	// supportedControls are the control types supported by the mux and it's
+	// nil when criticality isn't enforced.
+	supportedControls []string
+}
+
+// mountMu serializes mounting, so concurrent calls to Mount can't create a
+// cycle of mounted muxes.
+var mountMu sync.Mutex
+
+// mount is a child Mux which serves the requests targeting DNs at or below
+// its suffix.
+type mount struct {
+	suffix *DN
+	mux    *Mux
 }
 
 // NewMux creates a new multiplexer.
+// Options supported: WithSupportedControls
 func NewMux(opt ...Option) (*Mux, error) {
+	opts := getMuxOpts(opt...)
 	return &Mux{
-		routes: []route{},
+		routes:            []route{},
+		supportedControls: opts.withSupportedControls,
 	}, nil
 }
 
-// Bind will register a handler for bind requests.
-// Options supported: WithLabel
+// SupportedControls returns the control types supported by the mux (see:
+// WithSupportedControls)
+func (m *Mux) SupportedControls() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.supportedControls == nil {
+		return nil
+	}
+	return append([]string{}, m.supportedControls...)
+}
+
+// unsupportedCriticalControl returns the first critical control of the request
+// which isn't supported by the mux.  It returns false when the mux doesn't
+// enforce criticality or all of the request's critical controls are supported.
+func (m *Mux) unsupportedCriticalControl(req *Request) (string, bool) {
+	supported := m.SupportedControls()
+	if supported == nil {
+		return "", false
+	}
+	for _, controlType := range req.criticalControls {
+		found := false
+		for _, s := range supported {
+			if s == controlType {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return controlType, true
+		}
+	}
+	return "", false
+}
+
+// Bind will register a handler for simple bind requests (see SASLBind for SASL
+// bind requests).
+// Options supported: WithLabel, WithControl, WithMiddleware
 func (m *Mux) Bind(bindFn HandlerFunc, opt ...Option) error {
 	const op = "gldap.(Mux).Bind"
 	if bindFn == nil {
@@ -36,9 +92,11 @@ func (m *Mux) Bind(bindFn HandlerFunc, opt ...Option) error {
 
 	r := &simpleBindRoute{
 		baseRoute: &baseRoute{
-			h:       bindFn,
-			routeOp: bindRouteOperation,
-			label:   opts.withLabel,
+			h:          bindFn,
+			routeOp:    bindRouteOperation,
+			label:      opts.withLabel,
+			middleware: opts.withMiddleware,
+			controls:   opts.withControls,
 		},
 		authChoice: SimpleAuthChoice,
 	}
@@ -48,11 +106,43 @@ func (m *Mux) Bind(bindFn HandlerFunc, opt ...Option) error {
 	return nil
 }
 
+// SASLBind will register a handler for SASL bind requests using the specified
+// mechanism.  Handlers for multi-step mechanisms can use
+// Request.SetSASLBindState(...) and Request.SASLBindState() to keep state
+// between the steps of a bind.  See NewSASLPlainHandler(...) and
+// NewSASLExternalHandler(...) for built-in handlers.  Options supported:
+// WithLabel, WithControl, WithMiddleware
+func (m *Mux) SASLBind(mechanism SASLMechanism, bindFn HandlerFunc, opt ...Option) error {
+	const op = "gldap.(Mux).SASLBind"
+	if mechanism == "" {
+		return fmt.Errorf("%s: missing SASL mechanism: %w", op, ErrInvalidParameter)
+	}
+	if bindFn == nil {
+		return fmt.Errorf("%s: missing HandlerFunc: %w", op, ErrInvalidParameter)
+	}
+	opts := getRouteOpts(opt...)
+
+	r := &saslBindRoute{
+		baseRoute: &baseRoute{
+			h:          bindFn,
+			routeOp:    bindRouteOperation,
+			label:      opts.withLabel,
+			middleware: opts.withMiddleware,
+			controls:   opts.withControls,
+		},
+		mechanism: mechanism,
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.routes = append(m.routes, r)
+	return nil
+}
+
 // Unbind will register a handler for unbind requests and override the default
 // unbind handler.  Registering an unbind handler is optional and regardless of
 // whether or not an unbind route is defined the server will stop serving
 // requests for a connection after an unbind request is received.  Options
-// supported: WithLabel
+// supported: WithLabel, WithMiddleware
 func (m *Mux) Unbind(bindFn HandlerFunc, opt ...Option) error {
 	const op = "gldap.(Mux).Unbind"
 	if bindFn == nil {
@@ -62,9 +152,10 @@ func (m *Mux) Unbind(bindFn HandlerFunc, opt ...Option) error {
 
 	r := &unbindRoute{
 		baseRoute: &baseRoute{
-			h:       bindFn,
-			routeOp: bindRouteOperation,
-			label:   opts.withLabel,
+			h:          bindFn,
+			routeOp:    unbindRouteOperation,
+			label:      opts.withLabel,
+			middleware: opts.withMiddleware,
 		},
 	}
 	m.mu.Lock()
@@ -74,22 +165,33 @@ func (m *Mux) Unbind(bindFn HandlerFunc, opt ...Option) error {
 }
 
 // Search will register a handler for search requests.
-// Options supported: WithLabel, WithBaseDN, WithScope
+// Options supported: WithLabel, WithBaseDN, WithBaseDNSuffix, WithFilter,
+// WithScope, WithControl, WithMiddleware
 func (m *Mux) Search(searchFn HandlerFunc, opt ...Option) error {
 	const op = "gldap.(Mux).Search"
 	if searchFn == nil {
 		return fmt.Errorf("%s: missing HandlerFunc: %w", op, ErrInvalidParameter)
 	}
 	opts := getRouteOpts(opt...)
+	var suffix *DN
+	if opts.withBaseDNSuffix != "" {
+		var err error
+		if suffix, err = ParseDN(opts.withBaseDNSuffix); err != nil {
+			return fmt.Errorf("%s: invalid base DN suffix: %w", op, err)
+		}
+	}
 	r := &searchRoute{
 		baseRoute: &baseRoute{
-			h:       searchFn,
-			routeOp: searchRouteOperation,
-			label:   opts.withLabel,
+			h:          searchFn,
+			routeOp:    searchRouteOperation,
+			label:      opts.withLabel,
+			middleware: opts.withMiddleware,
+			controls:   opts.withControls,
 		},
-		basedn: opts.withBaseDN,
-		filter: opts.withFilter,
-		scope:  opts.withScope,
+		basedn:       opts.withBaseDN,
+		basednSuffix: suffix,
+		filter:       opts.withFilter,
+		scope:        opts.withScope,
 	}
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -98,7 +200,7 @@ func (m *Mux) Search(searchFn HandlerFunc, opt ...Option) error {
 }
 
 // ExtendedOperation will register a handler for extended operation requests.
-// Options supported: WithLabel
+// Options supported: WithLabel, WithControl, WithMiddleware
 func (m *Mux) ExtendedOperation(operationFn HandlerFunc, exName ExtendedOperationName, opt ...Option) error {
 	const op = "gldap.(Mux).Search"
 	if operationFn == nil {
@@ -107,9 +209,11 @@ func (m *Mux) ExtendedOperation(operationFn HandlerFunc, exName ExtendedOperatio
 	opts := getRouteOpts(opt...)
 	r := &extendedRoute{
 		baseRoute: &baseRoute{
-			h:       operationFn,
-			routeOp: extendedRouteOperation,
-			label:   opts.withLabel,
+			h:          operationFn,
+			routeOp:    extendedRouteOperation,
+			label:      opts.withLabel,
+			middleware: opts.withMiddleware,
+			controls:   opts.withControls,
 		},
 		extendedName: exName,
 	}
@@ -120,7 +224,7 @@ func (m *Mux) ExtendedOperation(operationFn HandlerFunc, exName ExtendedOperatio
 }
 
 // Modify will register a handler for modify operation requests.
-// Options supported: WithLabel
+// Options supported: WithLabel, WithControl, WithMiddleware
 func (m *Mux) Modify(modifyFn HandlerFunc, opt ...Option) error {
 	const op = "gldap.(Mux).Modify"
 	if modifyFn == nil {
@@ -129,9 +233,11 @@ func (m *Mux) Modify(modifyFn HandlerFunc, opt ...Option) error {
 	opts := getRouteOpts(opt...)
 	r := &modifyRoute{
 		baseRoute: &baseRoute{
-			h:       modifyFn,
-			routeOp: modifyRouteOperation,
-			label:   opts.withLabel,
+			h:          modifyFn,
+			routeOp:    modifyRouteOperation,
+			label:      opts.withLabel,
+			middleware: opts.withMiddleware,
+			controls:   opts.withControls,
 		},
 	}
 	m.mu.Lock()
@@ -141,7 +247,7 @@ func (m *Mux) Modify(modifyFn HandlerFunc, opt ...Option) error {
 }
 
 // Add will register a handler for add operation requests.
-// Options supported: WithLabel
+// Options supported: WithLabel, WithControl, WithMiddleware
 func (m *Mux) Add(addFn HandlerFunc, opt ...Option) error {
 	const op = "gldap.(Mux).Add"
 	if addFn == nil {
@@ -150,9 +256,11 @@ func (m *Mux) Add(addFn HandlerFunc, opt ...Option) error {
 	opts := getRouteOpts(opt...)
 	r := &addRoute{
 		baseRoute: &baseRoute{
-			h:       addFn,
-			routeOp: addRouteOperation,
-			label:   opts.withLabel,
+			h:          addFn,
+			routeOp:    addRouteOperation,
+			label:      opts.withLabel,
+			middleware: opts.withMiddleware,
+			controls:   opts.withControls,
 		},
 	}
 	m.mu.Lock()
@@ -162,7 +270,7 @@ func (m *Mux) Add(addFn HandlerFunc, opt ...Option) error {
 }
 
 // Delete will register a handler for delete operation requests.
-// Options supported: WithLabel
+// Options supported: WithLabel, WithControl, WithMiddleware
 func (m *Mux) Delete(modifyFn HandlerFunc, opt ...Option) error {
 	const op = "gldap.(Mux).Delete"
 	if modifyFn == nil {
@@ -171,9 +279,58 @@ func (m *Mux) Delete(modifyFn HandlerFunc, opt ...Option) error {
 	opts := getRouteOpts(opt...)
 	r := &deleteRoute{
 		baseRoute: &baseRoute{
-			h:       modifyFn,
-			routeOp: deleteRouteOperation,
-			label:   opts.withLabel,
+			h:          modifyFn,
+			routeOp:    deleteRouteOperation,
+			label:      opts.withLabel,
+			middleware: opts.withMiddleware,
+			controls:   opts.withControls,
+		},
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.routes = append(m.routes, r)
+	return nil
+}
+
+// Compare will register a handler for compare operation requests.
+// Options supported: WithLabel, WithControl, WithMiddleware
+func (m *Mux) Compare(compareFn HandlerFunc, opt ...Option) error {
+	const op = "gldap.(Mux).Compare"
+	if compareFn == nil {
+		return fmt.Errorf("%s: missing HandlerFunc: %w", op, ErrInvalidParameter)
+	}
+	opts := getRouteOpts(opt...)
+	r := &compareRoute{
+		baseRoute: &baseRoute{
+			h:          compareFn,
+			routeOp:    compareRouteOperation,
+			label:      opts.withLabel,
+			middleware: opts.withMiddleware,
+			controls:   opts.withControls,
+		},
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.routes = append(m.routes, r)
+	return nil
+}
+
+// ModifyDN will register a handler for modify DN (rename/move) operation
+// requests.
+// Options supported: WithLabel, WithControl, WithMiddleware
+func (m *Mux) ModifyDN(modifyDNFn HandlerFunc, opt ...Option) error {
+	const op = "gldap.(Mux).ModifyDN"
+	if modifyDNFn == nil {
+		return fmt.Errorf("%s: missing HandlerFunc: %w", op, ErrInvalidParameter)
+	}
+	opts := getRouteOpts(opt...)
+	r := &modifyDNRoute{
+		baseRoute: &baseRoute{
+			h:          modifyDNFn,
+			routeOp:    modifyDNRouteOperation,
+			label:      opts.withLabel,
+			middleware: opts.withMiddleware,
+			controls:   opts.withControls,
 		},
 	}
 	m.mu.Lock()
@@ -182,16 +339,112 @@ func (m *Mux) Delete(modifyFn HandlerFunc, opt ...Option) error {
 	return nil
 }
 
+// Mount will mount a child Mux under the DN suffix (naming context).  Bind
+// (simple), search, add, modify, delete, compare and modify DN requests which
+// target a DN equal to or below the suffix are served by the child, including
+// the child's default route when none of its routes match.  When several
+// mounted suffixes contain the DN, the child with the longest suffix serves
+// the request.  Requests which don't target a DN (SASL binds, extended
+// operations, etc) are always served by the parent.  A child which has the
+// parent mounted (directly or through its own children) can't be mounted,
+// since requests would be served by the muxes in a cycle.
+func (m *Mux) Mount(suffix string, child *Mux) error {
+	const op = "gldap.(Mux).Mount"
+	if suffix == "" {
+		return fmt.Errorf("%s: missing suffix: %w", op, ErrInvalidParameter)
+	}
+	if child == nil {
+		return fmt.Errorf("%s: missing child Mux: %w", op, ErrInvalidParameter)
+	}
+	if child == m {
+		return fmt.Errorf("%s: unable to mount a Mux on itself: %w", op, ErrInvalidParameter)
+	}
+	dn, err := ParseDN(suffix)
+	if err != nil {
+		return fmt.Errorf("%s: invalid suffix: %w", op, err)
+	}
+	mountMu.Lock()
+	defer mountMu.Unlock()
+	if child.reaches(m) {
+		return fmt.Errorf("%s: unable to mount a Mux which has this Mux mounted: %w", op, ErrInvalidParameter)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, mnt := range m.mounts {
+		if mnt.suffix.Equal(dn) {
+			return fmt.Errorf("%s: suffix %q is already mounted: %w", op, suffix, ErrInvalidParameter)
+		}
+	}
+	m.mounts = append(m.mounts, &mount{suffix: dn, mux: child})
+	return nil
+}
+
+// reaches returns true when the target is mounted on the mux or any of the
+// muxes mounted on it.
+func (m *Mux) reaches(target *Mux) bool {
+	visited := map[*Mux]bool{}
+	pending := []*Mux{m}
+	for len(pending) > 0 {
+		current := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+		if current == target {
+			return true
+		}
+		if visited[current] {
+			continue
+		}
+		visited[current] = true
+		current.mu.Lock()
+		for _, mnt := range current.mounts {
+			pending = append(pending, mnt.mux)
+		}
+		current.mu.Unlock()
+	}
+	return false
+}
+
+// mounted returns the mounted child Mux which owns the DN targeted by the
+// request or nil when there isn't one.
+func (m *Mux) mounted(req *Request) *Mux {
+	target, ok := req.targetDN()
+	if !ok {
+		return nil
+	}
+	dn, err := ParseDN(target)
+	if err != nil {
+		return nil
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var found *mount
+	for _, mnt := range m.mounts {
+		if !dn.Equal(mnt.suffix) && !dn.IsDescendantOf(mnt.suffix) {
+			continue
+		}
+		if found == nil || len(mnt.suffix.RDNs) > len(found.suffix.RDNs) {
+			found = mnt
+		}
+	}
+	if found == nil {
+		return nil
+	}
+	return found.mux
+}
+
 // DefaultRoute will register a default handler requests which have no other
 // registered handler.
+// Options supported: WithLabel, WithMiddleware
 func (m *Mux) DefaultRoute(noRouteFN HandlerFunc, opt ...Option) error {
 	const op = "gldap.(Mux).Bind"
 	if noRouteFN == nil {
 		return fmt.Errorf("%s: missing HandlerFunc: %w", op, ErrInvalidParameter)
 	}
+	opts := getRouteOpts(opt...)
 	r := &baseRoute{
-		h:       noRouteFN,
-		routeOp: bindRouteOperation,
+		h:          noRouteFN,
+		routeOp:    defaultRouteOperation,
+		label:      opts.withLabel,
+		middleware: opts.withMiddleware,
 	}
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -199,6 +452,77 @@ func (m *Mux) DefaultRoute(noRouteFN HandlerFunc, opt ...Option) error {
 	return nil
 }
 
+// Use will register middleware which wraps the handlers of every request
+// served by the Mux, including its unbind and default routes and requests
+// served by its mounted child muxes.  Middleware is applied in the order it's
+// registered (the first is the outermost) and it wraps any middleware
+// registered for the route (see: WithMiddleware).
+func (m *Mux) Use(mw ...Middleware) error {
+	const op = "gldap.(Mux).Use"
+	if len(mw) == 0 {
+		return fmt.Errorf("%s: missing middleware: %w", op, ErrInvalidParameter)
+	}
+	for _, fn := range mw {
+		if fn == nil {
+			return fmt.Errorf("%s: missing middleware: %w", op, ErrInvalidParameter)
+		}
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.middleware = append(m.middleware, mw...)
+	return nil
+}
+
+// Routes returns descriptions of the mux's routes in the order they're
+// matched, followed by its unbind and default routes and then the routes of
+// its mounted muxes.
+func (m *Mux) Routes() []RouteInfo {
+	m.mu.Lock()
+	routes := make([]route, 0, len(m.routes)+2)
+	routes = append(routes, m.routes...)
+	if m.unbindRoute != nil {
+		routes = append(routes, m.unbindRoute)
+	}
+	if m.defaultRoute != nil {
+		routes = append(routes, m.defaultRoute)
+	}
+	mounts := make([]*mount, len(m.mounts))
+	copy(mounts, m.mounts)
+	m.mu.Unlock()
+
+	infos := make([]RouteInfo, 0, len(routes))
+	for _, r := range routes {
+		infos = append(infos, r.info())
+	}
+	for _, mnt := range mounts {
+		for _, i := range mnt.mux.Routes() {
+			if i.Mount == "" {
+				i.Mount = mnt.suffix.String()
+			}
+			infos = append(infos, i)
+		}
+	}
+	return infos
+}
+
+// withMiddleware wraps the handler with the Mux's middleware
+func (m *Mux) withMiddleware(h HandlerFunc) HandlerFunc {
+	m.mu.Lock()
+	mw := make([]Middleware, len(m.middleware))
+	copy(mw, m.middleware)
+	m.mu.Unlock()
+	return chain(h, mw)
+}
+
+// serveUnbind will serve an unbind request using the optional unbind route
+func (m *Mux) serveUnbind(w *ResponseWriter, req *Request) {
+	if m.unbindRoute == nil {
+		return
+	}
+	req.routeLabel = m.unbindRoute.info().Label
+	m.withMiddleware(m.unbindRoute.handler())(w, req)
+}
+
 // serveRequests will find a matching route to serve the request
 func (m *Mux) serve(w *ResponseWriter, req *Request) {
 	const op = "gldap.(Mux).serve"
@@ -214,6 +538,28 @@ func (m *Mux) serve(w *ResponseWriter, req *Request) {
 		return
 	}
 
+	// requests with critical controls that aren't supported must be rejected
+	// before they're served.
+	// see: https://datatracker.ietf.org/doc/html/rfc4511#section-4.1.11
+	if controlType, ok := m.unsupportedCriticalControl(req); ok {
+		m.withMiddleware(func(w *ResponseWriter, req *Request) {
+			w.logger.Debug("unsupported critical control", "op", op, "connID", w.connID, "requestID", w.requestID, "controlType", controlType)
+			resp := req.NewResponse(
+				WithApplicationCode(req.responseApplicationCode()),
+				WithResponseCode(ResultUnavailableCriticalExtension),
+				WithDiagnosticMessage(fmt.Sprintf("unsupported critical control: %s", controlType)),
+			)
+			_ = w.Write(rejectedResponse(req, resp))
+		})(w, req)
+		return
+	}
+
+	// requests for a mounted suffix are served by its child mux
+	if child := m.mounted(req); child != nil {
+		m.withMiddleware(child.serve)(w, req)
+		return
+	}
+
 	// find the first matching route to dispatch the request to and then return
 	for _, r := range m.routes {
 		if !r.match(req) {
@@ -221,20 +567,38 @@ func (m *Mux) serve(w *ResponseWriter, req *Request) {
 		}
 		h := r.handler()
 		if h == nil {
-			w.logger.Error("route is missing handler", "op", op, "connID", w.connID, "requestID", w.requestID, "route", r.op)
+			w.logger.Error("route is missing handler", "op", op, "connID", w.connID, "requestID", w.requestID, "routeOp", r.op(), "routeLabel", r.info().Label)
 			return
 		}
+		req.routeLabel = r.info().Label
+		w.logger.Debug("matched route", "op", op, "connID", w.connID, "requestID", w.requestID, "routeOp", r.op(), "routeLabel", req.routeLabel)
 		// the handler intentionally doesn't return errors, since we want the
 		// handler to response to the connection's client with errors.
-		h(w, req)
+		m.withMiddleware(h)(w, req)
 		return
 	}
 	if m.defaultRoute != nil {
-		h := m.defaultRoute.handler()
-		h(w, req)
+		req.routeLabel = m.defaultRoute.info().Label
+		w.logger.Debug("using default route", "op", op, "connID", w.connID, "requestID", w.requestID, "routeLabel", req.routeLabel)
+		m.withMiddleware(m.defaultRoute.handler())(w, req)
 		return
 	}
-	w.logger.Error("no matching handler found for request and returning internal error", "op", op, "connID", w.connID, "requestID", w.requestID, "routeOp", req.routeOp)
-	resp := req.NewResponse(WithResponseCode(ResultUnwillingToPerform), WithDiagnosticMessage("No matching handler found"))
-	_ = w.Write(resp)
+	m.withMiddleware(func(w *ResponseWriter, req *Request) {
+		w.logger.Error("no matching handler found for request and returning internal error", "op", op, "connID", w.connID, "requestID", w.requestID, "routeOp", req.routeOp)
+		resp := req.NewResponse(WithResponseCode(ResultUnwillingToPerform), WithDiagnosticMessage("No matching handler found"))
+		_ = w.Write(rejectedResponse(req, resp))
+	})(w, req)
+}
+
+// rejectedResponse returns the response for a request which the mux rejects
+// without routing it.  Bind requests get a BindResponse with the same result
+// code and diagnostic message, since writing it clears the connection's
+// previous bind (see: Request.BoundDN)
+func rejectedResponse(req *Request, resp *GeneralResponse) Response {
+	if req.routeOp != bindRouteOperation {
+		return resp
+	}
+	bindResp := req.NewBindResponse(WithResponseCode(int(resp.code)))
+	bindResp.SetDiagnosticMessage(resp.diagMessage)
+	return bindResp
 }
@@ -0,0 +1,36 @@
+// Copyright (c) Jim Lambert
+// SPDX-License-Identifier: MIT
+
+package gldap
+
+type muxOptions struct {
+	withSupportedControls []string
+}
+
+func muxDefaults() muxOptions {
+	return muxOptions{}
+}
+
+func getMuxOpts(opt ...Option) muxOptions {
+	opts := muxDefaults()
+	applyOpts(&opts, opt...)
+	return opts
+}
+
+// WithSupportedControls specifies the control types (OIDs) supported by a Mux.
+// When specified, the Mux rejects requests which include a critical control
+// that isn't supported with ResultUnavailableCriticalExtension before calling
+// the request's handler, and the supported controls are advertised by the
+// Mux's root DSE (see: Mux.RootDSE).  Without this option, criticality isn't
+// enforced and handlers are responsible for any controls they don't support.
+// see: https://datatracker.ietf.org/doc/html/rfc4511#section-4.1.11
+func WithSupportedControls(controlType ...string) Option {
+	return func(o interface{}) {
+		if o, ok := o.(*muxOptions); ok {
+			if o.withSupportedControls == nil {
+				o.withSupportedControls = []string{}
+			}
+			o.withSupportedControls = append(o.withSupportedControls, controlType...)
+		}
+	}
+}
@@ -0,0 +1,23 @@
+// Copyright (c) Jim Lambert
+// SPDX-License-Identifier: MIT
+
+package gldap
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_WithSupportedControls(t *testing.T) {
+	t.Parallel()
+	assert := assert.New(t)
+	opts := getMuxOpts(WithSupportedControls(ControlTypePaging), WithSupportedControls(ControlTypeManageDsaIT))
+	testOpts := muxDefaults()
+	testOpts.withSupportedControls = []string{ControlTypePaging, ControlTypeManageDsaIT}
+	assert.Equal(opts, testOpts)
+
+	opts = getMuxOpts(WithSupportedControls())
+	assert.NotNil(opts.withSupportedControls)
+	assert.Empty(opts.withSupportedControls)
+}
@@ -12,6 +12,7 @@ import (
 	"testing"
 	"time"
 
+	ber "github.com/go-asn1-ber/asn1-ber"
 	"github.com/go-ldap/ldap/v3"
 	"github.com/hashicorp/go-hclog"
 	"github.com/stretchr/testify/assert"
@@ -152,6 +153,759 @@ func TestMux_Delete(t *testing.T) {
 	}
 }
 
+func TestMux_Search(t *testing.T) {
+	tests := []struct {
+		name            string
+		mux             *Mux
+		fn              HandlerFunc
+		opt             []Option
+		wantErr         bool
+		wantErrIs       error
+		wantErrContains string
+	}{
+		{
+			name:            "missing-fn",
+			mux:             func() *Mux { m, err := NewMux(); require.NoError(t, err); return m }(),
+			wantErr:         true,
+			wantErrIs:       ErrInvalidParameter,
+			wantErrContains: "missing HandlerFunc",
+		},
+		{
+			name:            "invalid-base-dn-suffix",
+			mux:             func() *Mux { m, err := NewMux(); require.NoError(t, err); return m }(),
+			fn:              func(*ResponseWriter, *Request) {},
+			opt:             []Option{WithBaseDNSuffix("ou=people,dc")},
+			wantErr:         true,
+			wantErrIs:       ErrInvalidParameter,
+			wantErrContains: "invalid base DN suffix",
+		},
+		{
+			name: "valid",
+			mux:  func() *Mux { m, err := NewMux(); require.NoError(t, err); return m }(),
+			fn:   func(*ResponseWriter, *Request) {},
+			opt:  []Option{WithBaseDNSuffix("ou=people,dc=example,dc=com")},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert, require := assert.New(t), require.New(t)
+			err := tc.mux.Search(tc.fn, tc.opt...)
+			if tc.wantErr {
+				require.Error(err)
+				if tc.wantErrIs != nil {
+					assert.ErrorIs(err, tc.wantErrIs)
+				}
+				if tc.wantErrContains != "" {
+					assert.Contains(err.Error(), tc.wantErrContains)
+				}
+				return
+			}
+			require.NoError(err)
+			require.Len(tc.mux.routes, 1)
+			assert.NotNil(tc.mux.routes[0].(*searchRoute).basednSuffix)
+		})
+	}
+}
+
+func TestMux_Mount(t *testing.T) {
+	newMux := func() *Mux { m, err := NewMux(); require.NoError(t, err); return m }
+	self := newMux()
+	mounted := newMux()
+	require.NoError(t, mounted.Mount("dc=corp,dc=example", newMux()))
+
+	// parent -> child -> grandchild
+	parent, child, grandchild := newMux(), newMux(), newMux()
+	require.NoError(t, parent.Mount("dc=corp,dc=example", child))
+	require.NoError(t, child.Mount("ou=people,dc=corp,dc=example", grandchild))
+
+	tests := []struct {
+		name            string
+		mux             *Mux
+		suffix          string
+		child           *Mux
+		wantErr         bool
+		wantErrIs       error
+		wantErrContains string
+	}{
+		{
+			name:            "missing-suffix",
+			mux:             newMux(),
+			child:           newMux(),
+			wantErr:         true,
+			wantErrIs:       ErrInvalidParameter,
+			wantErrContains: "missing suffix",
+		},
+		{
+			name:            "missing-child",
+			mux:             newMux(),
+			suffix:          "dc=corp,dc=example",
+			wantErr:         true,
+			wantErrIs:       ErrInvalidParameter,
+			wantErrContains: "missing child Mux",
+		},
+		{
+			name:            "mount-on-itself",
+			mux:             self,
+			suffix:          "dc=corp,dc=example",
+			child:           self,
+			wantErr:         true,
+			wantErrIs:       ErrInvalidParameter,
+			wantErrContains: "unable to mount a Mux on itself",
+		},
+		{
+			name:            "two-mux-cycle",
+			mux:             child,
+			suffix:          "dc=corp,dc=example",
+			child:           parent,
+			wantErr:         true,
+			wantErrIs:       ErrInvalidParameter,
+			wantErrContains: "unable to mount a Mux which has this Mux mounted",
+		},
+		{
+			name:            "three-mux-cycle",
+			mux:             grandchild,
+			suffix:          "dc=corp,dc=example",
+			child:           parent,
+			wantErr:         true,
+			wantErrIs:       ErrInvalidParameter,
+			wantErrContains: "unable to mount a Mux which has this Mux mounted",
+		},
+		{
+			name:            "invalid-suffix",
+			mux:             newMux(),
+			suffix:          "dc=corp,dc",
+			child:           newMux(),
+			wantErr:         true,
+			wantErrIs:       ErrInvalidParameter,
+			wantErrContains: "invalid suffix",
+		},
+		{
+			name:            "already-mounted",
+			mux:             mounted,
+			suffix:          "DC=Corp, DC=Example",
+			child:           newMux(),
+			wantErr:         true,
+			wantErrIs:       ErrInvalidParameter,
+			wantErrContains: "already mounted",
+		},
+		{
+			name:   "valid",
+			mux:    newMux(),
+			suffix: "dc=corp,dc=example",
+			child:  newMux(),
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert, require := assert.New(t), require.New(t)
+			err := tc.mux.Mount(tc.suffix, tc.child)
+			if tc.wantErr {
+				require.Error(err)
+				if tc.wantErrIs != nil {
+					assert.ErrorIs(err, tc.wantErrIs)
+				}
+				if tc.wantErrContains != "" {
+					assert.Contains(err.Error(), tc.wantErrContains)
+				}
+				return
+			}
+			require.NoError(err)
+			require.Len(tc.mux.mounts, 1)
+			assert.Equal(tc.child, tc.mux.mounts[0].mux)
+		})
+	}
+}
+
+func TestMux_serve_mounted(t *testing.T) {
+	t.Parallel()
+	var served string
+	newMux := func(name string) *Mux {
+		m, err := NewMux()
+		require.NoError(t, err)
+		h := func(*ResponseWriter, *Request) { served = name }
+		require.NoError(t, m.Bind(h))
+		require.NoError(t, m.Search(h))
+		require.NoError(t, m.Add(h))
+		require.NoError(t, m.Modify(h))
+		require.NoError(t, m.Delete(h))
+		require.NoError(t, m.Compare(h))
+		require.NoError(t, m.ModifyDN(h))
+		require.NoError(t, m.ExtendedOperation(h, ExtendedOperationWhoAmI))
+		return m
+	}
+	root := newMux("root")
+	require.NoError(t, root.Mount("dc=corp,dc=example", newMux("corp")))
+	require.NoError(t, root.Mount("ou=eng,dc=corp,dc=example", newMux("eng")))
+	require.NoError(t, root.Mount("dc=acme,dc=example", newMux("acme")))
+
+	empty, err := NewMux()
+	require.NoError(t, err)
+	require.NoError(t, empty.DefaultRoute(func(*ResponseWriter, *Request) { served = "empty-default" }))
+	require.NoError(t, root.Mount("dc=empty,dc=example", empty))
+
+	tests := []struct {
+		name    string
+		routeOp routeOperation
+		message Message
+		want    string
+	}{
+		{
+			name:    "bind",
+			routeOp: bindRouteOperation,
+			message: &SimpleBindMessage{AuthChoice: SimpleAuthChoice, UserName: "uid=alice,ou=people,dc=corp,dc=example"},
+			want:    "corp",
+		},
+		{
+			name:    "search-suffix",
+			routeOp: searchRouteOperation,
+			message: &SearchMessage{BaseDN: "DC=Acme,DC=Example"},
+			want:    "acme",
+		},
+		{
+			name:    "search-longest-suffix",
+			routeOp: searchRouteOperation,
+			message: &SearchMessage{BaseDN: "ou=people,ou=eng,dc=corp,dc=example"},
+			want:    "eng",
+		},
+		{
+			name:    "search-root-dse",
+			routeOp: searchRouteOperation,
+			message: &SearchMessage{BaseDN: ""},
+			want:    "root",
+		},
+		{
+			name:    "add",
+			routeOp: addRouteOperation,
+			message: &AddMessage{DN: "uid=bob,dc=acme,dc=example"},
+			want:    "acme",
+		},
+		{
+			name:    "modify",
+			routeOp: modifyRouteOperation,
+			message: &ModifyMessage{DN: "uid=bob,ou=eng,dc=corp,dc=example"},
+			want:    "eng",
+		},
+		{
+			name:    "delete",
+			routeOp: deleteRouteOperation,
+			message: &DeleteMessage{DN: "uid=bob,dc=other,dc=example"},
+			want:    "root",
+		},
+		{
+			name:    "compare",
+			routeOp: compareRouteOperation,
+			message: &CompareMessage{DN: "uid=bob,dc=corp,dc=example"},
+			want:    "corp",
+		},
+		{
+			name:    "modify-dn",
+			routeOp: modifyDNRouteOperation,
+			message: &ModifyDNMessage{DN: "uid=bob,dc=acme,dc=example"},
+			want:    "acme",
+		},
+		{
+			name:    "invalid-dn",
+			routeOp: deleteRouteOperation,
+			message: &DeleteMessage{DN: "dc=corp,dc"},
+			want:    "root",
+		},
+		{
+			name:    "extended-without-dn",
+			routeOp: extendedRouteOperation,
+			message: &ExtendedOperationMessage{Name: ExtendedOperationWhoAmI},
+			want:    "root",
+		},
+		{
+			name:    "child-default-route",
+			routeOp: searchRouteOperation,
+			message: &SearchMessage{BaseDN: "dc=empty,dc=example"},
+			want:    "empty-default",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert, require := assert.New(t), require.New(t)
+			served = ""
+			w, err := newResponseWriter(bufio.NewWriter(&bytes.Buffer{}), &sync.Mutex{}, hclog.NewNullLogger(), 1, 1)
+			require.NoError(err)
+			req := &Request{ID: 1, routeOp: tc.routeOp, message: tc.message}
+			if m, ok := tc.message.(*ExtendedOperationMessage); ok {
+				req.extendedName = m.Name
+			}
+			root.serve(w, req)
+			assert.Equal(tc.want, served)
+		})
+	}
+}
+
+func TestMux_Use(t *testing.T) {
+	tests := []struct {
+		name            string
+		mw              []Middleware
+		wantErr         bool
+		wantErrIs       error
+		wantErrContains string
+	}{
+		{
+			name:            "missing-middleware",
+			wantErr:         true,
+			wantErrIs:       ErrInvalidParameter,
+			wantErrContains: "missing middleware",
+		},
+		{
+			name:            "nil-middleware",
+			mw:              []Middleware{func(h HandlerFunc) HandlerFunc { return h }, nil},
+			wantErr:         true,
+			wantErrIs:       ErrInvalidParameter,
+			wantErrContains: "missing middleware",
+		},
+		{
+			name: "valid",
+			mw:   []Middleware{func(h HandlerFunc) HandlerFunc { return h }},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert, require := assert.New(t), require.New(t)
+			m, err := NewMux()
+			require.NoError(err)
+			err = m.Use(tc.mw...)
+			if tc.wantErr {
+				require.Error(err)
+				if tc.wantErrIs != nil {
+					assert.ErrorIs(err, tc.wantErrIs)
+				}
+				if tc.wantErrContains != "" {
+					assert.Contains(err.Error(), tc.wantErrContains)
+				}
+				assert.Empty(m.middleware)
+				return
+			}
+			require.NoError(err)
+			assert.Len(m.middleware, len(tc.mw))
+		})
+	}
+}
+
+func TestMux_serve_middleware(t *testing.T) {
+	t.Parallel()
+	var called []string
+	mw := func(name string) Middleware {
+		return func(next HandlerFunc) HandlerFunc {
+			return func(w *ResponseWriter, r *Request) {
+				called = append(called, name)
+				next(w, r)
+			}
+		}
+	}
+	handler := func(name string) HandlerFunc {
+		return func(*ResponseWriter, *Request) { called = append(called, name) }
+	}
+	// deny short circuits requests without calling the wrapped handler
+	deny := func(next HandlerFunc) HandlerFunc {
+		return func(w *ResponseWriter, r *Request) {
+			called = append(called, "deny")
+			_ = w.Write(r.NewResponse(WithResponseCode(ResultInsufficientAccessRights)))
+		}
+	}
+
+	m, err := NewMux()
+	require.NoError(t, err)
+	require.NoError(t, m.Use(mw("mux-1"), mw("mux-2")))
+	require.NoError(t, m.Search(handler("search"), WithMiddleware(mw("route"))))
+	require.NoError(t, m.Delete(handler("delete"), WithMiddleware(deny)))
+	require.NoError(t, m.Unbind(handler("unbind")))
+	require.NoError(t, m.DefaultRoute(handler("default"), WithMiddleware(mw("default-route"))))
+
+	child, err := NewMux()
+	require.NoError(t, err)
+	require.NoError(t, child.Use(mw("child")))
+	require.NoError(t, child.Add(handler("child-add")))
+	require.NoError(t, m.Mount("dc=corp,dc=example", child))
+
+	noRoutes, err := NewMux()
+	require.NoError(t, err)
+	require.NoError(t, noRoutes.Use(mw("no-routes")))
+
+	tests := []struct {
+		name    string
+		mux     *Mux
+		routeOp routeOperation
+		message Message
+		want    []string
+	}{
+		{
+			name:    "route",
+			mux:     m,
+			routeOp: searchRouteOperation,
+			message: &SearchMessage{},
+			want:    []string{"mux-1", "mux-2", "route", "search"},
+		},
+		{
+			name:    "short-circuit",
+			mux:     m,
+			routeOp: deleteRouteOperation,
+			message: &DeleteMessage{DN: "uid=alice,dc=example"},
+			want:    []string{"mux-1", "mux-2", "deny"},
+		},
+		{
+			name:    "unbind",
+			mux:     m,
+			routeOp: unbindRouteOperation,
+			message: &UnbindMessage{},
+			want:    []string{"mux-1", "mux-2", "unbind"},
+		},
+		{
+			name:    "default",
+			mux:     m,
+			routeOp: modifyRouteOperation,
+			message: &ModifyMessage{DN: "uid=alice,dc=example"},
+			want:    []string{"mux-1", "mux-2", "default-route", "default"},
+		},
+		{
+			name:    "mounted",
+			mux:     m,
+			routeOp: addRouteOperation,
+			message: &AddMessage{DN: "uid=alice,dc=corp,dc=example"},
+			want:    []string{"mux-1", "mux-2", "child", "child-add"},
+		},
+		{
+			name:    "no-matching-handler",
+			mux:     noRoutes,
+			routeOp: searchRouteOperation,
+			message: &SearchMessage{},
+			want:    []string{"no-routes"},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert, require := assert.New(t), require.New(t)
+			called = nil
+			w, err := newResponseWriter(bufio.NewWriter(&bytes.Buffer{}), &sync.Mutex{}, hclog.NewNullLogger(), 1, 1)
+			require.NoError(err)
+			req := &Request{ID: 1, routeOp: tc.routeOp, message: tc.message}
+			if tc.routeOp == unbindRouteOperation {
+				tc.mux.serveUnbind(w, req)
+			} else {
+				tc.mux.serve(w, req)
+			}
+			assert.Equal(tc.want, called)
+		})
+	}
+}
+
+func TestMux_RootDSE(t *testing.T) {
+	t.Parallel()
+	t.Run("supported-control-attribute", func(t *testing.T) {
+		assert, require := assert.New(t), require.New(t)
+		m, err := NewMux(WithSupportedControls(ControlTypePaging))
+		require.NoError(err)
+		err = m.RootDSE(map[string][]string{"SupportedControl": {ControlTypePaging}})
+		require.Error(err)
+		assert.ErrorIs(err, ErrInvalidParameter)
+		assert.Contains(err.Error(), "supportedControl is set using WithSupportedControls")
+	})
+	t.Run("supported-ldap-version-attribute", func(t *testing.T) {
+		assert, require := assert.New(t), require.New(t)
+		m, err := NewMux(WithSupportedControls(ControlTypePaging))
+		require.NoError(err)
+		var buf bytes.Buffer
+		bufWriter := bufio.NewWriter(&buf)
+		w, err := newResponseWriter(bufWriter, &sync.Mutex{}, hclog.NewNullLogger(), 1, 1)
+		require.NoError(err)
+		m.rootDSEHandler(map[string][]string{"supportedldapversion": {"2", "3"}})(w, &Request{message: &SearchMessage{}})
+		require.NoError(bufWriter.Flush())
+
+		p, err := ber.ReadPacket(&buf)
+		require.NoError(err)
+		got := map[string][]string{}
+		for _, a := range p.Children[1].Children[1].Children {
+			values := []string{}
+			for _, v := range a.Children[1].Children {
+				values = append(values, v.Data.String())
+			}
+			got[a.Children[0].Value.(string)] = values
+		}
+		assert.Equal(map[string][]string{
+			"supportedldapversion":  {"2", "3"},
+			rootDSESupportedControl: {ControlTypePaging},
+		}, got)
+	})
+	t.Run("valid", func(t *testing.T) {
+		assert, require := assert.New(t), require.New(t)
+		m, err := NewMux(WithSupportedControls(ControlTypePaging))
+		require.NoError(err)
+		require.NoError(m.RootDSE(nil, WithLabel("root DSE")))
+		require.Len(m.routes, 1)
+		assert.IsType(&rootDSERoute{}, m.routes[0])
+		assert.Equal([]string{ControlTypePaging}, m.SupportedControls())
+	})
+	t.Run("with-control", func(t *testing.T) {
+		assert, require := assert.New(t), require.New(t)
+		m, err := NewMux()
+		require.NoError(err)
+		require.NoError(m.RootDSE(nil, WithControl(ControlTypeManageDsaIT)))
+		require.Len(m.routes, 1)
+		assert.Equal([]string{ControlTypeManageDsaIT}, m.Routes()[0].Controls)
+	})
+}
+
+func TestMux_ExtendedOperation(t *testing.T) {
+	t.Parallel()
+	assert, require := assert.New(t), require.New(t)
+	m, err := NewMux()
+	require.NoError(err)
+	require.NoError(m.ExtendedOperation(func(*ResponseWriter, *Request) {}, ExtendedOperationWhoAmI, WithControl(ControlTypeManageDsaIT)))
+	require.Len(m.routes, 1)
+	assert.Equal([]string{ControlTypeManageDsaIT}, m.Routes()[0].Controls)
+}
+
+func TestMux_Routes(t *testing.T) {
+	t.Parallel()
+	assert, require := assert.New(t), require.New(t)
+	h := func(*ResponseWriter, *Request) {}
+
+	m, err := NewMux()
+	require.NoError(err)
+	assert.Empty(m.Routes())
+
+	require.NoError(m.Bind(h, WithLabel("bind")))
+	require.NoError(m.SASLBind(SASLMechanismPlain, h, WithLabel("plain")))
+	require.NoError(m.Search(h,
+		WithLabel("people"),
+		WithBaseDN("ou=people,dc=example,dc=com"),
+		WithFilter("(uid=*)"),
+		WithScope(SingleLevel),
+		WithControl(ControlTypePaging),
+	))
+	require.NoError(m.Search(h, WithBaseDNSuffix("OU=Groups, DC=example,DC=com")))
+	require.NoError(m.ExtendedOperation(h, ExtendedOperationWhoAmI, WithLabel("whoami")))
+	require.NoError(m.Unbind(h, WithLabel("unbind")))
+	require.NoError(m.DefaultRoute(h, WithLabel("default")))
+
+	child, err := NewMux()
+	require.NoError(err)
+	require.NoError(child.Delete(h, WithLabel("corp-delete")))
+	require.NoError(m.Mount("dc=corp,dc=example", child))
+
+	assert.Equal([]RouteInfo{
+		{Operation: "bind", Label: "bind"},
+		{Operation: "bind", Label: "plain", Mechanism: SASLMechanismPlain},
+		{
+			Operation: "search",
+			Label:     "people",
+			BaseDN:    "ou=people,dc=example,dc=com",
+			Filter:    "(uid=*)",
+			Scope:     SingleLevel,
+			Controls:  []string{ControlTypePaging},
+		},
+		{Operation: "search", BaseDNSuffix: "OU=Groups,DC=example,DC=com"},
+		{Operation: "extendedOperation", Label: "whoami", ExtendedName: ExtendedOperationWhoAmI},
+		{Operation: "unbind", Label: "unbind"},
+		{Operation: "noRoute", Label: "default"},
+		{Operation: "delete", Label: "corp-delete", Mount: "dc=corp,dc=example"},
+	}, m.Routes())
+
+	// modifying the returned route info must not modify the routes
+	routes := m.Routes()
+	routes[2].Controls[0] = "modified"
+	assert.Equal([]string{ControlTypePaging}, m.Routes()[2].Controls)
+}
+
+func TestMux_serve_routeLabel(t *testing.T) {
+	t.Parallel()
+	var gotLabel string
+	h := func(_ *ResponseWriter, r *Request) { gotLabel = r.RouteLabel() }
+
+	m, err := NewMux()
+	require.NoError(t, err)
+	require.NoError(t, m.Search(h, WithLabel("people"), WithBaseDN("ou=people,dc=example,dc=com")))
+	require.NoError(t, m.Search(h))
+	require.NoError(t, m.Unbind(h, WithLabel("unbind")))
+	require.NoError(t, m.DefaultRoute(h, WithLabel("default")))
+
+	tests := []struct {
+		name    string
+		routeOp routeOperation
+		message Message
+		want    string
+	}{
+		{
+			name:    "labeled",
+			routeOp: searchRouteOperation,
+			message: &SearchMessage{BaseDN: "ou=people,dc=example,dc=com"},
+			want:    "people",
+		},
+		{
+			name:    "unlabeled",
+			routeOp: searchRouteOperation,
+			message: &SearchMessage{BaseDN: "ou=groups,dc=example,dc=com"},
+		},
+		{
+			name:    "unbind",
+			routeOp: unbindRouteOperation,
+			message: &UnbindMessage{},
+			want:    "unbind",
+		},
+		{
+			name:    "default",
+			routeOp: deleteRouteOperation,
+			message: &DeleteMessage{},
+			want:    "default",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert, require := assert.New(t), require.New(t)
+			gotLabel = "not-served"
+			w, err := newResponseWriter(bufio.NewWriter(&bytes.Buffer{}), &sync.Mutex{}, hclog.NewNullLogger(), 1, 1)
+			require.NoError(err)
+			req := &Request{ID: 1, routeOp: tc.routeOp, message: tc.message}
+			if tc.routeOp == unbindRouteOperation {
+				m.serveUnbind(w, req)
+			} else {
+				m.serve(w, req)
+			}
+			assert.Equal(tc.want, gotLabel)
+			assert.Equal(tc.want, req.RouteLabel())
+		})
+	}
+}
+
+func TestMux_SASLBind(t *testing.T) {
+	tests := []struct {
+		name            string
+		mux             *Mux
+		mechanism       SASLMechanism
+		fn              HandlerFunc
+		wantErr         bool
+		wantErrIs       error
+		wantErrContains string
+	}{
+		{
+			name:            "missing-mechanism",
+			mux:             func() *Mux { m, err := NewMux(); require.NoError(t, err); return m }(),
+			fn:              func(*ResponseWriter, *Request) {},
+			wantErr:         true,
+			wantErrIs:       ErrInvalidParameter,
+			wantErrContains: "missing SASL mechanism",
+		},
+		{
+			name:            "missing-fn",
+			mux:             func() *Mux { m, err := NewMux(); require.NoError(t, err); return m }(),
+			mechanism:       SASLMechanismPlain,
+			wantErr:         true,
+			wantErrIs:       ErrInvalidParameter,
+			wantErrContains: "missing HandlerFunc",
+		},
+		{
+			name:      "valid",
+			mux:       func() *Mux { m, err := NewMux(); require.NoError(t, err); return m }(),
+			mechanism: SASLMechanismPlain,
+			fn:        func(*ResponseWriter, *Request) {},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert, require := assert.New(t), require.New(t)
+			err := tc.mux.SASLBind(tc.mechanism, tc.fn)
+			if tc.wantErr {
+				require.Error(err)
+				if tc.wantErrIs != nil {
+					assert.ErrorIs(err, tc.wantErrIs)
+				}
+				if tc.wantErrContains != "" {
+					assert.Contains(err.Error(), tc.wantErrContains)
+				}
+				return
+			}
+			require.NoError(err)
+		})
+	}
+}
+
+func TestMux_Compare(t *testing.T) {
+	tests := []struct {
+		name            string
+		mux             *Mux
+		fn              HandlerFunc
+		wantErr         bool
+		wantErrIs       error
+		wantErrContains string
+	}{
+		{
+			name:            "missing-fn",
+			mux:             func() *Mux { m, err := NewMux(); require.NoError(t, err); return m }(),
+			wantErr:         true,
+			wantErrIs:       ErrInvalidParameter,
+			wantErrContains: "missing HandlerFunc",
+		},
+		{
+			name: "valid",
+			mux:  func() *Mux { m, err := NewMux(); require.NoError(t, err); return m }(),
+			fn:   func(*ResponseWriter, *Request) {},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert, require := assert.New(t), require.New(t)
+			err := tc.mux.Compare(tc.fn)
+			if tc.wantErr {
+				require.Error(err)
+				if tc.wantErrIs != nil {
+					assert.ErrorIs(err, tc.wantErrIs)
+				}
+				if tc.wantErrContains != "" {
+					assert.Contains(err.Error(), tc.wantErrContains)
+				}
+				return
+			}
+			require.NoError(err)
+		})
+	}
+}
+
+func TestMux_ModifyDN(t *testing.T) {
+	tests := []struct {
+		name            string
+		mux             *Mux
+		fn              HandlerFunc
+		wantErr         bool
+		wantErrIs       error
+		wantErrContains string
+	}{
+		{
+			name:            "missing-fn",
+			mux:             func() *Mux { m, err := NewMux(); require.NoError(t, err); return m }(),
+			wantErr:         true,
+			wantErrIs:       ErrInvalidParameter,
+			wantErrContains: "missing HandlerFunc",
+		},
+		{
+			name: "valid",
+			mux:  func() *Mux { m, err := NewMux(); require.NoError(t, err); return m }(),
+			fn:   func(*ResponseWriter, *Request) {},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert, require := assert.New(t), require.New(t)
+			err := tc.mux.ModifyDN(tc.fn)
+			if tc.wantErr {
+				require.Error(err)
+				if tc.wantErrIs != nil {
+					assert.ErrorIs(err, tc.wantErrIs)
+				}
+				if tc.wantErrContains != "" {
+					assert.Contains(err.Error(), tc.wantErrContains)
+				}
+				return
+			}
+			require.NoError(err)
+		})
+	}
+}
+
 func TestMux_Unbind(t *testing.T) {
 	tests := []struct {
 		name            string
@@ -8,7 +8,6 @@ import (
 	"io"
 
 	ber "github.com/go-asn1-ber/asn1-ber"
-	"github.com/go-ldap/ldap/v3"
 	"github.com/hashicorp/go-hclog"
 )
 
@@ -77,6 +76,40 @@ func (p *packet) controlPacket() (*packet, error) {
 	return controlPacket, nil
 }
 
+// criticalControlTypes returns the control types of the packet's critical
+// controls.
+func (p *packet) criticalControlTypes() ([]string, error) {
+	const (
+		op = "gldap.(packet).criticalControlTypes"
+
+		childControlType = 0
+		childCriticality = 1
+	)
+	controlPacket, err := p.controlPacket()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if controlPacket == nil {
+		return nil, nil
+	}
+	var controlTypes []string
+	for _, c := range controlPacket.Children {
+		if len(c.Children) <= childCriticality {
+			continue
+		}
+		// criticality is optional, so the second child may be the value
+		if critical, ok := c.Children[childCriticality].Value.(bool); !ok || !critical {
+			continue
+		}
+		controlType, ok := c.Children[childControlType].Value.(string)
+		if !ok {
+			return nil, fmt.Errorf("%s: expected string control type and got %T: %w", op, c.Children[childControlType].Value, ErrInvalidParameter)
+		}
+		controlTypes = append(controlTypes, controlType)
+	}
+	return controlTypes, nil
+}
+
 func (p *packet) requestPacket() (*packet, error) {
 	const (
 		op = "gldap.(packet).requestPacket"
@@ -134,6 +167,12 @@ func (p *packet) requestType() (requestType, error) {
 		return deleteRequestType, nil
 	case ApplicationUnbindRequest:
 		return unbindRequestType, nil
+	case ApplicationAbandonRequest:
+		return abandonRequestType, nil
+	case ApplicationCompareRequest:
+		return compareRequestType, nil
+	case ApplicationModifyDNRequest:
+		return modifyDNRequestType, nil
 	default:
 		return unknownRequestType, fmt.Errorf("%s: unhandled request type %d: %w", op, requestPacket.Tag, ErrInternal)
 	}
@@ -209,12 +248,18 @@ func (p *packet) modifyParameters() (*modifyParameters, error) {
 		chg.Modification.Type = modificationPacket.Children[childModificationType].Data.String()
 
 		// get the modification values
-		if len(modificationPacket.Children) < childModificationValues+1 {
+		if err := modificationPacket.assert(ber.ClassUniversal, ber.TypeConstructed, withTag(ber.TagSet), withAssertChild(childModificationValues)); err != nil {
 			return nil, fmt.Errorf("%s: missing modification values packet: %w", op, ErrInvalidParameter)
 		}
-		chg.Modification.Vals = make([]string, 0, len(modificationPacket.Children)-1)
-		for _, value := range modificationPacket.Children[1:] {
+		valuesPacket := packet{Packet: modificationPacket.Children[childModificationValues]}
+		chg.Modification.Vals = make([]string, 0, len(valuesPacket.Children))
+		chg.Modification.ByteValues = make([][]byte, 0, len(valuesPacket.Children))
+		for idx, value := range valuesPacket.Children {
+			if err := valuesPacket.assert(ber.ClassUniversal, ber.TypePrimitive, withTag(ber.TagOctetString), withAssertChild(idx)); err != nil {
+				return nil, fmt.Errorf("%s: invalid modification value packet: %w", op, ErrInvalidParameter)
+			}
 			chg.Modification.Vals = append(chg.Modification.Vals, value.Data.String())
+			chg.Modification.ByteValues = append(chg.Modification.ByteValues, decodeByteValue(value))
 		}
 
 		parameters.changes = append(parameters.changes, chg)
@@ -258,6 +303,52 @@ func (p *packet) extendedOperationName() (ExtendedOperationName, error) {
 	return ExtendedOperationName(n), nil
 }
 
+// extendedOperationValue returns the optional request value of an extended
+// operation request, which is nil when the request doesn't have a value.
+func (p *packet) extendedOperationValue() ([]byte, error) {
+	const (
+		op = "gldap.(Packet).extendedOperationValue"
+
+		childExtendedOperationValue = 1
+	)
+	requestPacket, err := p.requestPacket()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if requestPacket.Packet.Tag != ApplicationExtendedRequest {
+		return nil, fmt.Errorf("%s: not an extended operation request, expected tag %d and got %d: %w", op, ApplicationExtendedRequest, requestPacket.Tag, ErrInvalidParameter)
+	}
+	if len(requestPacket.Children) <= childExtendedOperationValue {
+		return nil, nil
+	}
+	if err := requestPacket.assert(ber.ClassContext, ber.TypePrimitive, withTag(1), withAssertChild(childExtendedOperationValue)); err != nil {
+		return nil, fmt.Errorf("%s: invalid request value packet: %w", op, ErrInvalidParameter)
+	}
+	return requestPacket.Children[childExtendedOperationValue].Data.Bytes(), nil
+}
+
+// extendedOperationControls decodes the optional controls of an extended
+// operation request
+func (p *packet) extendedOperationControls() ([]Control, error) {
+	const op = "gldap.(Packet).extendedOperationControls"
+	controlPacket, err := p.controlPacket()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	var controls []Control
+	if controlPacket != nil {
+		controls = make([]Control, 0, len(controlPacket.Children))
+		for _, c := range controlPacket.Children {
+			ctrl, err := decodeControl(c)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", op, err)
+			}
+			controls = append(controls, ctrl)
+		}
+	}
+	return controls, nil
+}
+
 // Password is a simple bind request password
 type Password string
 
@@ -305,6 +396,96 @@ func (p *packet) simpleBindParameters() (string, Password, []Control, error) {
 	return userName, Password(password), controls, nil
 }
 
+// bindAuthChoice returns the authentication choice of a bind request
+func (p *packet) bindAuthChoice() (AuthChoice, error) {
+	const (
+		op = "gldap.(Packet).bindAuthChoice"
+
+		childBindAuthentication = 2
+	)
+	requestPacket, err := p.requestPacket()
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+	if len(requestPacket.Children) <= childBindAuthentication {
+		// let simpleBindParameters(...) sort out what's missing
+		return SimpleAuthChoice, nil
+	}
+	authPacket := requestPacket.Children[childBindAuthentication]
+	if authPacket.ClassType != ber.ClassContext {
+		return "", fmt.Errorf("%s: invalid authentication choice class %d: %w", op, authPacket.ClassType, ErrInvalidParameter)
+	}
+	switch authPacket.Tag {
+	case 0:
+		return SimpleAuthChoice, nil
+	case 3:
+		return SASLAuthChoice, nil
+	default:
+		return "", fmt.Errorf("%s: unsupported authentication choice %d: %w", op, authPacket.Tag, ErrInvalidParameter)
+	}
+}
+
+type saslBindParameters struct {
+	userName    string
+	mechanism   SASLMechanism
+	credentials []byte
+	controls    []Control
+}
+
+// saslBindParameters decodes the sasl bind request parameters from the packet
+func (p *packet) saslBindParameters() (*saslBindParameters, error) {
+	const (
+		op = "gldap.(Packet).saslBindParameters"
+
+		childBindUserName       = 1
+		childBindAuthentication = 2
+
+		childMechanism   = 0
+		childCredentials = 1
+	)
+	requestPacket, err := p.requestPacket()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	var parameters saslBindParameters
+	if err := requestPacket.assert(ber.ClassUniversal, ber.TypePrimitive, withTag(ber.TagOctetString), withAssertChild(childBindUserName)); err != nil {
+		return nil, fmt.Errorf("%s: missing/invalid username packet: %w", op, ErrInvalidParameter)
+	}
+	parameters.userName = requestPacket.Children[childBindUserName].Data.String()
+
+	if err := requestPacket.assert(ber.ClassContext, ber.TypeConstructed, withTag(3), withAssertChild(childBindAuthentication)); err != nil {
+		return nil, fmt.Errorf("%s: missing/invalid sasl credentials packet: %w", op, ErrInvalidParameter)
+	}
+	saslPacket := &packet{Packet: requestPacket.Children[childBindAuthentication]}
+	if err := saslPacket.assert(ber.ClassUniversal, ber.TypePrimitive, withTag(ber.TagOctetString), withAssertChild(childMechanism)); err != nil {
+		return nil, fmt.Errorf("%s: missing/invalid sasl mechanism packet: %w", op, ErrInvalidParameter)
+	}
+	parameters.mechanism = SASLMechanism(saslPacket.Children[childMechanism].Data.String())
+
+	if len(saslPacket.Children) > childCredentials {
+		if err := saslPacket.assert(ber.ClassUniversal, ber.TypePrimitive, withTag(ber.TagOctetString), withAssertChild(childCredentials)); err != nil {
+			return nil, fmt.Errorf("%s: invalid sasl credentials packet: %w", op, ErrInvalidParameter)
+		}
+		parameters.credentials = saslPacket.Children[childCredentials].Data.Bytes()
+	}
+
+	controlPacket, err := p.controlPacket()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if controlPacket != nil {
+		parameters.controls = make([]Control, 0, len(controlPacket.Children))
+		for _, c := range controlPacket.Children {
+			ctrl, err := decodeControl(c)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", op, err)
+			}
+			parameters.controls = append(parameters.controls, ctrl)
+		}
+	}
+	return &parameters, nil
+}
+
 type addParameters struct {
 	dn         string
 	attributes []Attribute
@@ -373,6 +554,7 @@ type searchParameters struct {
 	timeLimit    int64
 	typesOnly    bool
 	filter       string
+	parsedFilter Filter
 	attributes   []string
 	controls     []Control
 }
@@ -449,11 +631,12 @@ func (p *packet) searchParmeters() (*searchParameters, error) {
 		return nil, fmt.Errorf("%s: missing filter: %w", op, ErrInvalidParameter)
 	}
 
-	filter, err := ldap.DecompileFilter(requestPacket.Children[childFilter])
+	filter, err := decodeFilter(requestPacket.Children[childFilter])
 	if err != nil {
-		return nil, fmt.Errorf("%s: unable to decompile filter: %w", op, err)
+		return nil, fmt.Errorf("%s: unable to decode filter: %w", op, err)
 	}
-	searchFor.filter = filter
+	searchFor.parsedFilter = filter
+	searchFor.filter = filter.String()
 
 	// check for attributes packet
 	if len(requestPacket.Children) < childAttributes+1 {
@@ -542,8 +725,8 @@ func (p *packet) assertApplicationRequest() error {
 	}
 	switch chkPacket.TagType {
 	case ber.TypePrimitive:
-		if chkPacket.Tag != ApplicationDelRequest && chkPacket.Tag != ApplicationUnbindRequest {
-			return fmt.Errorf("%s: incorrect type, primitive %q must be a delete request %q or an unbind request %q, but got %q", op, ber.TypePrimitive, ApplicationDelRequest, ApplicationUnbindRequest, chkPacket.Tag)
+		if chkPacket.Tag != ApplicationDelRequest && chkPacket.Tag != ApplicationUnbindRequest && chkPacket.Tag != ApplicationAbandonRequest {
+			return fmt.Errorf("%s: incorrect type, primitive %q must be a delete request %q, an unbind request %q or an abandon request %q, but got %q", op, ber.TypePrimitive, ApplicationDelRequest, ApplicationUnbindRequest, ApplicationAbandonRequest, chkPacket.Tag)
 		}
 	case ber.TypeConstructed:
 	default:
@@ -627,6 +810,171 @@ func (p *packet) deleteParameters() (string, []Control, error) {
 	return dn, controls, nil
 }
 
+// abandonParameters decodes the message ID of the request being abandoned and
+// the controls from the packet
+func (p *packet) abandonParameters() (int64, []Control, error) {
+	const op = "gldap.(packet).abandonParameters"
+
+	requestPacket, err := p.requestPacket()
+	if err != nil {
+		return 0, nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if requestPacket.Packet.Tag != ApplicationAbandonRequest {
+		return 0, nil, fmt.Errorf("%s: not an abandon request, expected tag %d and got %d: %w", op, ApplicationAbandonRequest, requestPacket.Tag, ErrInvalidParameter)
+	}
+	if requestPacket.Data == nil || requestPacket.Data.Len() == 0 {
+		return 0, nil, fmt.Errorf("%s: missing message id: %w", op, ErrInvalidParameter)
+	}
+	messageID, err := ber.ParseInt64(requestPacket.Data.Bytes())
+	if err != nil {
+		return 0, nil, fmt.Errorf("%s: invalid message id: %w", op, ErrInvalidParameter)
+	}
+
+	controlPacket, err := p.controlPacket()
+	if err != nil {
+		return 0, nil, fmt.Errorf("%s: %w", op, err)
+	}
+	var controls []Control
+	if controlPacket != nil {
+		controls = make([]Control, 0, len(controlPacket.Children))
+		for _, c := range controlPacket.Children {
+			ctrl, err := decodeControl(c)
+			if err != nil {
+				return 0, nil, fmt.Errorf("%s: %w", op, err)
+			}
+			controls = append(controls, ctrl)
+		}
+	}
+	return messageID, controls, nil
+}
+
+type compareParameters struct {
+	dn        string
+	assertion AttributeValueAssertion
+	controls  []Control
+}
+
+// compareParameters decodes the compare request parameters from the packet
+func (p *packet) compareParameters() (*compareParameters, error) {
+	const op = "gldap.(Packet).compareParameters"
+	const (
+		childDN        = 0
+		childAssertion = 1
+	)
+	var compare compareParameters
+	requestPacket, err := p.requestPacket()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	// validate that it's a compare request
+	if requestPacket.Packet.Tag != ApplicationCompareRequest {
+		return nil, fmt.Errorf("%s: not a compare request, expected tag %d and got %d: %w", op, ApplicationCompareRequest, requestPacket.Tag, ErrInvalidParameter)
+	}
+	// DN child
+	if err := requestPacket.assert(ber.ClassUniversal, ber.TypePrimitive, withTag(ber.TagOctetString), withAssertChild(childDN)); err != nil {
+		return nil, fmt.Errorf("%s: missing/invalid DN: %w", op, ErrInvalidParameter)
+	}
+	compare.dn = requestPacket.Children[childDN].Data.String()
+
+	// attribute value assertion child
+	if err := requestPacket.assert(ber.ClassUniversal, ber.TypeConstructed, withTag(ber.TagSequence), withAssertChild(childAssertion)); err != nil {
+		return nil, fmt.Errorf("%s: missing/invalid attribute value assertion: %w", op, ErrInvalidParameter)
+	}
+	ava, err := decodeAttributeValueAssertion(requestPacket.Children[childAssertion])
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to decode attribute value assertion: %w", op, err)
+	}
+	compare.assertion = *ava
+
+	controlPacket, err := p.controlPacket()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if controlPacket != nil {
+		compare.controls = make([]Control, 0, len(controlPacket.Children))
+		for _, c := range controlPacket.Children {
+			ctrl, err := decodeControl(c)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", op, err)
+			}
+			compare.controls = append(compare.controls, ctrl)
+		}
+	}
+	return &compare, nil
+}
+
+type modifyDNParameters struct {
+	dn           string
+	newRDN       string
+	deleteOldRDN bool
+	newSuperior  string
+	controls     []Control
+}
+
+// modifyDNParameters decodes the modify DN request parameters from the packet
+func (p *packet) modifyDNParameters() (*modifyDNParameters, error) {
+	const op = "gldap.(Packet).modifyDNParameters"
+	const (
+		childDN           = 0
+		childNewRDN       = 1
+		childDeleteOldRDN = 2
+		childNewSuperior  = 3
+	)
+	var modDN modifyDNParameters
+	requestPacket, err := p.requestPacket()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	// validate that it's a modify DN request
+	if requestPacket.Packet.Tag != ApplicationModifyDNRequest {
+		return nil, fmt.Errorf("%s: not a modify DN request, expected tag %d and got %d: %w", op, ApplicationModifyDNRequest, requestPacket.Tag, ErrInvalidParameter)
+	}
+	// DN child
+	if err := requestPacket.assert(ber.ClassUniversal, ber.TypePrimitive, withTag(ber.TagOctetString), withAssertChild(childDN)); err != nil {
+		return nil, fmt.Errorf("%s: missing/invalid DN: %w", op, ErrInvalidParameter)
+	}
+	modDN.dn = requestPacket.Children[childDN].Data.String()
+
+	// new RDN child
+	if err := requestPacket.assert(ber.ClassUniversal, ber.TypePrimitive, withTag(ber.TagOctetString), withAssertChild(childNewRDN)); err != nil {
+		return nil, fmt.Errorf("%s: missing/invalid new RDN: %w", op, ErrInvalidParameter)
+	}
+	modDN.newRDN = requestPacket.Children[childNewRDN].Data.String()
+
+	// delete old RDN child
+	if err := requestPacket.assert(ber.ClassUniversal, ber.TypePrimitive, withTag(ber.TagBoolean), withAssertChild(childDeleteOldRDN)); err != nil {
+		return nil, fmt.Errorf("%s: missing/invalid delete old RDN: %w", op, ErrInvalidParameter)
+	}
+	var ok bool
+	if modDN.deleteOldRDN, ok = requestPacket.Children[childDeleteOldRDN].Value.(bool); !ok {
+		return nil, fmt.Errorf("%s: delete old RDN is not a bool: %w", op, ErrInvalidParameter)
+	}
+
+	// optional new superior child
+	if len(requestPacket.Children) > childNewSuperior {
+		if err := requestPacket.assert(ber.ClassContext, ber.TypePrimitive, withTag(0), withAssertChild(childNewSuperior)); err != nil {
+			return nil, fmt.Errorf("%s: invalid new superior: %w", op, ErrInvalidParameter)
+		}
+		modDN.newSuperior = requestPacket.Children[childNewSuperior].Data.String()
+	}
+
+	controlPacket, err := p.controlPacket()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if controlPacket != nil {
+		modDN.controls = make([]Control, 0, len(controlPacket.Children))
+		for _, c := range controlPacket.Children {
+			ctrl, err := decodeControl(c)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", op, err)
+			}
+			modDN.controls = append(modDN.controls, ctrl)
+		}
+	}
+	return &modDN, nil
+}
+
 var tagMap = map[ber.Tag]string{
 	ber.TagEOC:              "EOC (End-of-Content)",
 	ber.TagBoolean:          "Boolean",
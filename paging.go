@@ -0,0 +1,248 @@
+// Copyright (c) Jim Lambert
+// SPDX-License-Identifier: MIT
+
+package gldap
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+)
+
+const (
+	// pagingCookieLen is the length of the opaque cookies of paging cursors
+	pagingCookieLen = 16
+
+	// defaultMaxPagingCursors is the default maximum number of paging
+	// cursors stored for a connection.  See: WithMaxPagingCursors
+	defaultMaxPagingCursors = 10
+)
+
+// EntryIterator returns the next entry of a search's results and false when
+// there are no more entries.  See: Request.NextPage
+type EntryIterator func() (*Entry, bool)
+
+// NewEntryIterator returns an EntryIterator over the entries.
+func NewEntryIterator(entries []*Entry) EntryIterator {
+	i := 0
+	return func() (*Entry, bool) {
+		if i >= len(entries) {
+			return nil, false
+		}
+		e := entries[i]
+		i++
+		return e, true
+	}
+}
+
+// Page is a page of a search's entries for a paged results control.
+// See: Request.NextPage
+type Page struct {
+	// Entries of the page
+	Entries []*Entry
+	// Cookie for the next page, which is empty for the last page
+	Cookie []byte
+}
+
+// Control returns the paging control for the page's SearchResponseDone
+func (p *Page) Control() *ControlPaging {
+	return &ControlPaging{Cookie: p.Cookie}
+}
+
+// pagingCursor is the state of a connection's paged search between requests
+type pagingCursor struct {
+	// search identifies the search which the cursor is paging through
+	search string
+	// messageID is the message ID of the last request for the cursor
+	messageID int64
+	next      EntryIterator
+	// pending is the first entry of the next page
+	pending *Entry
+	// seq is the order the cursor was stored in, which is used to evict the
+	// least recently stored cursor
+	seq uint64
+}
+
+// pagedSearch identifies a search request, since every request for a paged
+// search must be the same other than its paging control.
+func pagedSearch(m *SearchMessage) string {
+	return fmt.Sprintf("%s:%d:%s", m.BaseDN, m.Scope, m.Filter)
+}
+
+// PagingControl returns the search request's paging control.  It returns
+// false when the request doesn't have one.
+func (m *SearchMessage) PagingControl() (*ControlPaging, bool) {
+	for _, c := range m.Controls {
+		if c, ok := c.(*ControlPaging); ok {
+			return c, true
+		}
+	}
+	return nil, false
+}
+
+// NextPage returns the next page of a paged search (RFC 2696) for the
+// request's paging control.  A request without a cookie starts a new paged
+// search of the entries, and a request with a cookie continues the paged
+// search the cookie was returned for (entries is ignored and may be nil).
+// When there are more entries, the cursor is stored for the connection using
+// the page's cookie.  A paging size of 0 ends the paged search and returns an
+// empty page.
+//
+// Cursors are dropped when their last page is returned, their request is
+// abandoned or cancelled, or the client disconnects.  A connection stores at
+// most 10 cursors (see: WithMaxPagingCursors) and the least recently stored
+// cursor is evicted to store another.  An error is returned for an unknown
+// cookie, which handlers typically respond to with ResultUnwillingToPerform,
+// and when the request's context is done before the cursor is stored.
+// see: https://www.rfc-editor.org/rfc/rfc2696
+func (r *Request) NextPage(entries EntryIterator, paging *ControlPaging) (*Page, error) {
+	const op = "gldap.(Request).NextPage"
+	switch {
+	case paging == nil:
+		return nil, fmt.Errorf("%s: missing paging control: %w", op, ErrInvalidParameter)
+	case r.conn == nil:
+		return nil, fmt.Errorf("%s: missing connection: %w", op, ErrInvalidState)
+	}
+	m, ok := r.message.(*SearchMessage)
+	if !ok {
+		return nil, fmt.Errorf("%s: %T not a search request: %w", op, r.message, ErrInvalidParameter)
+	}
+	var cursor *pagingCursor
+	if len(paging.Cookie) == 0 {
+		if entries == nil {
+			return nil, fmt.Errorf("%s: missing entries: %w", op, ErrInvalidParameter)
+		}
+		cursor = &pagingCursor{
+			search: pagedSearch(m),
+			next:   entries,
+		}
+	} else {
+		if cursor = r.conn.takePagingCursor(paging.Cookie); cursor == nil {
+			return nil, fmt.Errorf("%s: unknown paging cookie: %w", op, ErrInvalidParameter)
+		}
+		if cursor.search != pagedSearch(m) {
+			return nil, fmt.Errorf("%s: paging cookie is for a different search: %w", op, ErrInvalidParameter)
+		}
+	}
+	page := &Page{}
+	if paging.PagingSize == 0 {
+		return page, nil
+	}
+	for uint32(len(page.Entries)) < paging.PagingSize {
+		e := cursor.pending
+		if e != nil {
+			cursor.pending = nil
+		} else if e, ok = cursor.next(); !ok {
+			return page, nil
+		}
+		page.Entries = append(page.Entries, e)
+	}
+	if cursor.pending, ok = cursor.next(); !ok {
+		return page, nil
+	}
+	cookie := make([]byte, pagingCookieLen)
+	if _, err := rand.Read(cookie); err != nil {
+		return nil, fmt.Errorf("%s: unable to generate paging cookie: %w", op, err)
+	}
+	cursor.messageID = m.GetID()
+	if err := r.conn.storePagingCursor(r.Context(), cookie, cursor); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	page.Cookie = cookie
+	return page, nil
+}
+
+// WritePage writes the next page of a paged search's entries (see: NextPage)
+// followed by a successful SearchResponseDone with the page's paging control.
+// Nothing is written when an error is returned.
+func (r *Request) WritePage(w *ResponseWriter, entries EntryIterator, paging *ControlPaging) error {
+	const op = "gldap.(Request).WritePage"
+	if w == nil {
+		return fmt.Errorf("%s: missing response writer: %w", op, ErrInvalidParameter)
+	}
+	page, err := r.NextPage(entries, paging)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	for _, e := range page.Entries {
+		resp := r.NewSearchResponseEntry(e.DN)
+		resp.entry.Attributes = e.Attributes
+		if err := w.Write(resp); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+	done := r.NewSearchDoneResponse(WithResponseCode(ResultSuccess))
+	done.SetControls(page.Control())
+	if err := w.Write(done); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// takePagingCursor removes and returns the conn's paging cursor for the
+// cookie, so only one request at a time can use it.  It returns nil when
+// there's no cursor for the cookie.
+func (c *conn) takePagingCursor(cookie []byte) *pagingCursor {
+	c.pagingMu.Lock()
+	defer c.pagingMu.Unlock()
+	cursor, ok := c.pagingCursors[string(cookie)]
+	if !ok {
+		return nil
+	}
+	delete(c.pagingCursors, string(cookie))
+	return cursor
+}
+
+// storePagingCursor stores the conn's paging cursor for the cookie, evicting
+// the least recently stored cursor when the conn has its maximum number of
+// cursors.  The cursor isn't stored when the request's context is done, which
+// is checked while holding the lock since a request's cursors are dropped
+// after its context is cancelled (see: abandonRequest and cancelRequest).
+func (c *conn) storePagingCursor(ctx context.Context, cookie []byte, cursor *pagingCursor) error {
+	const op = "gldap.(Conn).storePagingCursor"
+	c.pagingMu.Lock()
+	defer c.pagingMu.Unlock()
+	if ctx.Err() != nil {
+		return fmt.Errorf("%s: request is done: %w", op, context.Cause(ctx))
+	}
+	if c.pagingCursors == nil {
+		c.pagingCursors = map[string]*pagingCursor{}
+	}
+	maxCursors := c.maxPagingCursors
+	if maxCursors <= 0 {
+		maxCursors = defaultMaxPagingCursors
+	}
+	for len(c.pagingCursors) >= maxCursors {
+		var oldest *pagingCursor
+		var oldestCookie string
+		for k, v := range c.pagingCursors {
+			if oldest == nil || v.seq < oldest.seq {
+				oldest, oldestCookie = v, k
+			}
+		}
+		delete(c.pagingCursors, oldestCookie)
+	}
+	c.pagingSeq++
+	cursor.seq = c.pagingSeq
+	c.pagingCursors[string(cookie)] = cursor
+	return nil
+}
+
+// dropPagingCursors drops the conn's paging cursors for the message ID, which
+// is the message ID of the last request for the cursor.
+func (c *conn) dropPagingCursors(messageID int64) {
+	c.pagingMu.Lock()
+	defer c.pagingMu.Unlock()
+	for cookie, cursor := range c.pagingCursors {
+		if cursor.messageID == messageID {
+			delete(c.pagingCursors, cookie)
+		}
+	}
+}
+
+// resetPaging drops all of the conn's paging cursors
+func (c *conn) resetPaging() {
+	c.pagingMu.Lock()
+	defer c.pagingMu.Unlock()
+	c.pagingCursors = nil
+}
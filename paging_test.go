@@ -0,0 +1,236 @@
+// Copyright (c) Jim Lambert
+// SPDX-License-Identifier: MIT
+
+package gldap
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testPagedSearchRequest(t *testing.T, c *conn, messageID int64, filter string) *Request {
+	t.Helper()
+	return &Request{
+		conn:    c,
+		routeOp: searchRouteOperation,
+		message: &SearchMessage{
+			baseMessage: baseMessage{id: messageID},
+			BaseDN:      "dc=example,dc=org",
+			Scope:       WholeSubtree,
+			Filter:      filter,
+		},
+	}
+}
+
+func testPagedEntries(t *testing.T, n int) []*Entry {
+	t.Helper()
+	entries := make([]*Entry, 0, n)
+	for i := 0; i < n; i++ {
+		entries = append(entries, NewEntry(fmt.Sprintf("cn=user%d,dc=example,dc=org", i), map[string][]string{"cn": {fmt.Sprintf("user%d", i)}}))
+	}
+	return entries
+}
+
+func TestRequest_NextPage(t *testing.T) {
+	t.Parallel()
+
+	t.Run("pages", func(t *testing.T) {
+		assert, require := assert.New(t), require.New(t)
+		c := &conn{}
+		entries := testPagedEntries(t, 5)
+
+		page, err := testPagedSearchRequest(t, c, 1, "(cn=*)").NextPage(NewEntryIterator(entries), &ControlPaging{PagingSize: 2})
+		require.NoError(err)
+		assert.Equal(entries[0:2], page.Entries)
+		require.Len(page.Cookie, pagingCookieLen)
+		assert.Equal(&ControlPaging{Cookie: page.Cookie}, page.Control())
+		assert.Len(c.pagingCursors, 1)
+
+		page, err = testPagedSearchRequest(t, c, 2, "(cn=*)").NextPage(nil, &ControlPaging{PagingSize: 2, Cookie: page.Cookie})
+		require.NoError(err)
+		assert.Equal(entries[2:4], page.Entries)
+		require.NotEmpty(page.Cookie)
+		assert.Len(c.pagingCursors, 1)
+
+		lastCookie := page.Cookie
+		page, err = testPagedSearchRequest(t, c, 3, "(cn=*)").NextPage(nil, &ControlPaging{PagingSize: 2, Cookie: lastCookie})
+		require.NoError(err)
+		assert.Equal(entries[4:], page.Entries)
+		assert.Empty(page.Cookie)
+		assert.Empty(c.pagingCursors)
+
+		_, err = testPagedSearchRequest(t, c, 4, "(cn=*)").NextPage(nil, &ControlPaging{PagingSize: 2, Cookie: lastCookie})
+		require.Error(err)
+		assert.ErrorIs(err, ErrInvalidParameter)
+		assert.Contains(err.Error(), "unknown paging cookie")
+	})
+	t.Run("exact-page", func(t *testing.T) {
+		assert, require := assert.New(t), require.New(t)
+		c := &conn{}
+		entries := testPagedEntries(t, 2)
+		page, err := testPagedSearchRequest(t, c, 1, "(cn=*)").NextPage(NewEntryIterator(entries), &ControlPaging{PagingSize: 2})
+		require.NoError(err)
+		assert.Equal(entries, page.Entries)
+		assert.Empty(page.Cookie)
+		assert.Empty(c.pagingCursors)
+	})
+	t.Run("size-zero-ends-search", func(t *testing.T) {
+		assert, require := assert.New(t), require.New(t)
+		c := &conn{}
+		page, err := testPagedSearchRequest(t, c, 1, "(cn=*)").NextPage(NewEntryIterator(testPagedEntries(t, 5)), &ControlPaging{PagingSize: 2})
+		require.NoError(err)
+		require.NotEmpty(page.Cookie)
+
+		page, err = testPagedSearchRequest(t, c, 2, "(cn=*)").NextPage(nil, &ControlPaging{PagingSize: 0, Cookie: page.Cookie})
+		require.NoError(err)
+		assert.Empty(page.Entries)
+		assert.Empty(page.Cookie)
+		assert.Empty(c.pagingCursors)
+	})
+	t.Run("different-search", func(t *testing.T) {
+		assert, require := assert.New(t), require.New(t)
+		c := &conn{}
+		page, err := testPagedSearchRequest(t, c, 1, "(cn=*)").NextPage(NewEntryIterator(testPagedEntries(t, 5)), &ControlPaging{PagingSize: 2})
+		require.NoError(err)
+
+		_, err = testPagedSearchRequest(t, c, 2, "(cn=alice)").NextPage(nil, &ControlPaging{PagingSize: 2, Cookie: page.Cookie})
+		require.Error(err)
+		assert.ErrorIs(err, ErrInvalidParameter)
+		assert.Contains(err.Error(), "paging cookie is for a different search")
+	})
+	t.Run("abandon", func(t *testing.T) {
+		assert, require := assert.New(t), require.New(t)
+		c := &conn{logger: hclog.NewNullLogger()}
+		first, err := testPagedSearchRequest(t, c, 1, "(cn=*)").NextPage(NewEntryIterator(testPagedEntries(t, 5)), &ControlPaging{PagingSize: 2})
+		require.NoError(err)
+		second, err := testPagedSearchRequest(t, c, 2, "(cn=*)").NextPage(NewEntryIterator(testPagedEntries(t, 5)), &ControlPaging{PagingSize: 2})
+		require.NoError(err)
+		require.Len(c.pagingCursors, 2)
+
+		c.abandonRequest(1)
+		require.Len(c.pagingCursors, 1)
+		assert.Contains(c.pagingCursors, string(second.Cookie))
+		assert.NotContains(c.pagingCursors, string(first.Cookie))
+
+		c.resetPaging()
+		assert.Empty(c.pagingCursors)
+	})
+	t.Run("done-mid-page", func(t *testing.T) {
+		tests := []struct {
+			name    string
+			done    func(c *conn, r *Request)
+			wantErr error
+		}{
+			{
+				name:    "abandoned",
+				done:    func(c *conn, r *Request) { c.abandonRequest(r.message.GetID()) },
+				wantErr: ErrAbandoned,
+			},
+			{
+				name:    "canceled",
+				done:    func(c *conn, r *Request) { r.cancel(ErrCanceled) },
+				wantErr: ErrCanceled,
+			},
+		}
+		for _, tc := range tests {
+			tc := tc
+			t.Run(tc.name, func(t *testing.T) {
+				assert, require := assert.New(t), require.New(t)
+				c := &conn{logger: hclog.NewNullLogger()}
+				r := testPagedSearchRequest(t, c, 1, "(cn=*)")
+				r.initContext(context.Background())
+				defer r.cancel(nil)
+				c.trackRequest(nil, r)
+
+				// the request is done while the page is being read
+				entries := NewEntryIterator(testPagedEntries(t, 5))
+				read := 0
+				next := func() (*Entry, bool) {
+					if read++; read == 2 {
+						tc.done(c, r)
+					}
+					return entries()
+				}
+				_, err := r.NextPage(next, &ControlPaging{PagingSize: 2})
+				require.Error(err)
+				assert.ErrorIs(err, tc.wantErr)
+				assert.Empty(c.pagingCursors)
+			})
+		}
+	})
+	t.Run("max-cursors", func(t *testing.T) {
+		assert, require := assert.New(t), require.New(t)
+		c := &conn{maxPagingCursors: 2}
+		start := func(messageID int64) *Page {
+			page, err := testPagedSearchRequest(t, c, messageID, "(cn=*)").NextPage(NewEntryIterator(testPagedEntries(t, 5)), &ControlPaging{PagingSize: 2})
+			require.NoError(err)
+			require.NotEmpty(page.Cookie)
+			return page
+		}
+		first, second := start(1), start(2)
+
+		// continuing the first search stores it again, so the second search
+		// is the least recently stored
+		first, err := testPagedSearchRequest(t, c, 3, "(cn=*)").NextPage(nil, &ControlPaging{PagingSize: 2, Cookie: first.Cookie})
+		require.NoError(err)
+		require.NotEmpty(first.Cookie)
+
+		third := start(4)
+		require.Len(c.pagingCursors, 2)
+		assert.Contains(c.pagingCursors, string(first.Cookie))
+		assert.Contains(c.pagingCursors, string(third.Cookie))
+		assert.NotContains(c.pagingCursors, string(second.Cookie))
+
+		_, err = testPagedSearchRequest(t, c, 5, "(cn=*)").NextPage(nil, &ControlPaging{PagingSize: 2, Cookie: second.Cookie})
+		require.Error(err)
+		assert.Contains(err.Error(), "unknown paging cookie")
+	})
+	t.Run("default-max-cursors", func(t *testing.T) {
+		assert := assert.New(t)
+		c := &conn{}
+		for i := 1; i <= defaultMaxPagingCursors+1; i++ {
+			_, err := testPagedSearchRequest(t, c, int64(i), "(cn=*)").NextPage(NewEntryIterator(testPagedEntries(t, 5)), &ControlPaging{PagingSize: 2})
+			require.NoError(t, err)
+		}
+		assert.Len(c.pagingCursors, defaultMaxPagingCursors)
+	})
+	t.Run("missing-paging-control", func(t *testing.T) {
+		_, err := testPagedSearchRequest(t, &conn{}, 1, "(cn=*)").NextPage(NewEntryIterator(nil), nil)
+		require.Error(t, err)
+		assert.ErrorIs(t, err, ErrInvalidParameter)
+		assert.Contains(t, err.Error(), "missing paging control")
+	})
+	t.Run("missing-entries", func(t *testing.T) {
+		_, err := testPagedSearchRequest(t, &conn{}, 1, "(cn=*)").NextPage(nil, &ControlPaging{PagingSize: 2})
+		require.Error(t, err)
+		assert.ErrorIs(t, err, ErrInvalidParameter)
+		assert.Contains(t, err.Error(), "missing entries")
+	})
+	t.Run("not-search", func(t *testing.T) {
+		r := &Request{conn: &conn{}, message: &DeleteMessage{}}
+		_, err := r.NextPage(NewEntryIterator(nil), &ControlPaging{PagingSize: 2})
+		require.Error(t, err)
+		assert.ErrorIs(t, err, ErrInvalidParameter)
+		assert.Contains(t, err.Error(), "not a search request")
+	})
+}
+
+func TestSearchMessage_PagingControl(t *testing.T) {
+	t.Parallel()
+	assert := assert.New(t)
+	m := &SearchMessage{}
+	got, ok := m.PagingControl()
+	assert.False(ok)
+	assert.Nil(got)
+
+	paging := &ControlPaging{PagingSize: 10}
+	m.Controls = []Control{&ControlManageDsaIT{}, paging}
+	got, ok = m.PagingControl()
+	assert.True(ok)
+	assert.Equal(paging, got)
+}
@@ -0,0 +1,152 @@
+// Copyright (c) Jim Lambert
+// SPDX-License-Identifier: MIT
+
+package gldap
+
+import (
+	"fmt"
+
+	ber "github.com/go-asn1-ber/asn1-ber"
+)
+
+// PasswordModifyRequestValue is the request value of an
+// ExtendedOperationPasswordModify request as defined in
+// https://datatracker.ietf.org/doc/html/rfc3062#section-2
+type PasswordModifyRequestValue struct {
+	// UserIdentity is the optional identity of the user whose password is
+	// being modified.  When it's empty, the user associated with the
+	// connection is being modified.
+	UserIdentity string
+	// OldPassword is the optional current password of the user
+	OldPassword Password
+	// NewPassword is the optional new password for the user.  When it's empty,
+	// the server is expected to generate a new password.
+	NewPassword Password
+}
+
+// DecodePasswordModifyRequestValue decodes the request value of an
+// ExtendedOperationPasswordModify request, which is defined as:
+//
+//	PasswdModifyRequestValue ::= SEQUENCE {
+//		userIdentity    [0]  OCTET STRING OPTIONAL
+//		oldPasswd       [1]  OCTET STRING OPTIONAL
+//		newPasswd       [2]  OCTET STRING OPTIONAL }
+//
+// The request value is optional, so an empty value decodes to an empty
+// PasswordModifyRequestValue.
+func DecodePasswordModifyRequestValue(value []byte) (*PasswordModifyRequestValue, error) {
+	const (
+		op = "gldap.DecodePasswordModifyRequestValue"
+
+		tagUserIdentity = 0
+		tagOldPassword  = 1
+		tagNewPassword  = 2
+	)
+	var v PasswordModifyRequestValue
+	if len(value) == 0 {
+		return &v, nil
+	}
+	berPacket, err := ber.DecodePacketErr(value)
+	if err != nil {
+		return nil, fmt.Errorf("%s: unable to decode request value: %w", op, ErrInvalidParameter)
+	}
+	seq := &packet{Packet: berPacket}
+	if err := seq.assert(ber.ClassUniversal, ber.TypeConstructed, withTag(ber.TagSequence)); err != nil {
+		return nil, fmt.Errorf("%s: invalid request value sequence: %w", op, ErrInvalidParameter)
+	}
+	for idx, child := range seq.Children {
+		if err := seq.assert(ber.ClassContext, ber.TypePrimitive, withAssertChild(idx)); err != nil {
+			return nil, fmt.Errorf("%s: invalid request value child %d: %w", op, idx, ErrInvalidParameter)
+		}
+		switch child.Tag {
+		case tagUserIdentity:
+			v.UserIdentity = child.Data.String()
+		case tagOldPassword:
+			v.OldPassword = Password(child.Data.String())
+		case tagNewPassword:
+			v.NewPassword = Password(child.Data.String())
+		default:
+			return nil, fmt.Errorf("%s: unknown request value tag %d: %w", op, child.Tag, ErrInvalidParameter)
+		}
+	}
+	return &v, nil
+}
+
+// PasswordModifyMessage is an ExtendedOperationPasswordModify request message
+// (see: https://datatracker.ietf.org/doc/html/rfc3062).  When its NewPassword
+// is empty, the server is expected to generate a new password and return it
+// via PasswordModifyResponse.SetGeneratedPassword(...)
+type PasswordModifyMessage struct {
+	baseMessage
+	PasswordModifyRequestValue
+}
+
+// GetPasswordModifyMessage retrieves the PasswordModifyMessage from the
+// request, which allows you handle the request based on the message
+// attributes.  An error is returned if the request isn't an
+// ExtendedOperationPasswordModify request or its request value is invalid.
+func (r *Request) GetPasswordModifyMessage() (*PasswordModifyMessage, error) {
+	const op = "gldap.(Request).GetPasswordModifyMessage"
+	m, ok := r.message.(*ExtendedOperationMessage)
+	if !ok || m.Name != ExtendedOperationPasswordModify {
+		return nil, fmt.Errorf("%s: %T not a password modify request: %w", op, r.message, ErrInvalidParameter)
+	}
+	v, err := DecodePasswordModifyRequestValue(m.ByteValue)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return &PasswordModifyMessage{
+		baseMessage:                m.baseMessage,
+		PasswordModifyRequestValue: *v,
+	}, nil
+}
+
+// PasswordModifyResponse represents a response to an
+// ExtendedOperationPasswordModify request
+type PasswordModifyResponse struct {
+	*ExtendedResponse
+	genPassword Password
+}
+
+// NewPasswordModifyResponse creates a new password modify response.  The
+// response defaults to ResultUnwillingToPerform.
+// Supported options: WithResponseCode, WithDiagnosticMessage, WithMatchedDN
+func (r *Request) NewPasswordModifyResponse(opt ...Option) *PasswordModifyResponse {
+	const op = "gldap.NewPasswordModifyResponse" // nolint:unused
+	opts := getResponseOpts(opt...)
+	if opts.withResponseCode == nil {
+		opts.withResponseCode = intPtr(ResultUnwillingToPerform)
+	}
+	return &PasswordModifyResponse{
+		ExtendedResponse: &ExtendedResponse{
+			baseResponse: &baseResponse{
+				messageID:   r.message.GetID(),
+				code:        int16(*opts.withResponseCode),
+				diagMessage: opts.withDiagnosticMessage,
+				matchedDN:   opts.withMatchedDN,
+			},
+		},
+	}
+}
+
+// SetGeneratedPassword sets the password generated by the server, which is
+// returned to the client when the request didn't include a new password.
+func (r *PasswordModifyResponse) SetGeneratedPassword(p Password) {
+	r.genPassword = p
+}
+
+// packet encodes the response.  The responseName is omitted and the optional
+// responseValue is defined as:
+//
+//	PasswdModifyResponseValue ::= SEQUENCE {
+//		genPasswd       [0]     OCTET STRING OPTIONAL }
+//
+// see: https://datatracker.ietf.org/doc/html/rfc3062#section-2
+func (r *PasswordModifyResponse) packet() *packet {
+	if r.genPassword != "" {
+		value := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "PasswdModifyResponseValue")
+		value.AppendChild(ber.NewString(ber.ClassContext, ber.TypePrimitive, 0, string(r.genPassword), "genPasswd"))
+		r.SetResponseValue(value.Bytes())
+	}
+	return r.ExtendedResponse.packet()
+}
@@ -0,0 +1,234 @@
+// Copyright (c) Jim Lambert
+// SPDX-License-Identifier: MIT
+
+package gldap
+
+import (
+	"testing"
+
+	ber "github.com/go-asn1-ber/asn1-ber"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodePasswordModifyRequestValue(t *testing.T) {
+	t.Parallel()
+	encode := func(children ...*ber.Packet) []byte {
+		seq := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "PasswdModifyRequestValue")
+		for _, c := range children {
+			seq.AppendChild(c)
+		}
+		return seq.Bytes()
+	}
+	tests := []struct {
+		name            string
+		value           []byte
+		want            *PasswordModifyRequestValue
+		wantErr         bool
+		wantErrIs       error
+		wantErrContains string
+	}{
+		{
+			name: "missing-value",
+			want: &PasswordModifyRequestValue{},
+		},
+		{
+			name:  "empty-sequence",
+			value: encode(),
+			want:  &PasswordModifyRequestValue{},
+		},
+		{
+			name: "all-fields",
+			value: encode(
+				ber.NewString(ber.ClassContext, ber.TypePrimitive, 0, "uid=alice,ou=people,dc=example,dc=org", "userIdentity"),
+				ber.NewString(ber.ClassContext, ber.TypePrimitive, 1, "old-password", "oldPasswd"),
+				ber.NewString(ber.ClassContext, ber.TypePrimitive, 2, "new-password", "newPasswd"),
+			),
+			want: &PasswordModifyRequestValue{
+				UserIdentity: "uid=alice,ou=people,dc=example,dc=org",
+				OldPassword:  "old-password",
+				NewPassword:  "new-password",
+			},
+		},
+		{
+			name: "only-old-password",
+			value: encode(
+				ber.NewString(ber.ClassContext, ber.TypePrimitive, 1, "old-password", "oldPasswd"),
+			),
+			want: &PasswordModifyRequestValue{
+				OldPassword: "old-password",
+			},
+		},
+		{
+			name:            "not-ber",
+			value:           []byte{0x30},
+			wantErr:         true,
+			wantErrIs:       ErrInvalidParameter,
+			wantErrContains: "unable to decode request value",
+		},
+		{
+			name:            "not-a-sequence",
+			value:           ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "value", "value").Bytes(),
+			wantErr:         true,
+			wantErrIs:       ErrInvalidParameter,
+			wantErrContains: "invalid request value sequence",
+		},
+		{
+			name: "invalid-child-class",
+			value: encode(
+				ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "uid=alice", "userIdentity"),
+			),
+			wantErr:         true,
+			wantErrIs:       ErrInvalidParameter,
+			wantErrContains: "invalid request value child 0",
+		},
+		{
+			name: "unknown-tag",
+			value: encode(
+				ber.NewString(ber.ClassContext, ber.TypePrimitive, 3, "unknown", "unknown"),
+			),
+			wantErr:         true,
+			wantErrIs:       ErrInvalidParameter,
+			wantErrContains: "unknown request value tag 3",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert, require := assert.New(t), require.New(t)
+			got, err := DecodePasswordModifyRequestValue(tc.value)
+			if tc.wantErr {
+				require.Error(err)
+				assert.Nil(got)
+				if tc.wantErrIs != nil {
+					assert.ErrorIs(err, tc.wantErrIs)
+				}
+				if tc.wantErrContains != "" {
+					assert.Contains(err.Error(), tc.wantErrContains)
+				}
+				return
+			}
+			require.NoError(err)
+			assert.Equal(tc.want, got)
+		})
+	}
+}
+
+func TestRequest_GetPasswordModifyMessage(t *testing.T) {
+	t.Parallel()
+	value := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "PasswdModifyRequestValue")
+	value.AppendChild(ber.NewString(ber.ClassContext, ber.TypePrimitive, 0, "uid=alice,ou=people,dc=example,dc=org", "userIdentity"))
+	value.AppendChild(ber.NewString(ber.ClassContext, ber.TypePrimitive, 2, "new-password", "newPasswd"))
+
+	tests := []struct {
+		name            string
+		r               *Request
+		want            *PasswordModifyMessage
+		wantErr         bool
+		wantErrIs       error
+		wantErrContains string
+	}{
+		{
+			name:            "not-extended-operation",
+			r:               &Request{message: &SearchMessage{}},
+			wantErr:         true,
+			wantErrIs:       ErrInvalidParameter,
+			wantErrContains: "not a password modify request",
+		},
+		{
+			name:            "wrong-extended-operation",
+			r:               &Request{message: &ExtendedOperationMessage{Name: ExtendedOperationWhoAmI}},
+			wantErr:         true,
+			wantErrIs:       ErrInvalidParameter,
+			wantErrContains: "not a password modify request",
+		},
+		{
+			name: "invalid-value",
+			r: &Request{message: &ExtendedOperationMessage{
+				Name:      ExtendedOperationPasswordModify,
+				ByteValue: []byte{0x30},
+			}},
+			wantErr:         true,
+			wantErrIs:       ErrInvalidParameter,
+			wantErrContains: "unable to decode request value",
+		},
+		{
+			name: "missing-value",
+			r: &Request{message: &ExtendedOperationMessage{
+				baseMessage: baseMessage{id: 1},
+				Name:        ExtendedOperationPasswordModify,
+			}},
+			want: &PasswordModifyMessage{baseMessage: baseMessage{id: 1}},
+		},
+		{
+			name: "valid",
+			r: &Request{message: &ExtendedOperationMessage{
+				baseMessage: baseMessage{id: 1},
+				Name:        ExtendedOperationPasswordModify,
+				ByteValue:   value.Bytes(),
+			}},
+			want: &PasswordModifyMessage{
+				baseMessage: baseMessage{id: 1},
+				PasswordModifyRequestValue: PasswordModifyRequestValue{
+					UserIdentity: "uid=alice,ou=people,dc=example,dc=org",
+					NewPassword:  "new-password",
+				},
+			},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert, require := assert.New(t), require.New(t)
+			m, err := tc.r.GetPasswordModifyMessage()
+			if tc.wantErr {
+				require.Error(err)
+				assert.Nil(m)
+				if tc.wantErrIs != nil {
+					assert.ErrorIs(err, tc.wantErrIs)
+				}
+				if tc.wantErrContains != "" {
+					assert.Contains(err.Error(), tc.wantErrContains)
+				}
+				return
+			}
+			require.NoError(err)
+			assert.Equal(tc.want, m)
+		})
+	}
+}
+
+func TestPasswordModifyResponse_packet(t *testing.T) {
+	t.Parallel()
+	r := &Request{message: &ExtendedOperationMessage{baseMessage: baseMessage{id: 1}}}
+
+	t.Run("defaults", func(t *testing.T) {
+		assert := assert.New(t)
+		p := r.NewPasswordModifyResponse().packet()
+		resp := p.Children[1]
+		assert.Equal(ber.Tag(ApplicationExtendedResponse), resp.Tag)
+		assert.Equal(int16(ResultUnwillingToPerform), resp.Children[0].Value)
+		// result code, matched dn and diagnostic message without a response
+		// value
+		assert.Len(resp.Children, 3)
+	})
+	t.Run("generated-password", func(t *testing.T) {
+		assert, require := assert.New(t), require.New(t)
+		resp := r.NewPasswordModifyResponse(WithResponseCode(ResultSuccess))
+		resp.SetGeneratedPassword("generated-password")
+		p := resp.packet()
+		extResp := p.Children[1]
+		assert.Equal(int16(ResultSuccess), extResp.Children[0].Value)
+		require.Len(extResp.Children, 4)
+
+		value := extResp.Children[3]
+		assert.Equal(ber.ClassContext, value.ClassType)
+		assert.Equal(ber.Tag(11), value.Tag)
+
+		decoded, err := ber.DecodePacketErr(value.Data.Bytes())
+		require.NoError(err)
+		assert.Equal(ber.Tag(ber.TagSequence), decoded.Tag)
+		require.Len(decoded.Children, 1)
+		assert.Equal(ber.ClassContext, decoded.Children[0].ClassType)
+		assert.Equal(ber.Tag(0), decoded.Children[0].Tag)
+		assert.Equal("generated-password", decoded.Children[0].Data.String())
+	})
+}
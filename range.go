@@ -0,0 +1,192 @@
+// Copyright (c) Jim Lambert
+// SPDX-License-Identifier: MIT
+
+package gldap
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	// AttributeRangeEnd is the High of an AttributeRange which ends with the
+	// attribute's last value (for example: "member;range=1500-*")
+	AttributeRangeEnd = -1
+
+	// rangeOptionPrefix is the prefix of an attribute description's range
+	// option (for example: "range=0-1499")
+	rangeOptionPrefix = "range="
+)
+
+// AttributeRange is the range of an attribute's values requested by a client
+// using Active Directory's ranged retrieval (for example:
+// "member;range=0-1499").  Low and High are zero-based and inclusive, and a
+// High of AttributeRangeEnd is the attribute's last value.
+type AttributeRange struct {
+	// Low is the index of the range's first value
+	Low int
+	// High is the index of the range's last value, or AttributeRangeEnd
+	High int
+}
+
+// String returns the range's option value (for example: "0-1499" or
+// "1500-*")
+func (r AttributeRange) String() string {
+	if r.High == AttributeRangeEnd {
+		return fmt.Sprintf("%d-*", r.Low)
+	}
+	return fmt.Sprintf("%d-%d", r.Low, r.High)
+}
+
+// parseAttributeRange parses a range option's value (for example: "0-1499")
+func parseAttributeRange(s string) (*AttributeRange, error) {
+	const op = "gldap.parseAttributeRange"
+	low, high, ok := strings.Cut(s, "-")
+	if !ok {
+		return nil, fmt.Errorf("%s: invalid range %q: %w", op, s, ErrInvalidParameter)
+	}
+	r := &AttributeRange{High: AttributeRangeEnd}
+	var err error
+	if r.Low, err = strconv.Atoi(low); err != nil || r.Low < 0 {
+		return nil, fmt.Errorf("%s: invalid range %q low value: %w", op, s, ErrInvalidParameter)
+	}
+	if high != "*" {
+		if r.High, err = strconv.Atoi(high); err != nil || r.High < r.Low {
+			return nil, fmt.Errorf("%s: invalid range %q high value: %w", op, s, ErrInvalidParameter)
+		}
+	}
+	return r, nil
+}
+
+// AttributeDescription is an attribute type with its options (for example:
+// "member;range=0-1499" or "userCertificate;binary"), which is how clients
+// request attributes in a search request.
+// see: https://www.rfc-editor.org/rfc/rfc4512#section-2.5
+type AttributeDescription struct {
+	// Type of the attribute (for example: "member")
+	Type string
+	// Options of the attribute, other than its range (for example: "binary")
+	Options []string
+	// Range of the attribute's values, which is nil when a range wasn't
+	// requested
+	Range *AttributeRange
+}
+
+// ParseAttributeDescription parses an attribute description (for example:
+// "member;range=0-1499").  Options are not case sensitive.
+func ParseAttributeDescription(s string) (*AttributeDescription, error) {
+	const op = "gldap.ParseAttributeDescription"
+	parts := strings.Split(s, ";")
+	if parts[0] == "" {
+		return nil, fmt.Errorf("%s: missing attribute type in %q: %w", op, s, ErrInvalidParameter)
+	}
+	d := &AttributeDescription{
+		Type: parts[0],
+	}
+	for _, o := range parts[1:] {
+		switch {
+		case o == "":
+			return nil, fmt.Errorf("%s: empty option in %q: %w", op, s, ErrInvalidParameter)
+		case len(o) > len(rangeOptionPrefix) && strings.EqualFold(o[:len(rangeOptionPrefix)], rangeOptionPrefix):
+			if d.Range != nil {
+				return nil, fmt.Errorf("%s: more than one range in %q: %w", op, s, ErrInvalidParameter)
+			}
+			r, err := parseAttributeRange(o[len(rangeOptionPrefix):])
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", op, err)
+			}
+			d.Range = r
+		default:
+			d.Options = append(d.Options, o)
+		}
+	}
+	return d, nil
+}
+
+// String returns the attribute description (for example:
+// "member;range=0-1499")
+func (d *AttributeDescription) String() string {
+	var b strings.Builder
+	b.WriteString(d.Type)
+	for _, o := range d.Options {
+		b.WriteString(";")
+		b.WriteString(o)
+	}
+	if d.Range != nil {
+		b.WriteString(";" + rangeOptionPrefix)
+		b.WriteString(d.Range.String())
+	}
+	return b.String()
+}
+
+// AttributeDescriptions returns the parsed descriptions of the attributes
+// requested.  Attributes which can't be parsed are skipped.
+func (m *SearchMessage) AttributeDescriptions() []*AttributeDescription {
+	descriptions := make([]*AttributeDescription, 0, len(m.Attributes))
+	for _, a := range m.Attributes {
+		d, err := ParseAttributeDescription(a)
+		if err != nil {
+			continue
+		}
+		descriptions = append(descriptions, d)
+	}
+	return descriptions
+}
+
+// AttributeRange returns the range requested for the attribute type (for
+// example: 1500-* for "member;range=1500-*").  It returns false when a range
+// wasn't requested for the attribute type.
+func (m *SearchMessage) AttributeRange(attributeType string) (*AttributeRange, bool) {
+	for _, d := range m.AttributeDescriptions() {
+		if d.Range != nil && strings.EqualFold(d.Type, attributeType) {
+			return d.Range, true
+		}
+	}
+	return nil, false
+}
+
+// AddRangedAttribute will add the values within the range to the response
+// entry, using an attribute named with the range of values returned (for
+// example: "member;range=0-1499" or "member;range=1500-*").  The range is
+// typically from SearchMessage.AttributeRange and a nil range is all the
+// values.  When a nil range includes all the values, the attribute is added
+// without a range.  An invalid range (a negative Low or a High before its Low)
+// returns an error and no attribute is added.
+//
+// Options supported: WithMaxValueRange
+func (r *SearchResponseEntry) AddRangedAttribute(name string, values []string, valueRange *AttributeRange, opt ...Option) error {
+	const op = "gldap.(SearchResponseEntry).AddRangedAttribute"
+	opts := getResponseOpts(opt...)
+	low, high := 0, AttributeRangeEnd
+	if valueRange != nil {
+		low, high = valueRange.Low, valueRange.High
+	}
+	switch {
+	case low < 0:
+		return fmt.Errorf("%s: invalid range %q low value: %w", op, valueRange, ErrInvalidParameter)
+	case high != AttributeRangeEnd && high < low:
+		return fmt.Errorf("%s: invalid range %q high value: %w", op, valueRange, ErrInvalidParameter)
+	}
+	if low > len(values) {
+		low = len(values)
+	}
+	end := len(values)
+	if high != AttributeRangeEnd && high+1 < end {
+		end = high + 1
+	}
+	if opts.withMaxValueRange > 0 && end-low > opts.withMaxValueRange {
+		end = low + opts.withMaxValueRange
+	}
+	returned := AttributeRange{Low: low, High: end - 1}
+	if end == len(values) {
+		if valueRange == nil {
+			r.AddAttribute(name, values)
+			return nil
+		}
+		returned.High = AttributeRangeEnd
+	}
+	d := &AttributeDescription{Type: name, Range: &returned}
+	r.AddAttribute(d.String(), values[low:end])
+	return nil
+}
@@ -0,0 +1,234 @@
+// Copyright (c) Jim Lambert
+// SPDX-License-Identifier: MIT
+
+package gldap
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseAttributeDescription(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name            string
+		description     string
+		want            *AttributeDescription
+		wantString      string
+		wantErrContains string
+	}{
+		{
+			name:        "type-only",
+			description: "member",
+			want:        &AttributeDescription{Type: "member"},
+		},
+		{
+			name:        "range",
+			description: "member;range=0-1499",
+			want:        &AttributeDescription{Type: "member", Range: &AttributeRange{Low: 0, High: 1499}},
+		},
+		{
+			name:        "range-to-end",
+			description: "member;range=1500-*",
+			want:        &AttributeDescription{Type: "member", Range: &AttributeRange{Low: 1500, High: AttributeRangeEnd}},
+		},
+		{
+			name:        "options-and-range",
+			description: "member;lang-en;Range=10-20",
+			want:        &AttributeDescription{Type: "member", Options: []string{"lang-en"}, Range: &AttributeRange{Low: 10, High: 20}},
+			wantString:  "member;lang-en;range=10-20",
+		},
+		{
+			name:        "binary",
+			description: "userCertificate;binary",
+			want:        &AttributeDescription{Type: "userCertificate", Options: []string{"binary"}},
+		},
+		{
+			name:            "missing-type",
+			description:     ";range=0-1",
+			wantErrContains: "missing attribute type",
+		},
+		{
+			name:            "empty-option",
+			description:     "member;",
+			wantErrContains: "empty option",
+		},
+		{
+			name:            "two-ranges",
+			description:     "member;range=0-1;range=2-3",
+			wantErrContains: "more than one range",
+		},
+		{
+			name:            "missing-high",
+			description:     "member;range=0",
+			wantErrContains: "invalid range",
+		},
+		{
+			name:            "negative-low",
+			description:     "member;range=-1-10",
+			wantErrContains: "low value",
+		},
+		{
+			name:            "high-before-low",
+			description:     "member;range=10-9",
+			wantErrContains: "high value",
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			assert, require := assert.New(t), require.New(t)
+			got, err := ParseAttributeDescription(tc.description)
+			if tc.wantErrContains != "" {
+				require.Error(err)
+				assert.ErrorIs(err, ErrInvalidParameter)
+				assert.Contains(err.Error(), tc.wantErrContains)
+				return
+			}
+			require.NoError(err)
+			assert.Equal(tc.want, got)
+			wantString := tc.description
+			if tc.wantString != "" {
+				wantString = tc.wantString
+			}
+			assert.Equal(wantString, got.String())
+		})
+	}
+}
+
+func TestSearchMessage_AttributeRange(t *testing.T) {
+	t.Parallel()
+	assert := assert.New(t)
+	m := &SearchMessage{
+		Attributes: []string{"cn", "member;range=1500-*", ";invalid"},
+	}
+	assert.Equal([]*AttributeDescription{
+		{Type: "cn"},
+		{Type: "member", Range: &AttributeRange{Low: 1500, High: AttributeRangeEnd}},
+	}, m.AttributeDescriptions())
+
+	got, ok := m.AttributeRange("Member")
+	assert.True(ok)
+	assert.Equal(&AttributeRange{Low: 1500, High: AttributeRangeEnd}, got)
+
+	got, ok = m.AttributeRange("cn")
+	assert.False(ok)
+	assert.Nil(got)
+}
+
+func TestSearchResponseEntry_AddRangedAttribute(t *testing.T) {
+	t.Parallel()
+	values := make([]string, 0, 10)
+	for i := 0; i < 10; i++ {
+		values = append(values, fmt.Sprintf("cn=user%d,dc=example,dc=org", i))
+	}
+	tests := []struct {
+		name            string
+		values          []string
+		valueRange      *AttributeRange
+		opt             []Option
+		wantName        string
+		wantValues      []string
+		wantErr         bool
+		wantErrIs       error
+		wantErrContains string
+	}{
+		{
+			name:       "no-range",
+			values:     values,
+			wantName:   "member",
+			wantValues: values,
+		},
+		{
+			name:       "no-range-max-values",
+			values:     values,
+			opt:        []Option{WithMaxValueRange(4)},
+			wantName:   "member;range=0-3",
+			wantValues: values[0:4],
+		},
+		{
+			name:       "first-page",
+			values:     values,
+			valueRange: &AttributeRange{Low: 0, High: 3},
+			wantName:   "member;range=0-3",
+			wantValues: values[0:4],
+		},
+		{
+			name:       "middle-page-max-values",
+			values:     values,
+			valueRange: &AttributeRange{Low: 4, High: AttributeRangeEnd},
+			opt:        []Option{WithMaxValueRange(4)},
+			wantName:   "member;range=4-7",
+			wantValues: values[4:8],
+		},
+		{
+			name:       "last-page",
+			values:     values,
+			valueRange: &AttributeRange{Low: 8, High: AttributeRangeEnd},
+			opt:        []Option{WithMaxValueRange(4)},
+			wantName:   "member;range=8-*",
+			wantValues: values[8:],
+		},
+		{
+			name:       "high-past-end",
+			values:     values,
+			valueRange: &AttributeRange{Low: 6, High: 100},
+			wantName:   "member;range=6-*",
+			wantValues: values[6:],
+		},
+		{
+			name:       "high-is-last",
+			values:     values,
+			valueRange: &AttributeRange{Low: 6, High: 9},
+			wantName:   "member;range=6-*",
+			wantValues: values[6:],
+		},
+		{
+			name:       "low-past-end",
+			values:     values,
+			valueRange: &AttributeRange{Low: 20, High: AttributeRangeEnd},
+			wantName:   "member;range=10-*",
+			wantValues: []string{},
+		},
+		{
+			name:            "high-before-low",
+			values:          values,
+			valueRange:      &AttributeRange{Low: 8, High: 5},
+			wantErr:         true,
+			wantErrIs:       ErrInvalidParameter,
+			wantErrContains: `invalid range "8-5" high value`,
+		},
+		{
+			name:            "negative-low",
+			values:          values,
+			valueRange:      &AttributeRange{Low: -1, High: 5},
+			wantErr:         true,
+			wantErrIs:       ErrInvalidParameter,
+			wantErrContains: `invalid range "-1-5" low value`,
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			assert, require := assert.New(t), require.New(t)
+			r := &SearchResponseEntry{}
+			err := r.AddRangedAttribute("member", tc.values, tc.valueRange, tc.opt...)
+			if tc.wantErr {
+				require.Error(err)
+				assert.ErrorIs(err, tc.wantErrIs)
+				assert.Contains(err.Error(), tc.wantErrContains)
+				assert.Empty(r.entry.Attributes)
+				return
+			}
+			require.NoError(err)
+			require.Len(r.entry.Attributes, 1)
+			assert.Equal(tc.wantName, r.entry.Attributes[0].Name)
+			assert.Equal(tc.wantValues, r.entry.Attributes[0].Values)
+		})
+	}
+}
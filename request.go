@@ -4,9 +4,13 @@
 package gldap
 
 import (
+	"context"
 	"crypto/tls"
+	"crypto/x509"
 	"errors"
 	"fmt"
+	"strings"
+	"time"
 
 	ber "github.com/go-asn1-ber/asn1-ber"
 )
@@ -36,6 +40,19 @@ type Request struct {
 	message      Message
 	routeOp      routeOperation
 	extendedName ExtendedOperationName
+	peerCerts    []*x509.Certificate
+
+	// criticalControls are the control types of the request's critical
+	// controls
+	criticalControls []string
+
+	// routeLabel is the label of the route serving the request
+	routeLabel string
+
+	// ctx is cancelled when the request is abandoned, cancelled or completed,
+	// the client disconnects or the server is stopped
+	ctx    context.Context
+	cancel context.CancelCauseFunc
 }
 
 func newRequest(id int, c *conn, p *packet) (*Request, error) {
@@ -56,11 +73,16 @@ func newRequest(id int, c *conn, p *packet) (*Request, error) {
 	switch v := m.(type) {
 	case *SimpleBindMessage:
 		routeOp = bindRouteOperation
+	case *SASLBindMessage:
+		routeOp = bindRouteOperation
 	case *SearchMessage:
 		routeOp = searchRouteOperation
 	case *ExtendedOperationMessage:
 		routeOp = extendedRouteOperation
 		extendedName = v.Name
+	case *CancelMessage:
+		routeOp = extendedRouteOperation
+		extendedName = ExtendedOperationCancel
 	case *ModifyMessage:
 		routeOp = modifyRouteOperation
 	case *AddMessage:
@@ -69,22 +91,73 @@ func newRequest(id int, c *conn, p *packet) (*Request, error) {
 		routeOp = deleteRouteOperation
 	case *UnbindMessage:
 		routeOp = unbindRouteOperation
+	case *CompareMessage:
+		routeOp = compareRouteOperation
+	case *ModifyDNMessage:
+		routeOp = modifyDNRouteOperation
+	case *AbandonMessage:
+		routeOp = abandonRouteOperation
 	default:
 		// this should be unreachable, since newMessage defaults to returning an
 		// *ExtendedOperationMessage
 		return nil, fmt.Errorf("%s: %v is an unsupported route operation: %w", op, v, ErrInternal)
 	}
 
+	criticalControls, err := p.criticalControlTypes()
+	if err != nil {
+		return nil, fmt.Errorf("%s: unable to get critical controls for request %d: %w", op, id, err)
+	}
+
 	r := &Request{
-		ID:           id,
-		conn:         c,
-		message:      m,
-		routeOp:      routeOp,
-		extendedName: extendedName,
+		ID:               id,
+		conn:             c,
+		message:          m,
+		routeOp:          routeOp,
+		extendedName:     extendedName,
+		peerCerts:        c.peerCertificates(),
+		criticalControls: criticalControls,
 	}
 	return r, nil
 }
 
+// Context returns the request's context.  The context is cancelled when the
+// client disconnects, the request is abandoned or cancelled, the server is
+// stopped or the request's handler returns.  Search requests with a TimeLimit
+// will have a context deadline based on the TimeLimit.  Handlers performing
+// long running operations (database queries, HTTP requests, etc) should use it
+// to stop working on requests that are no longer needed.  context.Cause(...)
+// will return ErrAbandoned or ErrCanceled when the request was abandoned or
+// cancelled by the client.
+func (r *Request) Context() context.Context {
+	if r.ctx == nil {
+		return context.Background()
+	}
+	return r.ctx
+}
+
+// initContext initializes the request's context as a child of the parent
+// context.
+func (r *Request) initContext(parent context.Context) {
+	ctx, cancel := context.WithCancelCause(parent)
+	r.ctx, r.cancel = ctx, cancel
+	if m, ok := r.message.(*SearchMessage); ok && m.TimeLimit > 0 {
+		deadlineCtx, deadlineCancel := context.WithTimeout(ctx, time.Duration(m.TimeLimit)*time.Second)
+		r.ctx = deadlineCtx
+		r.cancel = func(cause error) {
+			cancel(cause)
+			deadlineCancel()
+		}
+	}
+}
+
+// PeerCertificates returns the verified client certificate chain (leaf first)
+// of the request's connection.  It returns nil when the connection isn't using
+// TLS (either via the WithTLSConfig listener or StartTLS) or the client didn't
+// present a certificate which was verified by the server's tls.Config.
+func (r *Request) PeerCertificates() []*x509.Certificate {
+	return r.peerCerts
+}
+
 // ConnectionID returns the request's connection ID which enables you to know
 // "who" (i.e. which connection) made a request. Using the connection ID you
 // can do things like ensure a connection performing a search operation has
@@ -93,6 +166,111 @@ func (r *Request) ConnectionID() int {
 	return r.conn.connID
 }
 
+// AuthzID returns the authorization identity (for example: "dn:<dn>" or
+// "u:<userid>") of the request's connection, which is set by the connection's
+// last successful bind (see: BindResponse.SetAuthzID).  It's empty when the
+// connection is anonymous.
+func (r *Request) AuthzID() string {
+	if r.conn == nil {
+		return ""
+	}
+	r.conn.stateMu.Lock()
+	defer r.conn.stateMu.Unlock()
+	return r.conn.authzID
+}
+
+// RouteLabel returns the label of the route which matched the request (see:
+// WithLabel).  It's empty until the request is matched to a route or when the
+// route doesn't have a label.
+func (r *Request) RouteLabel() string {
+	return r.routeLabel
+}
+
+// BoundDN returns the DN of the request's connection authorization identity
+// (see: AuthzID).  It's empty when the connection is anonymous or its
+// authorization identity isn't a "dn:" identity.
+func (r *Request) BoundDN() string {
+	id := r.AuthzID()
+	if !strings.HasPrefix(id, "dn:") {
+		return ""
+	}
+	return strings.TrimPrefix(id, "dn:")
+}
+
+// targetDN returns the DN targeted by the request (the bind DN of a simple
+// bind, the base DN of a search or the entry's DN for add, modify, delete,
+// compare and modify DN requests).  It returns false when the request doesn't
+// target a DN.
+func (r *Request) targetDN() (string, bool) {
+	switch m := r.message.(type) {
+	case *SimpleBindMessage:
+		return m.UserName, true
+	case *SearchMessage:
+		return m.BaseDN, true
+	case *AddMessage:
+		return m.DN, true
+	case *ModifyMessage:
+		return m.DN, true
+	case *DeleteMessage:
+		return m.DN, true
+	case *CompareMessage:
+		return m.DN, true
+	case *ModifyDNMessage:
+		return m.DN, true
+	default:
+		return "", false
+	}
+}
+
+// controls returns the controls of the request's message
+func (r *Request) controls() []Control {
+	switch m := r.message.(type) {
+	case *SimpleBindMessage:
+		return m.Controls
+	case *SASLBindMessage:
+		return m.Controls
+	case *SearchMessage:
+		return m.Controls
+	case *AddMessage:
+		return m.Controls
+	case *ModifyMessage:
+		return m.Controls
+	case *DeleteMessage:
+		return m.Controls
+	case *CompareMessage:
+		return m.Controls
+	case *ModifyDNMessage:
+		return m.Controls
+	case *ExtendedOperationMessage:
+		return m.Controls
+	default:
+		return nil
+	}
+}
+
+// responseApplicationCode returns the application code of the response to the
+// request's operation
+func (r *Request) responseApplicationCode() int {
+	switch r.message.(type) {
+	case *SimpleBindMessage, *SASLBindMessage:
+		return ApplicationBindResponse
+	case *SearchMessage:
+		return ApplicationSearchResultDone
+	case *AddMessage:
+		return ApplicationAddResponse
+	case *ModifyMessage:
+		return ApplicationModifyResponse
+	case *DeleteMessage:
+		return ApplicationDelResponse
+	case *CompareMessage:
+		return ApplicationCompareResponse
+	case *ModifyDNMessage:
+		return ApplicationModifyDNResponse
+	default:
+		return ApplicationExtendedResponse
+	}
+}
+
 // NewModifyResponse creates a modify response
 // Supported options: WithResponseCode, WithDiagnosticMessage, WithMatchedDN
 func (r *Request) NewModifyResponse(opt ...Option) *ModifyResponse {
@@ -172,10 +350,14 @@ func (r *Request) NewBindResponse(opt ...Option) *BindResponse {
 		baseResponse: &baseResponse{
 			messageID: r.message.GetID(),
 		},
+		conn: r.conn,
 	}
 	if opts.withResponseCode != nil {
 		resp.code = int16(*opts.withResponseCode)
 	}
+	if m, ok := r.message.(*SimpleBindMessage); ok && m.UserName != "" && m.Password != "" {
+		resp.authzID = "dn:" + m.UserName
+	}
 	return resp
 }
 
@@ -190,6 +372,29 @@ func (r *Request) GetSimpleBindMessage() (*SimpleBindMessage, error) {
 	return s, nil
 }
 
+// GetExtendedOperationMessage retrieves the ExtendedOperationMessage from the
+// request, which allows you handle the request based on the message attributes
+// (see the Decode*RequestValue(...) funcs for decoding the message's Value).
+func (r *Request) GetExtendedOperationMessage() (*ExtendedOperationMessage, error) {
+	const op = "gldap.(Request).GetExtendedOperationMessage"
+	m, ok := r.message.(*ExtendedOperationMessage)
+	if !ok {
+		return nil, fmt.Errorf("%s: %T not an extended operation request: %w", op, r.message, ErrInvalidParameter)
+	}
+	return m, nil
+}
+
+// GetSASLBindMessage retrieves the SASLBindMessage from the request, which
+// allows you handle the request based on the message attributes.
+func (r *Request) GetSASLBindMessage() (*SASLBindMessage, error) {
+	const op = "gldap.(Request).GetSASLBindMessage"
+	s, ok := r.message.(*SASLBindMessage)
+	if !ok {
+		return nil, fmt.Errorf("%s: %T not a sasl bind request: %w", op, r.message, ErrInvalidParameter)
+	}
+	return s, nil
+}
+
 // NewSearchDoneResponse creates a new search done response.  If there are no
 // results found, then set the response code by adding the option
 // WithResponseCode(ResultNoSuchObject)
@@ -283,6 +488,64 @@ func (r *Request) GetUnbindMessage() (*UnbindMessage, error) {
 	return m, nil
 }
 
+// GetCompareMessage retrieves the CompareMessage from the request, which
+// allows you handle the request based on the message attributes.
+func (r *Request) GetCompareMessage() (*CompareMessage, error) {
+	const op = "gldap.(Request).GetCompareMessage"
+	m, ok := r.message.(*CompareMessage)
+	if !ok {
+		return nil, fmt.Errorf("%s: %T not a compare request: %w", op, r.message, ErrInvalidParameter)
+	}
+	return m, nil
+}
+
+// NewCompareResponse creates a compare response.  If no response code is
+// specified, the response code defaults to ResultCompareFalse.
+// Supported options: WithResponseCode, WithDiagnosticMessage, WithMatchedDN
+func (r *Request) NewCompareResponse(opt ...Option) *CompareResponse {
+	opts := getResponseOpts(opt...)
+	if opts.withResponseCode == nil {
+		opts.withResponseCode = intPtr(ResultCompareFalse)
+	}
+	return &CompareResponse{
+		GeneralResponse: r.NewResponse(
+			WithApplicationCode(ApplicationCompareResponse),
+			WithResponseCode(*opts.withResponseCode),
+			WithDiagnosticMessage(opts.withDiagnosticMessage),
+			WithMatchedDN(opts.withMatchedDN),
+		),
+	}
+}
+
+// GetModifyDNMessage retrieves the ModifyDNMessage from the request, which
+// allows you handle the request based on the message attributes.
+func (r *Request) GetModifyDNMessage() (*ModifyDNMessage, error) {
+	const op = "gldap.(Request).GetModifyDNMessage"
+	m, ok := r.message.(*ModifyDNMessage)
+	if !ok {
+		return nil, fmt.Errorf("%s: %T not a modify DN request: %w", op, r.message, ErrInvalidParameter)
+	}
+	return m, nil
+}
+
+// NewModifyDNResponse creates a modify DN response.  If no response code is
+// specified, the response code defaults to ResultUnwillingToPerform.
+// Supported options: WithResponseCode, WithDiagnosticMessage, WithMatchedDN
+func (r *Request) NewModifyDNResponse(opt ...Option) *ModifyDNResponse {
+	opts := getResponseOpts(opt...)
+	if opts.withResponseCode == nil {
+		opts.withResponseCode = intPtr(ResultUnwillingToPerform)
+	}
+	return &ModifyDNResponse{
+		GeneralResponse: r.NewResponse(
+			WithApplicationCode(ApplicationModifyDNResponse),
+			WithResponseCode(*opts.withResponseCode),
+			WithDiagnosticMessage(opts.withDiagnosticMessage),
+			WithMatchedDN(opts.withMatchedDN),
+		),
+	}
+}
+
 // ConvertString will convert an ASN1 BER Octet string into a "native" go
 // string.  Support ber string encoding types: OctetString, GeneralString and
 // all other types will return an error.
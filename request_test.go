@@ -4,7 +4,9 @@
 package gldap
 
 import (
+	"context"
 	"testing"
+	"time"
 
 	ber "github.com/go-asn1-ber/asn1-ber"
 	"github.com/stretchr/testify/assert"
@@ -76,6 +78,174 @@ func Test_newRequest(t *testing.T) {
 				},
 			},
 		},
+		{
+			name:      "valid-sasl-bind",
+			requestID: 1,
+			conn:      &conn{},
+			packet: testSASLBindRequestPacket(t,
+				SASLBindMessage{
+					baseMessage: baseMessage{id: 1},
+					Mechanism:   SASLMechanismPlain,
+					Credentials: []byte("\x00alice\x00fido"),
+					Controls: []Control{
+						testControlString(t, "generic-control", WithControlValue("generic-value")),
+					},
+				},
+			),
+			wantMsg: &SASLBindMessage{
+				baseMessage: baseMessage{id: 1},
+				AuthChoice:  "sasl",
+				Mechanism:   SASLMechanismPlain,
+				Credentials: []byte("\x00alice\x00fido"),
+				Controls: []Control{
+					testControlString(t, "generic-control", WithControlValue("generic-value")),
+				},
+			},
+		},
+		{
+			name:      "valid-sasl-bind-no-credentials",
+			requestID: 1,
+			conn:      &conn{},
+			packet: testSASLBindRequestPacket(t,
+				SASLBindMessage{
+					baseMessage: baseMessage{id: 1},
+					Mechanism:   SASLMechanismExternal,
+				},
+			),
+			wantMsg: &SASLBindMessage{
+				baseMessage: baseMessage{id: 1},
+				AuthChoice:  "sasl",
+				Mechanism:   SASLMechanismExternal,
+			},
+		},
+		{
+			name:      "invalid-bind-auth-choice",
+			requestID: 1,
+			conn:      &conn{},
+			packet: func() *packet {
+				p := testSimpleBindRequestPacket(t, SimpleBindMessage{baseMessage: baseMessage{id: 1}, UserName: "alice"})
+				p.Children[1].Children[2].Tag = 2
+				return p
+			}(),
+			wantErr:         true,
+			wantErrIs:       ErrInvalidParameter,
+			wantErrContains: "unsupported authentication choice",
+		},
+		{
+			name:      "valid-abandon",
+			requestID: 1,
+			conn:      &conn{},
+			packet: testAbandonRequestPacket(t,
+				AbandonMessage{
+					baseMessage: baseMessage{id: 2},
+					MessageID:   1,
+					Controls: []Control{
+						testControlString(t, "generic-control", WithControlValue("generic-value")),
+					},
+				},
+			),
+			wantMsg: &AbandonMessage{
+				baseMessage: baseMessage{id: 2},
+				MessageID:   1,
+				Controls: []Control{
+					testControlString(t, "generic-control", WithControlValue("generic-value")),
+				},
+			},
+		},
+		{
+			name:      "invalid-abandon-missing-message-id",
+			requestID: 1,
+			conn:      &conn{},
+			packet: func() *packet {
+				envelope := testRequestEnvelope(t, 2)
+				envelope.AppendChild(ber.Encode(ber.ClassApplication, ber.TypePrimitive, ApplicationAbandonRequest, nil, "Abandon Request"))
+				return &packet{Packet: envelope}
+			}(),
+			wantErr:         true,
+			wantErrIs:       ErrInvalidParameter,
+			wantErrContains: "missing message id",
+		},
+		{
+			name:      "valid-extended-operation",
+			requestID: 1,
+			conn:      &conn{},
+			packet:    testStartTLSRequestPacket(t, 1),
+			wantMsg: &ExtendedOperationMessage{
+				baseMessage: baseMessage{id: 1},
+				Name:        ExtendedOperationStartTLS,
+			},
+		},
+		{
+			name:      "valid-extended-operation-with-value",
+			requestID: 1,
+			conn:      &conn{},
+			packet: testExtendedOperationRequestPacket(t,
+				ExtendedOperationMessage{
+					baseMessage: baseMessage{id: 1},
+					Name:        ExtendedOperationPasswordModify,
+					ByteValue:   []byte("\x30\x00"),
+				},
+			),
+			wantMsg: &ExtendedOperationMessage{
+				baseMessage: baseMessage{id: 1},
+				Name:        ExtendedOperationPasswordModify,
+				Value:       "\x30\x00",
+				ByteValue:   []byte("\x30\x00"),
+			},
+		},
+		{
+			name:      "valid-extended-operation-with-controls",
+			requestID: 1,
+			conn:      &conn{},
+			packet: testExtendedOperationRequestPacket(t,
+				ExtendedOperationMessage{
+					baseMessage: baseMessage{id: 1},
+					Name:        ExtendedOperationWhoAmI,
+					Controls:    []Control{&ControlManageDsaIT{}},
+				},
+			),
+			wantMsg: &ExtendedOperationMessage{
+				baseMessage: baseMessage{id: 1},
+				Name:        ExtendedOperationWhoAmI,
+				Controls:    []Control{&ControlManageDsaIT{}},
+			},
+		},
+		{
+			name:      "valid-cancel",
+			requestID: 1,
+			conn:      &conn{},
+			packet: testCancelRequestPacket(t,
+				CancelMessage{
+					baseMessage: baseMessage{id: 2},
+					Value:       testCancelRequestValue(t, 1),
+				},
+			),
+			wantMsg: &CancelMessage{
+				baseMessage: baseMessage{id: 2},
+				Value:       testCancelRequestValue(t, 1),
+			},
+		},
+		{
+			// invalid cancel values are decoded when the request is handled,
+			// so the request can get a ResultProtocolError response
+			name:      "cancel-missing-value",
+			requestID: 1,
+			conn:      &conn{},
+			packet:    testCancelRequestPacket(t, CancelMessage{baseMessage: baseMessage{id: 2}}),
+			wantMsg: &CancelMessage{
+				baseMessage: baseMessage{id: 2},
+			},
+		},
+		{
+			name:      "cancel-invalid-value",
+			requestID: 1,
+			conn:      &conn{},
+			packet:    testCancelRequestPacket(t, CancelMessage{baseMessage: baseMessage{id: 2}, Value: []byte("garbage")}),
+			wantMsg: &CancelMessage{
+				baseMessage: baseMessage{id: 2},
+				Value:       []byte("garbage"),
+			},
+		},
 		{
 			name:      "valid-unbind",
 			requestID: 1,
@@ -96,7 +266,12 @@ func Test_newRequest(t *testing.T) {
 			packet: testSearchRequestPacket(t,
 				SearchMessage{baseMessage: baseMessage{id: 1}, Filter: "(uid=alice)"},
 			),
-			wantMsg: &SearchMessage{baseMessage: baseMessage{id: 1}, Filter: "(uid=alice)", Attributes: []string{}},
+			wantMsg: &SearchMessage{
+				baseMessage:  baseMessage{id: 1},
+				Filter:       "(uid=alice)",
+				ParsedFilter: &EqualityFilter{Attribute: "uid", Value: "alice"},
+				Attributes:   []string{},
+			},
 		},
 		{
 			name:      "valid-extended",
@@ -132,7 +307,9 @@ func Test_newRequest(t *testing.T) {
 					{
 						Operation: AddAttribute,
 						Modification: PartialAttribute{
-							Type: "mail", Vals: []string{TestEncodeString(t, ber.TagOctetString, "alice@example.com")},
+							Type:       "mail",
+							Vals:       []string{"alice@example.com"},
+							ByteValues: [][]byte{[]byte("alice@example.com")},
 						},
 					},
 				},
@@ -141,6 +318,53 @@ func Test_newRequest(t *testing.T) {
 				},
 			},
 		},
+		{
+			name:      "valid-modify-multi-valued",
+			requestID: 1,
+			conn:      &conn{},
+			packet: testModifyRequestPacket(t,
+				ModifyMessage{
+					baseMessage: baseMessage{id: 1},
+					DN:          "uid=alice,ou=people,dc=example,dc=com",
+					Changes: []Change{
+						{
+							Operation: ReplaceAttribute,
+							Modification: PartialAttribute{
+								Type: "mail", Vals: []string{"alice@example.com", "alice@example.org"},
+							},
+						},
+						{
+							Operation: DeleteAttribute,
+							Modification: PartialAttribute{
+								Type: "description",
+							},
+						},
+					},
+				},
+			),
+			wantMsg: &ModifyMessage{
+				baseMessage: baseMessage{id: 1},
+				DN:          "uid=alice,ou=people,dc=example,dc=com",
+				Changes: []Change{
+					{
+						Operation: ReplaceAttribute,
+						Modification: PartialAttribute{
+							Type:       "mail",
+							Vals:       []string{"alice@example.com", "alice@example.org"},
+							ByteValues: [][]byte{[]byte("alice@example.com"), []byte("alice@example.org")},
+						},
+					},
+					{
+						Operation: DeleteAttribute,
+						Modification: PartialAttribute{
+							Type:       "description",
+							Vals:       []string{},
+							ByteValues: [][]byte{},
+						},
+					},
+				},
+			},
+		},
 		{
 			name:      "valid-add",
 			requestID: 1,
@@ -169,12 +393,14 @@ func Test_newRequest(t *testing.T) {
 				DN:          "uid=alice,ou=people,dc=example,dc=com",
 				Attributes: []Attribute{
 					{
-						Type: "mail",
-						Vals: []string{"alice@example.com"},
+						Type:       "mail",
+						Vals:       []string{"alice@example.com"},
+						ByteValues: [][]byte{[]byte("alice@example.com")},
 					},
 					{
-						Type: "givenname",
-						Vals: []string{"alice"},
+						Type:       "givenname",
+						Vals:       []string{"alice"},
+						ByteValues: [][]byte{[]byte("alice")},
 					},
 				},
 				Controls: []Control{
@@ -225,6 +451,110 @@ func Test_newRequest(t *testing.T) {
 				},
 			},
 		},
+		{
+			name:      "valid-compare",
+			requestID: 1,
+			conn:      &conn{},
+			packet: testCompareRequestPacket(t,
+				CompareMessage{
+					baseMessage: baseMessage{id: 1},
+					DN:          "cn=admin,ou=groups,dc=example,dc=com",
+					Assertion: AttributeValueAssertion{
+						AttributeDesc:  "member",
+						AssertionValue: "uid=alice,ou=people,dc=example,dc=com",
+					},
+					Controls: []Control{
+						testControlString(t, "generic-control", WithControlValue("generic-value")),
+					},
+				},
+			),
+			wantMsg: &CompareMessage{
+				baseMessage: baseMessage{id: 1},
+				DN:          "cn=admin,ou=groups,dc=example,dc=com",
+				Assertion: AttributeValueAssertion{
+					AttributeDesc:  "member",
+					AssertionValue: "uid=alice,ou=people,dc=example,dc=com",
+				},
+				Controls: []Control{
+					testControlString(t, "generic-control", WithControlValue("generic-value")),
+				},
+			},
+		},
+		{
+			name:      "invalid-compare",
+			requestID: 1,
+			conn:      &conn{},
+			packet: func() *packet {
+				envelope := testRequestEnvelope(t, 1)
+				pkt := ber.Encode(ber.ClassApplication, ber.TypeConstructed, ApplicationCompareRequest, nil, "Compare Request")
+				pkt.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "cn=admin", "DN"))
+				// missing attribute value assertion
+				envelope.AppendChild(pkt)
+				return &packet{Packet: envelope}
+			}(),
+			wantErr:         true,
+			wantErrContains: "missing/invalid attribute value assertion",
+		},
+		{
+			name:      "valid-modify-dn",
+			requestID: 1,
+			conn:      &conn{},
+			packet: testModifyDNRequestPacket(t,
+				ModifyDNMessage{
+					baseMessage:  baseMessage{id: 1},
+					DN:           "uid=alice,ou=people,dc=example,dc=com",
+					NewRDN:       "uid=alice-smith",
+					DeleteOldRDN: true,
+					NewSuperior:  "ou=admins,dc=example,dc=com",
+					Controls: []Control{
+						testControlString(t, "generic-control", WithControlValue("generic-value")),
+					},
+				},
+			),
+			wantMsg: &ModifyDNMessage{
+				baseMessage:  baseMessage{id: 1},
+				DN:           "uid=alice,ou=people,dc=example,dc=com",
+				NewRDN:       "uid=alice-smith",
+				DeleteOldRDN: true,
+				NewSuperior:  "ou=admins,dc=example,dc=com",
+				Controls: []Control{
+					testControlString(t, "generic-control", WithControlValue("generic-value")),
+				},
+			},
+		},
+		{
+			name:      "valid-modify-dn-rename-only",
+			requestID: 1,
+			conn:      &conn{},
+			packet: testModifyDNRequestPacket(t,
+				ModifyDNMessage{
+					baseMessage: baseMessage{id: 1},
+					DN:          "uid=alice,ou=people,dc=example,dc=com",
+					NewRDN:      "uid=alice-smith",
+				},
+			),
+			wantMsg: &ModifyDNMessage{
+				baseMessage: baseMessage{id: 1},
+				DN:          "uid=alice,ou=people,dc=example,dc=com",
+				NewRDN:      "uid=alice-smith",
+			},
+		},
+		{
+			name:      "invalid-modify-dn",
+			requestID: 1,
+			conn:      &conn{},
+			packet: func() *packet {
+				envelope := testRequestEnvelope(t, 1)
+				pkt := ber.Encode(ber.ClassApplication, ber.TypeConstructed, ApplicationModifyDNRequest, nil, "Modify DN Request")
+				pkt.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "uid=alice", "DN"))
+				pkt.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "uid=bob", "New RDN"))
+				// missing delete old RDN
+				envelope.AppendChild(pkt)
+				return &packet{Packet: envelope}
+			}(),
+			wantErr:         true,
+			wantErrContains: "missing/invalid delete old RDN",
+		},
 		{
 			name:      "invalid-delete",
 			requestID: 1,
@@ -303,6 +633,207 @@ func TestRequest_GetDeleteMessage(t *testing.T) {
 	}
 }
 
+func TestRequest_Context(t *testing.T) {
+	t.Parallel()
+	t.Run("no-context", func(t *testing.T) {
+		assert := assert.New(t)
+		r := &Request{message: &SearchMessage{}}
+		assert.Equal(context.Background(), r.Context())
+	})
+	t.Run("cancelled-by-parent", func(t *testing.T) {
+		assert := assert.New(t)
+		parent, cancel := context.WithCancel(context.Background())
+		r := &Request{message: &DeleteMessage{}}
+		r.initContext(parent)
+		_, ok := r.Context().Deadline()
+		assert.False(ok)
+		assert.NoError(r.Context().Err())
+		cancel()
+		assert.ErrorIs(r.Context().Err(), context.Canceled)
+	})
+	t.Run("abandoned", func(t *testing.T) {
+		assert := assert.New(t)
+		r := &Request{message: &SearchMessage{TimeLimit: 10}}
+		r.initContext(context.Background())
+		r.cancel(ErrAbandoned)
+		assert.ErrorIs(r.Context().Err(), context.Canceled)
+		assert.ErrorIs(context.Cause(r.Context()), ErrAbandoned)
+	})
+	t.Run("search-time-limit", func(t *testing.T) {
+		assert := assert.New(t)
+		r := &Request{message: &SearchMessage{TimeLimit: 10}}
+		r.initContext(context.Background())
+		defer r.cancel(nil)
+		deadline, ok := r.Context().Deadline()
+		assert.True(ok)
+		assert.WithinDuration(time.Now().Add(10*time.Second), deadline, time.Second)
+	})
+}
+
+func TestRequest_GetExtendedOperationMessage(t *testing.T) {
+	tests := []struct {
+		name            string
+		r               *Request
+		wantErr         bool
+		wantErrIs       error
+		wantErrContains string
+	}{
+		{
+			name:            "invalid",
+			r:               &Request{message: &SearchMessage{}},
+			wantErr:         true,
+			wantErrIs:       ErrInvalidParameter,
+			wantErrContains: "not an extended operation request",
+		},
+		{
+			name: "valid",
+			r:    &Request{message: &ExtendedOperationMessage{}},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert, require := assert.New(t), require.New(t)
+			m, err := tc.r.GetExtendedOperationMessage()
+			if tc.wantErr {
+				require.Error(err)
+				assert.Nil(m)
+				if tc.wantErrIs != nil {
+					assert.ErrorIs(err, tc.wantErrIs)
+				}
+				if tc.wantErrContains != "" {
+					assert.Contains(err.Error(), tc.wantErrContains)
+				}
+				return
+			}
+			require.NoError(err)
+			assert.NotNil(m)
+		})
+	}
+}
+
+func TestRequest_GetSASLBindMessage(t *testing.T) {
+	tests := []struct {
+		name            string
+		r               *Request
+		wantErr         bool
+		wantErrIs       error
+		wantErrContains string
+	}{
+		{
+			name:            "invalid",
+			r:               &Request{message: &SimpleBindMessage{}},
+			wantErr:         true,
+			wantErrIs:       ErrInvalidParameter,
+			wantErrContains: "not a sasl bind request",
+		},
+		{
+			name: "valid",
+			r:    &Request{message: &SASLBindMessage{}},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert, require := assert.New(t), require.New(t)
+			m, err := tc.r.GetSASLBindMessage()
+			if tc.wantErr {
+				require.Error(err)
+				assert.Nil(m)
+				if tc.wantErrIs != nil {
+					assert.ErrorIs(err, tc.wantErrIs)
+				}
+				if tc.wantErrContains != "" {
+					assert.Contains(err.Error(), tc.wantErrContains)
+				}
+				return
+			}
+			require.NoError(err)
+			assert.NotNil(m)
+		})
+	}
+}
+
+func TestRequest_GetCompareMessage(t *testing.T) {
+	tests := []struct {
+		name            string
+		r               *Request
+		wantErr         bool
+		wantErrIs       error
+		wantErrContains string
+	}{
+		{
+			name:            "invalid",
+			r:               &Request{},
+			wantErr:         true,
+			wantErrIs:       ErrInvalidParameter,
+			wantErrContains: "not a compare request",
+		},
+		{
+			name: "valid",
+			r:    &Request{message: &CompareMessage{}},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert, require := assert.New(t), require.New(t)
+			m, err := tc.r.GetCompareMessage()
+			if tc.wantErr {
+				require.Error(err)
+				assert.Nil(m)
+				if tc.wantErrIs != nil {
+					assert.ErrorIs(err, tc.wantErrIs)
+				}
+				if tc.wantErrContains != "" {
+					assert.Contains(err.Error(), tc.wantErrContains)
+				}
+				return
+			}
+			require.NoError(err)
+			assert.NotNil(m)
+		})
+	}
+}
+
+func TestRequest_GetModifyDNMessage(t *testing.T) {
+	tests := []struct {
+		name            string
+		r               *Request
+		wantErr         bool
+		wantErrIs       error
+		wantErrContains string
+	}{
+		{
+			name:            "invalid",
+			r:               &Request{},
+			wantErr:         true,
+			wantErrIs:       ErrInvalidParameter,
+			wantErrContains: "not a modify DN request",
+		},
+		{
+			name: "valid",
+			r:    &Request{message: &ModifyDNMessage{}},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert, require := assert.New(t), require.New(t)
+			m, err := tc.r.GetModifyDNMessage()
+			if tc.wantErr {
+				require.Error(err)
+				assert.Nil(m)
+				if tc.wantErrIs != nil {
+					assert.ErrorIs(err, tc.wantErrIs)
+				}
+				if tc.wantErrContains != "" {
+					assert.Contains(err.Error(), tc.wantErrContains)
+				}
+				return
+			}
+			require.NoError(err)
+			assert.NotNil(m)
+		})
+	}
+}
+
 func TestRequest_GetConnectionID(t *testing.T) {
 	t.Parallel()
 	assert, require := assert.New(t), require.New(t)
@@ -319,6 +850,51 @@ func TestRequest_GetConnectionID(t *testing.T) {
 	assert.Equal(connID, req.ConnectionID())
 }
 
+func TestRequest_AuthzID(t *testing.T) {
+	t.Parallel()
+	assert := assert.New(t)
+	const aliceDN = "uid=alice,ou=people,dc=example,dc=org"
+	c := &conn{}
+	simpleBind := func(userName string, password Password) *Request {
+		return &Request{conn: c, message: &SimpleBindMessage{UserName: userName, Password: password}}
+	}
+	search := &Request{conn: c, message: &SearchMessage{}}
+
+	// a request without a conn is anonymous
+	assert.Empty((&Request{message: &SearchMessage{}}).AuthzID())
+
+	// successful simple binds default to the bind DN
+	simpleBind(aliceDN, "password").NewBindResponse(WithResponseCode(ResultSuccess)).completeBind()
+	assert.Equal("dn:"+aliceDN, search.AuthzID())
+	assert.Equal(aliceDN, search.BoundDN())
+
+	// failed binds reset the conn to anonymous
+	simpleBind(aliceDN, "bad-password").NewBindResponse(WithResponseCode(ResultInvalidCredentials)).completeBind()
+	assert.Empty(search.AuthzID())
+	assert.Empty(search.BoundDN())
+
+	// anonymous binds have an empty identity
+	simpleBind(aliceDN, "password").NewBindResponse(WithResponseCode(ResultSuccess)).completeBind()
+	simpleBind(aliceDN, "").NewBindResponse(WithResponseCode(ResultSuccess)).completeBind()
+	assert.Empty(search.AuthzID())
+
+	// in-progress sasl binds are anonymous until they complete
+	sasl := &Request{conn: c, message: &SASLBindMessage{Mechanism: "DIGEST-MD5"}}
+	resp := sasl.NewBindResponse(WithResponseCode(ResultSaslBindInProgress))
+	resp.SetAuthzID("u:alice")
+	resp.completeBind()
+	assert.Empty(search.AuthzID())
+	resp = sasl.NewBindResponse(WithResponseCode(ResultSuccess))
+	resp.SetAuthzID("u:alice")
+	resp.completeBind()
+	assert.Equal("u:alice", search.AuthzID())
+	assert.Empty(search.BoundDN())
+
+	// unbind resets the conn to anonymous
+	c.resetBind()
+	assert.Empty(search.AuthzID())
+}
+
 func TestConvertString(t *testing.T) {
 	t.Parallel()
 
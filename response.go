@@ -5,6 +5,8 @@ package gldap
 
 import (
 	"bufio"
+	"context"
+	"errors"
 	"fmt"
 	"sync"
 
@@ -20,6 +22,12 @@ type ResponseWriter struct {
 	logger    hclog.Logger
 	connID    int
 	requestID int
+
+	// ctx is the context of the request being responded to
+	ctx context.Context
+	// responded is true once a response, other than a search result entry,
+	// has been written (guarded by the writerMu)
+	responded bool
 }
 
 func newResponseWriter(w *bufio.Writer, lock *sync.Mutex, logger hclog.Logger, connID, requestID int) (*ResponseWriter, error) {
@@ -54,19 +62,45 @@ func (rw *ResponseWriter) Write(r Response) error {
 	if r == nil {
 		return fmt.Errorf("%s: missing response: %w", op, ErrInvalidParameter)
 	}
+	if b, ok := r.(*BindResponse); ok {
+		b.completeBind()
+	}
+	_, isEntry := r.(*SearchResponseEntry)
+
+	rw.writerMu.Lock()
+	defer rw.writerMu.Unlock()
+	if rw.ctx != nil {
+		switch cause := context.Cause(rw.ctx); {
+		// no responses are sent for abandoned requests.
+		// see: https://datatracker.ietf.org/doc/html/rfc4511#section-4.11
+		case errors.Is(cause, ErrAbandoned):
+			return fmt.Errorf("%s: request %d: %w", op, rw.requestID, ErrAbandoned)
+		// cancelled requests stop returning entries and finish with a
+		// ResultCanceled response.
+		// see: https://datatracker.ietf.org/doc/html/rfc3909#section-2.2
+		case errors.Is(cause, ErrCanceled):
+			if isEntry || rw.responded {
+				return fmt.Errorf("%s: request %d: %w", op, rw.requestID, ErrCanceled)
+			}
+			if resp, ok := r.(interface{ SetResultCode(int) }); ok {
+				resp.SetResultCode(ResultCanceled)
+			}
+		}
+	}
 	p := r.packet()
 	if rw.logger.IsDebug() {
 		rw.logger.Debug("response write", "op", op, "conn", rw.connID, "requestID", rw.requestID)
 		p.Log(rw.logger.StandardWriter(&hclog.StandardLoggerOptions{}), 0, false)
 	}
-	rw.writerMu.Lock()
-	defer rw.writerMu.Unlock()
-	if _, err := rw.writer.Write(r.packet().Bytes()); err != nil {
+	if _, err := rw.writer.Write(p.Bytes()); err != nil {
 		return fmt.Errorf("%s: unable to write response: %w", op, err)
 	}
 	if err := rw.writer.Flush(); err != nil {
 		return fmt.Errorf("%s: unable to flush write: %w", op, err)
 	}
+	if !isEntry {
+		rw.responded = true
+	}
 	rw.logger.Debug("finished writing", "op", op, "conn", rw.connID, "requestID", rw.requestID)
 	return nil
 }
@@ -115,7 +149,9 @@ func (l *baseResponse) SetMatchedDN(dn string) {
 // ExtendedResponse represents a response to an extended operation request
 type ExtendedResponse struct {
 	*baseResponse
-	name ExtendedOperationName
+	name     ExtendedOperationName
+	value    []byte
+	controls []Control
 }
 
 // SetResponseName will set the response name for the extended operation response.
@@ -123,6 +159,19 @@ func (r *ExtendedResponse) SetResponseName(n ExtendedOperationName) {
 	r.name = n
 }
 
+// SetResponseValue will set the optional response value for the extended
+// operation response.  The value is encoded as-is, so any value which is
+// defined using ASN.1 (for example: PasswdModifyResponseValue) must already be
+// BER encoded.  A nil value omits the response value.
+func (r *ExtendedResponse) SetResponseValue(v []byte) {
+	r.value = v
+}
+
+// SetControls for extended response
+func (r *ExtendedResponse) SetControls(controls ...Control) {
+	r.controls = controls
+}
+
 func (r *ExtendedResponse) packet() *packet {
 	replyPacket := beginResponse(r.messageID)
 
@@ -134,14 +183,27 @@ func (r *ExtendedResponse) packet() *packet {
 	// Add optional diagnostic message and matched DN
 	addOptionalResponseChildren(resultPacket, WithDiagnosticMessage(r.diagMessage), WithMatchedDN(r.matchedDN))
 
+	if r.name != "" {
+		resultPacket.AppendChild(ber.NewString(ber.ClassContext, ber.TypePrimitive, 10, string(r.name), "responseName"))
+	}
+	if r.value != nil {
+		resultPacket.AppendChild(ber.NewString(ber.ClassContext, ber.TypePrimitive, 11, string(r.value), "responseValue"))
+	}
+
 	replyPacket.AppendChild(resultPacket)
+	if len(r.controls) > 0 {
+		replyPacket.AppendChild(encodeControls(r.controls))
+	}
 	return &packet{Packet: replyPacket}
 }
 
 // BindResponse represents the response to a bind request
 type BindResponse struct {
 	*baseResponse
-	controls []Control
+	controls        []Control
+	serverSASLCreds []byte
+	authzID         string
+	conn            *conn
 }
 
 // SetControls for bind response
@@ -149,6 +211,44 @@ func (r *BindResponse) SetControls(controls ...Control) {
 	r.controls = controls
 }
 
+// SetServerSASLCreds sets the optional server SASL credentials for the bind
+// response (typically a challenge, when the result code is
+// ResultSaslBindInProgress)
+func (r *BindResponse) SetServerSASLCreds(creds []byte) {
+	r.serverSASLCreds = creds
+}
+
+// SetAuthzID sets the authorization identity (for example: "dn:<dn>" or
+// "u:<userid>") for the connection when the bind is successful (see:
+// Request.AuthzID()).  Successful simple binds default to "dn:" plus the
+// bind's DN, and anonymous binds default to an empty identity.  SASL bind
+// handlers should set the identity authorized by their mechanism.
+func (r *BindResponse) SetAuthzID(id string) {
+	r.authzID = id
+}
+
+// completeBind sets the connection's authorization identity when the
+// response is successful and resets the connection to anonymous otherwise.
+// Any multi-step SASL bind state for the connection is cleared unless the
+// response indicates the bind is still in progress.
+// see: https://datatracker.ietf.org/doc/html/rfc4511#section-4.2.1
+func (r *BindResponse) completeBind() {
+	if r.conn == nil {
+		return
+	}
+	r.conn.stateMu.Lock()
+	defer r.conn.stateMu.Unlock()
+	switch r.code {
+	case ResultSuccess:
+		r.conn.authzID = r.authzID
+	default:
+		r.conn.authzID = ""
+	}
+	if r.code != ResultSaslBindInProgress {
+		r.conn.saslBind = nil
+	}
+}
+
 func (r *BindResponse) packet() *packet {
 	replyPacket := beginResponse(r.messageID)
 
@@ -160,6 +260,10 @@ func (r *BindResponse) packet() *packet {
 	// Add optional diagnostic message and matched DN
 	addOptionalResponseChildren(resultPacket, WithDiagnosticMessage(r.diagMessage), WithMatchedDN(r.matchedDN))
 
+	if r.serverSASLCreds != nil {
+		resultPacket.AppendChild(ber.NewString(ber.ClassContext, ber.TypePrimitive, 7, string(r.serverSASLCreds), "serverSaslCreds"))
+	}
+
 	replyPacket.AppendChild(resultPacket)
 	if len(r.controls) > 0 {
 		replyPacket.AppendChild(encodeControls(r.controls))
@@ -229,6 +333,12 @@ func (r *SearchResponseEntry) AddAttribute(name string, values []string) {
 	r.entry.Attributes = append(r.entry.Attributes, NewEntryAttribute(name, values))
 }
 
+// AddBinaryAttribute will add a binary attribute (for example: objectSid,
+// objectGUID or jpegPhoto) with raw values to the response entry
+func (r *SearchResponseEntry) AddBinaryAttribute(name string, values [][]byte) {
+	r.entry.Attributes = append(r.entry.Attributes, NewEntryAttributeBytes(name, values))
+}
+
 func (r *SearchResponseEntry) packet() *packet {
 	const op = "gldap.(SearchEntryResponse).packet" // nolint:unused
 	replyPacket := beginResponse(r.messageID)
@@ -9,6 +9,7 @@ type responseOptions struct {
 	withResponseCode      *int
 	withApplicationCode   *int
 	withAttributes        map[string][]string
+	withMaxValueRange     int
 }
 
 func responseDefaults() responseOptions {
@@ -73,3 +74,14 @@ func WithAttributes(attributes map[string][]string) Option {
 		}
 	}
 }
+
+// WithMaxValueRange specifies the max number of values returned for a ranged
+// attribute, which is like Active Directory's MaxValRange policy (for
+// example: 1500).  See: SearchResponseEntry.AddRangedAttribute
+func WithMaxValueRange(max int) Option {
+	return func(o interface{}) {
+		if o, ok := o.(*responseOptions); ok {
+			o.withMaxValueRange = max
+		}
+	}
+}
@@ -56,3 +56,12 @@ func Test_WithAttributes(t *testing.T) {
 	testOpts.withAttributes = attrs
 	assert.Equal(opts, testOpts)
 }
+
+func Test_WithMaxValueRange(t *testing.T) {
+	t.Parallel()
+	assert := assert.New(t)
+	opts := getResponseOpts(WithMaxValueRange(1500))
+	testOpts := responseDefaults()
+	testOpts.withMaxValueRange = 1500
+	assert.Equal(opts, testOpts)
+}
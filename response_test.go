@@ -6,10 +6,12 @@ package gldap
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"io"
 	"sync"
 	"testing"
 
+	ber "github.com/go-asn1-ber/asn1-ber"
 	"github.com/hashicorp/go-hclog"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -149,6 +151,19 @@ func TestResponseWriter_Write(t *testing.T) {
 			},
 		},
 	}
+	t.Run("abandoned", func(t *testing.T) {
+		assert, require := assert.New(t), require.New(t)
+		var buf bytes.Buffer
+		w, err := newResponseWriter(bufio.NewWriter(&buf), &sync.Mutex{}, hclog.NewNullLogger(), 1, 1)
+		require.NoError(err)
+		ctx, cancel := context.WithCancelCause(context.Background())
+		w.ctx = ctx
+		cancel(ErrAbandoned)
+		err = w.Write(&testResponse{baseResponse: &baseResponse{messageID: 1}, data: "test"})
+		require.Error(err)
+		assert.ErrorIs(err, ErrAbandoned)
+		assert.Empty(buf.Bytes())
+	})
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
 			assert, require := assert.New(t), require.New(t)
@@ -193,6 +208,104 @@ func Test_baseResponse(t *testing.T) {
 	})
 }
 
+func TestExtendedResponse_packet(t *testing.T) {
+	t.Parallel()
+	r := &Request{message: &ExtendedOperationMessage{baseMessage: baseMessage{id: 1}}}
+	tests := []struct {
+		name         string
+		resp         func() *ExtendedResponse
+		wantName     string
+		wantValue    []byte
+		wantControls bool
+	}{
+		{
+			name: "no-name-or-value",
+			resp: func() *ExtendedResponse {
+				return r.NewExtendedResponse(WithResponseCode(ResultSuccess))
+			},
+		},
+		{
+			name: "name",
+			resp: func() *ExtendedResponse {
+				resp := r.NewExtendedResponse(WithResponseCode(ResultSuccess))
+				resp.SetResponseName(ExtendedOperationStartTLS)
+				return resp
+			},
+			wantName: string(ExtendedOperationStartTLS),
+		},
+		{
+			name: "value",
+			resp: func() *ExtendedResponse {
+				resp := r.NewExtendedResponse(WithResponseCode(ResultSuccess))
+				resp.SetResponseValue([]byte("dn:uid=alice,ou=people,dc=example,dc=org"))
+				return resp
+			},
+			wantValue: []byte("dn:uid=alice,ou=people,dc=example,dc=org"),
+		},
+		{
+			name: "empty-value",
+			resp: func() *ExtendedResponse {
+				resp := r.NewExtendedResponse(WithResponseCode(ResultSuccess))
+				resp.SetResponseValue([]byte{})
+				return resp
+			},
+			wantValue: []byte{},
+		},
+		{
+			name: "name-value-and-controls",
+			resp: func() *ExtendedResponse {
+				resp := r.NewExtendedResponse(WithResponseCode(ResultSuccess))
+				resp.SetResponseName("1.2.3.4")
+				resp.SetResponseValue([]byte("value"))
+				c, err := NewControlManageDsaIT()
+				require.NoError(t, err)
+				resp.SetControls(c)
+				return resp
+			},
+			wantName:     "1.2.3.4",
+			wantValue:    []byte("value"),
+			wantControls: true,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert, require := assert.New(t), require.New(t)
+			// round trip the encoded response
+			p, err := ber.DecodePacketErr(tc.resp().packet().Bytes())
+			require.NoError(err)
+			extResp := p.Children[1]
+			assert.Equal(ber.Tag(ApplicationExtendedResponse), extResp.Tag)
+
+			// result code, matched dn and diagnostic message are always
+			// included
+			optional := extResp.Children[3:]
+			if tc.wantName != "" {
+				require.NotEmpty(optional)
+				assert.Equal(ber.ClassContext, optional[0].ClassType)
+				assert.Equal(ber.Tag(10), optional[0].Tag)
+				assert.Equal(tc.wantName, optional[0].Data.String())
+				optional = optional[1:]
+			}
+			if tc.wantValue != nil {
+				require.NotEmpty(optional)
+				assert.Equal(ber.ClassContext, optional[0].ClassType)
+				assert.Equal(ber.Tag(11), optional[0].Tag)
+				assert.Equal(string(tc.wantValue), optional[0].Data.String())
+				optional = optional[1:]
+			}
+			assert.Empty(optional)
+
+			if tc.wantControls {
+				require.Len(p.Children, 3)
+				assert.Equal(ber.Tag(0), p.Children[2].Tag)
+				assert.Len(p.Children[2].Children, 1)
+				return
+			}
+			assert.Len(p.Children, 2)
+		})
+	}
+}
+
 type testResponse struct {
 	*baseResponse
 	data string
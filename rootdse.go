@@ -0,0 +1,83 @@
+// Copyright (c) Jim Lambert
+// SPDX-License-Identifier: MIT
+
+package gldap
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Root DSE attributes.
+// see: https://datatracker.ietf.org/doc/html/rfc4512#section-5.1
+const (
+	rootDSESupportedControl     = "supportedControl"
+	rootDSESupportedLDAPVersion = "supportedLDAPVersion"
+)
+
+// RootDSE will register a handler for root DSE search requests (a search with
+// an empty base DN and a base object scope), which responds with an entry
+// containing the attributes and the mux's supported controls as its
+// supportedControl attribute (see: WithSupportedControls).  Routes are matched
+// in the order they're added, so the root DSE should be registered before any
+// other search routes which could match its requests.
+// Options supported: WithLabel, WithControl, WithMiddleware
+//
+// see: https://datatracker.ietf.org/doc/html/rfc4512#section-5.1
+func (m *Mux) RootDSE(attributes map[string][]string, opt ...Option) error {
+	const op = "gldap.(Mux).RootDSE"
+	if hasRootDSEAttribute(attributes, rootDSESupportedControl) {
+		return fmt.Errorf("%s: %s is set using WithSupportedControls: %w", op, rootDSESupportedControl, ErrInvalidParameter)
+	}
+	opts := getRouteOpts(opt...)
+	r := &rootDSERoute{
+		baseRoute: &baseRoute{
+			h:          m.rootDSEHandler(attributes),
+			routeOp:    searchRouteOperation,
+			label:      opts.withLabel,
+			middleware: opts.withMiddleware,
+			controls:   opts.withControls,
+		},
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.routes = append(m.routes, r)
+	return nil
+}
+
+// rootDSEHandler creates a HandlerFunc which responds with the root DSE entry
+func (m *Mux) rootDSEHandler(attributes map[string][]string) HandlerFunc {
+	const op = "gldap.(Mux).rootDSEHandler"
+	return func(w *ResponseWriter, r *Request) {
+		attrs := make(map[string][]string, len(attributes)+2)
+		for name, values := range attributes {
+			attrs[name] = values
+		}
+		if !hasRootDSEAttribute(attrs, rootDSESupportedLDAPVersion) {
+			attrs[rootDSESupportedLDAPVersion] = []string{"3"}
+		}
+		if controls := m.SupportedControls(); len(controls) > 0 {
+			attrs[rootDSESupportedControl] = controls
+		}
+		entry := r.NewSearchResponseEntry("", WithAttributes(attrs))
+		if err := w.Write(entry); err != nil {
+			w.logger.Error("error writing root DSE entry", "op", op, "conn", w.connID, "requestID", w.requestID, "err", err)
+			return
+		}
+		resp := r.NewSearchDoneResponse(WithResponseCode(ResultSuccess))
+		if err := w.Write(resp); err != nil {
+			w.logger.Error("error writing response", "op", op, "conn", w.connID, "requestID", w.requestID, "err", err)
+		}
+	}
+}
+
+// hasRootDSEAttribute returns true when the attributes have the named
+// attribute.  Attribute names are not case sensitive.
+func hasRootDSEAttribute(attributes map[string][]string, name string) bool {
+	for n := range attributes {
+		if strings.EqualFold(n, name) {
+			return true
+		}
+	}
+	return false
+}
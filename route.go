@@ -35,28 +35,89 @@ const (
 	// unbindRouteOperation is a route supporting the unbind operation
 	unbindRouteOperation routeOperation = "unbind"
 
+	// compareRouteOperation is a route supporting the compare operation
+	compareRouteOperation routeOperation = "compare"
+
+	// modifyDNRouteOperation is a route supporting the modify DN operation
+	modifyDNRouteOperation routeOperation = "modifyDN"
+
+	// abandonRouteOperation is an abandon operation, which is handled by the
+	// conn and never routed to a handler
+	abandonRouteOperation routeOperation = "abandon"
+
 	// defaultRouteOperation is a default route which is used when there are no routes
 	// defined for a particular operation
-	defaultRouteOperation routeOperation = "noRoute" // nolint:unused
+	defaultRouteOperation routeOperation = "noRoute"
 )
 
 // HandlerFunc defines a function for handling an LDAP request.
 type HandlerFunc func(*ResponseWriter, *Request)
 
+// Middleware wraps a HandlerFunc, which allows cross-cutting concerns (logging,
+// auth checks, metrics, etc) to be handled before and/or after the wrapped
+// handler.  Middleware can stop a request from reaching the wrapped handler by
+// simply writing its own response and returning without calling it.
+type Middleware func(HandlerFunc) HandlerFunc
+
+// chain wraps the handler with the middleware, where the first middleware is
+// the outermost.
+func chain(h HandlerFunc, mw []Middleware) HandlerFunc {
+	for i := len(mw) - 1; i >= 0; i-- {
+		h = mw[i](h)
+	}
+	return h
+}
+
 type route interface {
 	match(req *Request) bool
 	handler() HandlerFunc
 	op() routeOperation
+	info() RouteInfo
+}
+
+// RouteInfo describes a route registered with a Mux (see: Mux.Routes)
+type RouteInfo struct {
+	// Operation is the route's operation (for example: "bind", "search",
+	// "unbind" or "noRoute" for the default route)
+	Operation string
+	// Label is the route's optional label (see: WithLabel)
+	Label string
+	// BaseDN is the base DN matched by a search route (see: WithBaseDN)
+	BaseDN string
+	// BaseDNSuffix is the base DN suffix matched by a search route (see:
+	// WithBaseDNSuffix)
+	BaseDNSuffix string
+	// Filter is the filter matched by a search route (see: WithFilter)
+	Filter string
+	// Scope is the scope matched by a search route (see: WithScope)
+	Scope Scope
+	// ExtendedName is the extended operation name matched by an extended
+	// operation route
+	ExtendedName ExtendedOperationName
+	// Mechanism is the SASL mechanism matched by a SASL bind route
+	Mechanism SASLMechanism
+	// Controls are the control types matched by the route (see:
+	// WithControl)
+	Controls []string
+	// Mount is the suffix of the mounted Mux which the route is registered
+	// with, and it's empty for the Mux's own routes (see: Mux.Mount)
+	Mount string
 }
 
 type baseRoute struct {
-	h       HandlerFunc
-	routeOp routeOperation
-	label   string
+	h          HandlerFunc
+	routeOp    routeOperation
+	label      string
+	middleware []Middleware
+	controls   []string
 }
 
+// handler returns the route's handler wrapped with the route's middleware
 func (r *baseRoute) handler() HandlerFunc {
-	return r.h
+	if r.h == nil {
+		return nil
+	}
+	return chain(r.h, r.middleware)
 }
 
 func (r *baseRoute) op() routeOperation {
@@ -67,11 +128,42 @@ func (r *baseRoute) match(req *Request) bool {
 	return false
 }
 
+func (r *baseRoute) info() RouteInfo {
+	return RouteInfo{
+		Operation: string(r.routeOp),
+		Label:     r.label,
+		Controls:  append([]string(nil), r.controls...),
+	}
+}
+
+// matchControls returns true when the request includes all of the route's
+// controls
+func (r *baseRoute) matchControls(req *Request) bool {
+	if len(r.controls) == 0 {
+		return true
+	}
+	reqControls := req.controls()
+	for _, want := range r.controls {
+		found := false
+		for _, c := range reqControls {
+			if c != nil && c.GetControlType() == want {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
 type searchRoute struct {
 	*baseRoute
-	basedn string
-	filter string
-	scope  Scope
+	basedn       string
+	basednSuffix *DN
+	filter       string
+	scope        Scope
 }
 
 type simpleBindRoute struct {
@@ -79,6 +171,11 @@ type simpleBindRoute struct {
 	authChoice AuthChoice
 }
 
+type saslBindRoute struct {
+	*baseRoute
+	mechanism SASLMechanism
+}
+
 type unbindRoute struct {
 	*baseRoute
 }
@@ -100,6 +197,46 @@ type deleteRoute struct {
 	*baseRoute
 }
 
+type compareRoute struct {
+	*baseRoute
+}
+
+func (r *compareRoute) match(req *Request) bool {
+	if req == nil {
+		return false
+	}
+	if r.op() != req.routeOp {
+		return false
+	}
+	if !r.matchControls(req) {
+		return false
+	}
+	if _, ok := req.message.(*CompareMessage); !ok {
+		return false
+	}
+	return true
+}
+
+type modifyDNRoute struct {
+	*baseRoute
+}
+
+func (r *modifyDNRoute) match(req *Request) bool {
+	if req == nil {
+		return false
+	}
+	if r.op() != req.routeOp {
+		return false
+	}
+	if !r.matchControls(req) {
+		return false
+	}
+	if _, ok := req.message.(*ModifyDNMessage); !ok {
+		return false
+	}
+	return true
+}
+
 func (r *deleteRoute) match(req *Request) bool {
 	if req == nil {
 		return false
@@ -107,6 +244,9 @@ func (r *deleteRoute) match(req *Request) bool {
 	if r.op() != req.routeOp {
 		return false
 	}
+	if !r.matchControls(req) {
+		return false
+	}
 	if _, ok := req.message.(*DeleteMessage); !ok {
 		return false
 	}
@@ -120,6 +260,9 @@ func (r *addRoute) match(req *Request) bool {
 	if r.op() != req.routeOp {
 		return false
 	}
+	if !r.matchControls(req) {
+		return false
+	}
 	if _, ok := req.message.(*AddMessage); !ok {
 		return false
 	}
@@ -133,6 +276,9 @@ func (r *modifyRoute) match(req *Request) bool {
 	if r.op() != req.routeOp {
 		return false
 	}
+	if !r.matchControls(req) {
+		return false
+	}
 	if _, ok := req.message.(*ModifyMessage); !ok {
 		return false
 	}
@@ -146,6 +292,9 @@ func (r *simpleBindRoute) match(req *Request) bool {
 	if r.op() != req.routeOp {
 		return false
 	}
+	if !r.matchControls(req) {
+		return false
+	}
 	if m, ok := req.message.(*SimpleBindMessage); ok {
 		if r.authChoice != "" && r.authChoice == m.AuthChoice {
 			return true
@@ -154,6 +303,37 @@ func (r *simpleBindRoute) match(req *Request) bool {
 	return false
 }
 
+func (r *saslBindRoute) info() RouteInfo {
+	i := r.baseRoute.info()
+	i.Mechanism = r.mechanism
+	return i
+}
+
+func (r *saslBindRoute) match(req *Request) bool {
+	if req == nil {
+		return false
+	}
+	if r.op() != req.routeOp {
+		return false
+	}
+	if !r.matchControls(req) {
+		return false
+	}
+	if m, ok := req.message.(*SASLBindMessage); ok {
+		// sasl mechanism names are case-insensitive
+		if r.mechanism != "" && strings.EqualFold(string(r.mechanism), string(m.Mechanism)) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *extendedRoute) info() RouteInfo {
+	i := r.baseRoute.info()
+	i.ExtendedName = r.extendedName
+	return i
+}
+
 func (r *extendedRoute) match(req *Request) bool {
 	if req == nil {
 		return false
@@ -164,10 +344,24 @@ func (r *extendedRoute) match(req *Request) bool {
 	if r.extendedName != req.extendedName {
 		return false
 	}
+	if !r.matchControls(req) {
+		return false
+	}
 	_, ok := req.message.(*ExtendedOperationMessage)
 	return ok
 }
 
+func (r *searchRoute) info() RouteInfo {
+	i := r.baseRoute.info()
+	i.BaseDN = r.basedn
+	if r.basednSuffix != nil {
+		i.BaseDNSuffix = r.basednSuffix.String()
+	}
+	i.Filter = r.filter
+	i.Scope = r.scope
+	return i
+}
+
 func (r *searchRoute) match(req *Request) bool {
 	if req == nil {
 		return false
@@ -175,13 +369,25 @@ func (r *searchRoute) match(req *Request) bool {
 	if r.op() != req.routeOp {
 		return false
 	}
+	if !r.matchControls(req) {
+		return false
+	}
 	searchMsg, ok := req.message.(*SearchMessage)
 	if !ok {
 		return false
 	}
-	if r.basedn != "" && !strings.EqualFold(searchMsg.BaseDN, r.basedn) {
+	if r.basedn != "" && !equalDNs(searchMsg.BaseDN, r.basedn) {
 		return false
 	}
+	if r.basednSuffix != nil {
+		baseDN, err := ParseDN(searchMsg.BaseDN)
+		if err != nil {
+			return false
+		}
+		if !baseDN.Equal(r.basednSuffix) && !baseDN.IsDescendantOf(r.basednSuffix) {
+			return false
+		}
+	}
 	if r.filter != "" && !strings.EqualFold(searchMsg.Filter, r.filter) {
 		return false
 	}
@@ -193,3 +399,31 @@ func (r *searchRoute) match(req *Request) bool {
 	// match.
 	return true
 }
+
+// rootDSERoute matches root DSE search requests
+type rootDSERoute struct {
+	*baseRoute
+}
+
+func (r *rootDSERoute) info() RouteInfo {
+	i := r.baseRoute.info()
+	i.Scope = BaseObject
+	return i
+}
+
+func (r *rootDSERoute) match(req *Request) bool {
+	if req == nil {
+		return false
+	}
+	if r.op() != req.routeOp {
+		return false
+	}
+	if !r.matchControls(req) {
+		return false
+	}
+	searchMsg, ok := req.message.(*SearchMessage)
+	if !ok {
+		return false
+	}
+	return searchMsg.BaseDN == "" && searchMsg.Scope == BaseObject
+}
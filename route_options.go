@@ -4,10 +4,13 @@
 package gldap
 
 type routeOptions struct {
-	withLabel  string
-	withBaseDN string
-	withFilter string
-	withScope  Scope
+	withLabel        string
+	withBaseDN       string
+	withBaseDNSuffix string
+	withFilter       string
+	withScope        Scope
+	withMiddleware   []Middleware
+	withControls     []string
 }
 
 func routeDefaults() routeOptions {
@@ -29,7 +32,9 @@ func WithLabel(l string) Option {
 	}
 }
 
-// WithBaseDN specifies an optional base DN to associate with a Search route
+// WithBaseDN specifies an optional base DN to associate with a Search route.
+// The request's base DN is matched using normalized DNs (see: DN.Normalize), so
+// "cn=Foo, dc=x" matches "CN=foo,DC=x".
 func WithBaseDN(dn string) Option {
 	return func(o interface{}) {
 		if o, ok := o.(*routeOptions); ok {
@@ -38,6 +43,23 @@ func WithBaseDN(dn string) Option {
 	}
 }
 
+// WithBaseDNSuffix specifies an optional base DN suffix (naming context) to
+// associate with a Search route.  The route matches any request with a base DN
+// which is equal to or below the suffix (for example: the suffix
+// "ou=people,dc=example,dc=org" matches requests with a base DN of
+// "uid=alice,ou=people,dc=example,dc=org").  The request's scope doesn't
+// affect the match, since every scope is confined to its base DN's subtree:
+// a BaseObject search of a descendant and a SingleLevel search of the suffix
+// itself both match, while a WholeSubtree search of the suffix's parent
+// doesn't (see: WithScope to only match requests with a specific scope).
+func WithBaseDNSuffix(suffix string) Option {
+	return func(o interface{}) {
+		if o, ok := o.(*routeOptions); ok {
+			o.withBaseDNSuffix = suffix
+		}
+	}
+}
+
 // WithFilter specifies an optional filter to associate with a Search route
 func WithFilter(filter string) Option {
 	return func(o interface{}) {
@@ -55,3 +77,28 @@ func WithScope(s Scope) Option {
 		}
 	}
 }
+
+// WithMiddleware specifies optional middleware to wrap the route's handler.
+// The route's middleware is applied in the order it's specified, within any
+// middleware registered for the Mux (see: Mux.Use)
+func WithMiddleware(mw ...Middleware) Option {
+	return func(o interface{}) {
+		if o, ok := o.(*routeOptions); ok {
+			o.withMiddleware = append(o.withMiddleware, mw...)
+		}
+	}
+}
+
+// WithControl specifies optional control types (OIDs) to associate with a
+// route.  The route only matches requests which include all of the controls
+// (for example: WithControl(ControlTypePaging) to route paged searches to a
+// dedicated handler).  It's supported by the Bind, SASLBind, Search, RootDSE,
+// ExtendedOperation, Modify, Add, Delete, Compare and ModifyDN routes; the
+// Unbind and DefaultRoute routes ignore it.
+func WithControl(controlType ...string) Option {
+	return func(o interface{}) {
+		if o, ok := o.(*routeOptions); ok {
+			o.withControls = append(o.withControls, controlType...)
+		}
+	}
+}
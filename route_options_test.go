@@ -27,6 +27,15 @@ func Test_WithBaseDN(t *testing.T) {
 	assert.Equal(opts, testOpts)
 }
 
+func Test_WithBaseDNSuffix(t *testing.T) {
+	t.Parallel()
+	assert := assert.New(t)
+	opts := getRouteOpts(WithBaseDNSuffix("dc=example,dc=org"))
+	testOpts := routeDefaults()
+	testOpts.withBaseDNSuffix = "dc=example,dc=org"
+	assert.Equal(opts, testOpts)
+}
+
 func Test_WithFilter(t *testing.T) {
 	t.Parallel()
 	assert := assert.New(t)
@@ -44,3 +53,30 @@ func Test_WithScope(t *testing.T) {
 	testOpts.withScope = SingleLevel
 	assert.Equal(opts, testOpts)
 }
+
+func Test_WithMiddleware(t *testing.T) {
+	t.Parallel()
+	assert := assert.New(t)
+	var called []string
+	mw := func(name string) Middleware {
+		return func(next HandlerFunc) HandlerFunc {
+			return func(w *ResponseWriter, r *Request) {
+				called = append(called, name)
+				next(w, r)
+			}
+		}
+	}
+	opts := getRouteOpts(WithMiddleware(mw("first")), WithMiddleware(mw("second"), mw("third")))
+	assert.Len(opts.withMiddleware, 3)
+	chain(func(*ResponseWriter, *Request) {}, opts.withMiddleware)(nil, nil)
+	assert.Equal([]string{"first", "second", "third"}, called)
+}
+
+func Test_WithControl(t *testing.T) {
+	t.Parallel()
+	assert := assert.New(t)
+	opts := getRouteOpts(WithControl(ControlTypePaging), WithControl(ControlTypeManageDsaIT))
+	testOpts := routeDefaults()
+	testOpts.withControls = []string{ControlTypePaging, ControlTypeManageDsaIT}
+	assert.Equal(opts, testOpts)
+}
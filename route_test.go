@@ -65,6 +65,22 @@ func TestSearchRoute_match(t *testing.T) {
 			},
 			wantMatch: true,
 		},
+		{
+			name: "baseDN-normalized-match",
+			route: &searchRoute{
+				baseRoute: &baseRoute{
+					routeOp: searchRouteOperation,
+				},
+				basedn: "cn=Foo, dc=example,dc=com",
+			},
+			req: &Request{
+				routeOp: searchRouteOperation,
+				message: &SearchMessage{
+					BaseDN: "CN=foo,DC=Example, DC=com",
+				},
+			},
+			wantMatch: true,
+		},
 		{
 			name: "baseDN-mismatch",
 			route: &searchRoute{
@@ -80,6 +96,193 @@ func TestSearchRoute_match(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "baseDN-suffix-equal",
+			route: &searchRoute{
+				baseRoute: &baseRoute{
+					routeOp: searchRouteOperation,
+				},
+				basednSuffix: testParseDN(t, "ou=people,dc=example,dc=com"),
+			},
+			req: &Request{
+				routeOp: searchRouteOperation,
+				message: &SearchMessage{
+					BaseDN: "OU=People, DC=example,DC=com",
+					Scope:  WholeSubtree,
+				},
+			},
+			wantMatch: true,
+		},
+		{
+			name: "baseDN-suffix-descendant",
+			route: &searchRoute{
+				baseRoute: &baseRoute{
+					routeOp: searchRouteOperation,
+				},
+				basednSuffix: testParseDN(t, "ou=people,dc=example,dc=com"),
+			},
+			req: &Request{
+				routeOp: searchRouteOperation,
+				message: &SearchMessage{
+					BaseDN: "uid=alice,ou=people,dc=example,dc=com",
+					Scope:  BaseObject,
+				},
+			},
+			wantMatch: true,
+		},
+		{
+			// every scope is confined to the base DN's subtree, so a search
+			// of the suffix's entries with any scope matches
+			name: "baseDN-suffix-descendant-whole-subtree",
+			route: &searchRoute{
+				baseRoute: &baseRoute{
+					routeOp: searchRouteOperation,
+				},
+				basednSuffix: testParseDN(t, "ou=people,dc=example,dc=com"),
+			},
+			req: &Request{
+				routeOp: searchRouteOperation,
+				message: &SearchMessage{
+					BaseDN: "uid=alice,ou=people,dc=example,dc=com",
+					Scope:  WholeSubtree,
+				},
+			},
+			wantMatch: true,
+		},
+		{
+			name: "baseDN-suffix-equal-single-level",
+			route: &searchRoute{
+				baseRoute: &baseRoute{
+					routeOp: searchRouteOperation,
+				},
+				basednSuffix: testParseDN(t, "ou=people,dc=example,dc=com"),
+			},
+			req: &Request{
+				routeOp: searchRouteOperation,
+				message: &SearchMessage{
+					BaseDN: "ou=people,dc=example,dc=com",
+					Scope:  SingleLevel,
+				},
+			},
+			wantMatch: true,
+		},
+		{
+			name: "baseDN-suffix-equal-base-object",
+			route: &searchRoute{
+				baseRoute: &baseRoute{
+					routeOp: searchRouteOperation,
+				},
+				basednSuffix: testParseDN(t, "ou=people,dc=example,dc=com"),
+			},
+			req: &Request{
+				routeOp: searchRouteOperation,
+				message: &SearchMessage{
+					BaseDN: "ou=people,dc=example,dc=com",
+					Scope:  BaseObject,
+				},
+			},
+			wantMatch: true,
+		},
+		{
+			// a scope can't reach the suffix from above it, so a parent is
+			// never matched
+			name: "baseDN-suffix-parent-whole-subtree",
+			route: &searchRoute{
+				baseRoute: &baseRoute{
+					routeOp: searchRouteOperation,
+				},
+				basednSuffix: testParseDN(t, "ou=people,dc=example,dc=com"),
+			},
+			req: &Request{
+				routeOp: searchRouteOperation,
+				message: &SearchMessage{
+					BaseDN: "dc=example,dc=com",
+					Scope:  WholeSubtree,
+				},
+			},
+		},
+		{
+			name: "baseDN-suffix-parent",
+			route: &searchRoute{
+				baseRoute: &baseRoute{
+					routeOp: searchRouteOperation,
+				},
+				basednSuffix: testParseDN(t, "ou=people,dc=example,dc=com"),
+			},
+			req: &Request{
+				routeOp: searchRouteOperation,
+				message: &SearchMessage{
+					BaseDN: "dc=example,dc=com",
+					Scope:  SingleLevel,
+				},
+			},
+		},
+		{
+			name: "baseDN-suffix-sibling",
+			route: &searchRoute{
+				baseRoute: &baseRoute{
+					routeOp: searchRouteOperation,
+				},
+				basednSuffix: testParseDN(t, "ou=people,dc=example,dc=com"),
+			},
+			req: &Request{
+				routeOp: searchRouteOperation,
+				message: &SearchMessage{
+					BaseDN: "ou=groups,dc=example,dc=com",
+					Scope:  WholeSubtree,
+				},
+			},
+		},
+		{
+			name: "baseDN-suffix-invalid-request-dn",
+			route: &searchRoute{
+				baseRoute: &baseRoute{
+					routeOp: searchRouteOperation,
+				},
+				basednSuffix: testParseDN(t, "ou=people,dc=example,dc=com"),
+			},
+			req: &Request{
+				routeOp: searchRouteOperation,
+				message: &SearchMessage{
+					BaseDN: "not a dn",
+				},
+			},
+		},
+		{
+			name: "baseDN-suffix-scope-match",
+			route: &searchRoute{
+				baseRoute: &baseRoute{
+					routeOp: searchRouteOperation,
+				},
+				basednSuffix: testParseDN(t, "ou=people,dc=example,dc=com"),
+				scope:        SingleLevel,
+			},
+			req: &Request{
+				routeOp: searchRouteOperation,
+				message: &SearchMessage{
+					BaseDN: "ou=people,dc=example,dc=com",
+					Scope:  SingleLevel,
+				},
+			},
+			wantMatch: true,
+		},
+		{
+			name: "baseDN-suffix-scope-mismatch",
+			route: &searchRoute{
+				baseRoute: &baseRoute{
+					routeOp: searchRouteOperation,
+				},
+				basednSuffix: testParseDN(t, "ou=people,dc=example,dc=com"),
+				scope:        SingleLevel,
+			},
+			req: &Request{
+				routeOp: searchRouteOperation,
+				message: &SearchMessage{
+					BaseDN: "ou=people,dc=example,dc=com",
+					Scope:  WholeSubtree,
+				},
+			},
+		},
 		{
 			name: "filter-match",
 			route: &searchRoute{
@@ -142,6 +345,55 @@ func TestSearchRoute_match(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "control-match",
+			route: &searchRoute{
+				baseRoute: &baseRoute{
+					routeOp:  searchRouteOperation,
+					controls: []string{ControlTypePaging},
+				},
+			},
+			req: &Request{
+				routeOp: searchRouteOperation,
+				message: &SearchMessage{
+					Controls: []Control{
+						&ControlMicrosoftShowDeleted{},
+						&ControlPaging{PagingSize: 10},
+					},
+				},
+			},
+			wantMatch: true,
+		},
+		{
+			name: "control-mismatch",
+			route: &searchRoute{
+				baseRoute: &baseRoute{
+					routeOp:  searchRouteOperation,
+					controls: []string{ControlTypePaging},
+				},
+			},
+			req: &Request{
+				routeOp: searchRouteOperation,
+				message: &SearchMessage{
+					Controls: []Control{&ControlMicrosoftShowDeleted{}},
+				},
+			},
+		},
+		{
+			name: "controls-missing-one",
+			route: &searchRoute{
+				baseRoute: &baseRoute{
+					routeOp:  searchRouteOperation,
+					controls: []string{ControlTypePaging, ControlTypeManageDsaIT},
+				},
+			},
+			req: &Request{
+				routeOp: searchRouteOperation,
+				message: &SearchMessage{
+					Controls: []Control{&ControlPaging{PagingSize: 10}},
+				},
+			},
+		},
 	}
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
@@ -295,6 +547,37 @@ func TestExtendedRoute_match(t *testing.T) {
 				extendedName: ExtendedOperationDisconnection,
 			},
 		},
+		{
+			name: "control-match",
+			route: &extendedRoute{
+				baseRoute: &baseRoute{
+					routeOp:  extendedRouteOperation,
+					controls: []string{ControlTypeManageDsaIT},
+				},
+				extendedName: ExtendedOperationWhoAmI,
+			},
+			req: &Request{
+				routeOp:      extendedRouteOperation,
+				message:      &ExtendedOperationMessage{Controls: []Control{&ControlManageDsaIT{}}},
+				extendedName: ExtendedOperationWhoAmI,
+			},
+			wantMatch: true,
+		},
+		{
+			name: "control-mismatch",
+			route: &extendedRoute{
+				baseRoute: &baseRoute{
+					routeOp:  extendedRouteOperation,
+					controls: []string{ControlTypeManageDsaIT},
+				},
+				extendedName: ExtendedOperationWhoAmI,
+			},
+			req: &Request{
+				routeOp:      extendedRouteOperation,
+				message:      &ExtendedOperationMessage{},
+				extendedName: ExtendedOperationWhoAmI,
+			},
+		},
 		{
 			name: "extended-name-matched",
 			route: &extendedRoute{
@@ -377,6 +660,33 @@ func TestAddRoute_match(t *testing.T) {
 			},
 			wantMatch: true,
 		},
+		{
+			name: "control-match",
+			route: &addRoute{
+				baseRoute: &baseRoute{
+					routeOp:  addRouteOperation,
+					controls: []string{ControlTypeManageDsaIT},
+				},
+			},
+			req: &Request{
+				routeOp: addRouteOperation,
+				message: &AddMessage{Controls: []Control{&ControlManageDsaIT{}}},
+			},
+			wantMatch: true,
+		},
+		{
+			name: "control-mismatch",
+			route: &addRoute{
+				baseRoute: &baseRoute{
+					routeOp:  addRouteOperation,
+					controls: []string{ControlTypeManageDsaIT},
+				},
+			},
+			req: &Request{
+				routeOp: addRouteOperation,
+				message: &AddMessage{},
+			},
+		},
 	}
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
@@ -459,9 +769,304 @@ func TestModifyRoute_match(t *testing.T) {
 	}
 }
 
+func TestSASLBindRoute_match(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name      string
+		route     *saslBindRoute
+		req       *Request
+		wantMatch bool
+	}{
+		{
+			name: "req-nil",
+			route: &saslBindRoute{
+				baseRoute: &baseRoute{
+					routeOp: bindRouteOperation,
+				},
+				mechanism: SASLMechanismPlain,
+			},
+		},
+		{
+			name: "op-mismatched",
+			route: &saslBindRoute{
+				baseRoute: &baseRoute{
+					routeOp: bindRouteOperation,
+				},
+				mechanism: SASLMechanismPlain,
+			},
+			req: &Request{
+				routeOp: searchRouteOperation,
+			},
+		},
+		{
+			name: "not-a-sasl-bind-msg",
+			route: &saslBindRoute{
+				baseRoute: &baseRoute{
+					routeOp: bindRouteOperation,
+				},
+				mechanism: SASLMechanismPlain,
+			},
+			req: &Request{
+				routeOp: bindRouteOperation,
+				message: &SimpleBindMessage{AuthChoice: SimpleAuthChoice},
+			},
+		},
+		{
+			name: "mechanism-mismatched",
+			route: &saslBindRoute{
+				baseRoute: &baseRoute{
+					routeOp: bindRouteOperation,
+				},
+				mechanism: SASLMechanismPlain,
+			},
+			req: &Request{
+				routeOp: bindRouteOperation,
+				message: &SASLBindMessage{Mechanism: SASLMechanismExternal},
+			},
+		},
+		{
+			name: "success",
+			route: &saslBindRoute{
+				baseRoute: &baseRoute{
+					routeOp: bindRouteOperation,
+				},
+				mechanism: SASLMechanismPlain,
+			},
+			req: &Request{
+				routeOp: bindRouteOperation,
+				message: &SASLBindMessage{Mechanism: SASLMechanismPlain},
+			},
+			wantMatch: true,
+		},
+		{
+			name: "success-case-insensitive",
+			route: &saslBindRoute{
+				baseRoute: &baseRoute{
+					routeOp: bindRouteOperation,
+				},
+				mechanism: SASLMechanismPlain,
+			},
+			req: &Request{
+				routeOp: bindRouteOperation,
+				message: &SASLBindMessage{Mechanism: "plain"},
+			},
+			wantMatch: true,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := assert.New(t)
+			match := tc.route.match(tc.req)
+			switch tc.wantMatch {
+			case true:
+				assert.True(match)
+			case false:
+				assert.False(match)
+			}
+		})
+	}
+}
+
+func TestCompareRoute_match(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name      string
+		route     *compareRoute
+		req       *Request
+		wantMatch bool
+	}{
+		{
+			name: "req-nil",
+			route: &compareRoute{
+				baseRoute: &baseRoute{
+					routeOp: compareRouteOperation,
+				},
+			},
+		},
+		{
+			name: "op-mismatched",
+			route: &compareRoute{
+				baseRoute: &baseRoute{
+					routeOp: compareRouteOperation,
+				},
+			},
+			req: &Request{
+				routeOp: searchRouteOperation,
+			},
+		},
+		{
+			name: "not-a-compare-op-msg",
+			route: &compareRoute{
+				baseRoute: &baseRoute{
+					routeOp: compareRouteOperation,
+				},
+			},
+			req: &Request{
+				routeOp: compareRouteOperation,
+				message: &SearchMessage{},
+			},
+		},
+		{
+			name: "success",
+			route: &compareRoute{
+				baseRoute: &baseRoute{
+					routeOp: compareRouteOperation,
+				},
+			},
+			req: &Request{
+				routeOp: compareRouteOperation,
+				message: &CompareMessage{},
+			},
+			wantMatch: true,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := assert.New(t)
+			match := tc.route.match(tc.req)
+			switch tc.wantMatch {
+			case true:
+				assert.True(match)
+			case false:
+				assert.False(match)
+			}
+		})
+	}
+}
+
+func TestModifyDNRoute_match(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name      string
+		route     *modifyDNRoute
+		req       *Request
+		wantMatch bool
+	}{
+		{
+			name: "req-nil",
+			route: &modifyDNRoute{
+				baseRoute: &baseRoute{
+					routeOp: modifyDNRouteOperation,
+				},
+			},
+		},
+		{
+			name: "op-mismatched",
+			route: &modifyDNRoute{
+				baseRoute: &baseRoute{
+					routeOp: modifyDNRouteOperation,
+				},
+			},
+			req: &Request{
+				routeOp: searchRouteOperation,
+			},
+		},
+		{
+			name: "not-a-modify-dn-op-msg",
+			route: &modifyDNRoute{
+				baseRoute: &baseRoute{
+					routeOp: modifyDNRouteOperation,
+				},
+			},
+			req: &Request{
+				routeOp: modifyDNRouteOperation,
+				message: &SearchMessage{},
+			},
+		},
+		{
+			name: "success",
+			route: &modifyDNRoute{
+				baseRoute: &baseRoute{
+					routeOp: modifyDNRouteOperation,
+				},
+			},
+			req: &Request{
+				routeOp: modifyDNRouteOperation,
+				message: &ModifyDNMessage{},
+			},
+			wantMatch: true,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := assert.New(t)
+			match := tc.route.match(tc.req)
+			switch tc.wantMatch {
+			case true:
+				assert.True(match)
+			case false:
+				assert.False(match)
+			}
+		})
+	}
+}
+
 func TestBaseRoute_match(t *testing.T) {
 	t.Run("always-fail", func(t *testing.T) {
 		r := baseRoute{}
 		require.False(t, r.match(&Request{}))
 	})
 }
+
+func testParseDN(t *testing.T, dn string) *DN {
+	t.Helper()
+	d, err := ParseDN(dn)
+	require.NoError(t, err)
+	return d
+}
+
+func TestRootDSERoute_match(t *testing.T) {
+	t.Parallel()
+	route := &rootDSERoute{
+		baseRoute: &baseRoute{
+			routeOp: searchRouteOperation,
+		},
+	}
+	tests := []struct {
+		name      string
+		req       *Request
+		wantMatch bool
+	}{
+		{
+			name: "req-nil",
+		},
+		{
+			name: "op-mismatched",
+			req:  &Request{routeOp: bindRouteOperation},
+		},
+		{
+			name: "not-a-search-msg",
+			req:  &Request{routeOp: searchRouteOperation, message: &SimpleBindMessage{}},
+		},
+		{
+			name: "base-dn-mismatch",
+			req:  &Request{routeOp: searchRouteOperation, message: &SearchMessage{BaseDN: "dc=example,dc=com"}},
+		},
+		{
+			name: "scope-mismatch",
+			req:  &Request{routeOp: searchRouteOperation, message: &SearchMessage{Scope: WholeSubtree}},
+		},
+		{
+			name:      "success",
+			req:       &Request{routeOp: searchRouteOperation, message: &SearchMessage{Scope: BaseObject}},
+			wantMatch: true,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := assert.New(t)
+			assert.Equal(tc.wantMatch, route.match(tc.req))
+		})
+	}
+	t.Run("controls", func(t *testing.T) {
+		assert := assert.New(t)
+		route := &rootDSERoute{
+			baseRoute: &baseRoute{
+				routeOp:  searchRouteOperation,
+				controls: []string{ControlTypeManageDsaIT},
+			},
+		}
+		assert.False(route.match(&Request{routeOp: searchRouteOperation, message: &SearchMessage{Scope: BaseObject}}))
+		assert.True(route.match(&Request{routeOp: searchRouteOperation, message: &SearchMessage{Scope: BaseObject, Controls: []Control{&ControlManageDsaIT{}}}}))
+	})
+}
@@ -0,0 +1,231 @@
+// Copyright (c) Jim Lambert
+// SPDX-License-Identifier: MIT
+
+package gldap
+
+import (
+	"bytes"
+	"crypto/x509"
+	"fmt"
+	"strings"
+)
+
+// SASLMechanism is a SASL mechanism name (see:
+// https://www.iana.org/assignments/sasl-mechanisms/sasl-mechanisms.xhtml)
+type SASLMechanism string
+
+// Supported SASL mechanisms with built-in handlers.
+const (
+	// SASLMechanismPlain is the PLAIN mechanism defined in
+	// https://datatracker.ietf.org/doc/html/rfc4616
+	SASLMechanismPlain SASLMechanism = "PLAIN"
+
+	// SASLMechanismExternal is the EXTERNAL mechanism defined in
+	// https://datatracker.ietf.org/doc/html/rfc4422#appendix-A
+	SASLMechanismExternal SASLMechanism = "EXTERNAL"
+)
+
+// SASLBindMessage is a SASL bind request message
+type SASLBindMessage struct {
+	baseMessage
+	// AuthChoice for the request (SASLAuthChoice)
+	AuthChoice AuthChoice
+	// UserName for the bind request, which is typically empty for SASL binds
+	UserName string
+	// Mechanism is the requested SASL mechanism
+	Mechanism SASLMechanism
+	// Credentials are the optional SASL credentials for the bind request
+	Credentials []byte
+	// Controls are optional controls for the bind request
+	Controls []Control
+}
+
+// saslBindState is the state of a multi-step SASL bind for a connection
+type saslBindState struct {
+	mechanism SASLMechanism
+	state     interface{}
+}
+
+// SASLBindState returns the state of an in-progress multi-step SASL bind for
+// the request's connection.  The state returned is whatever was set with
+// SetSASLBindState(...) by a previous step of the bind which used the same
+// mechanism (mechanism names are case-insensitive).  It returns nil when there isn't a bind in progress, the request
+// isn't a SASL bind request or the in-progress bind is using a different
+// mechanism.
+//
+// The state is cleared when any bind response, other than one with a result
+// code of ResultSaslBindInProgress, is written for the connection.
+func (r *Request) SASLBindState() interface{} {
+	m, ok := r.message.(*SASLBindMessage)
+	if !ok {
+		return nil
+	}
+	r.conn.stateMu.Lock()
+	defer r.conn.stateMu.Unlock()
+	// sasl mechanism names are case-insensitive
+	if r.conn.saslBind == nil || !strings.EqualFold(string(r.conn.saslBind.mechanism), string(m.Mechanism)) {
+		return nil
+	}
+	return r.conn.saslBind.state
+}
+
+// SetSASLBindState stores the state of a multi-step SASL bind for the
+// request's connection, which will be available to the next step of the bind
+// via SASLBindState().  Handlers should set the state and then write a bind
+// response with a result code of ResultSaslBindInProgress.
+func (r *Request) SetSASLBindState(state interface{}) error {
+	const op = "gldap.(Request).SetSASLBindState"
+	m, ok := r.message.(*SASLBindMessage)
+	if !ok {
+		return fmt.Errorf("%s: %T not a sasl bind request: %w", op, r.message, ErrInvalidParameter)
+	}
+	r.conn.stateMu.Lock()
+	defer r.conn.stateMu.Unlock()
+	r.conn.saslBind = &saslBindState{
+		mechanism: m.Mechanism,
+		state:     state,
+	}
+	return nil
+}
+
+// SASLPlainAuthFunc authenticates the credentials of a SASL PLAIN bind
+// request.  The authzID will be empty when the client didn't request a
+// specific authorization identity.
+type SASLPlainAuthFunc func(r *Request, authzID, authcID string, password Password) bool
+
+// NewSASLPlainHandler creates a HandlerFunc for SASL PLAIN bind requests,
+// which uses the authFn to authenticate the request's credentials.  It's
+// intended to be registered using: Mux.SASLBind(SASLMechanismPlain, ...)
+func NewSASLPlainHandler(authFn SASLPlainAuthFunc) (HandlerFunc, error) {
+	const op = "gldap.NewSASLPlainHandler"
+	if authFn == nil {
+		return nil, fmt.Errorf("%s: missing auth func: %w", op, ErrInvalidParameter)
+	}
+	return func(w *ResponseWriter, r *Request) {
+		resp := r.NewBindResponse(WithResponseCode(ResultInvalidCredentials))
+		defer func() {
+			if err := w.Write(resp); err != nil {
+				w.logger.Error("error writing response", "op", op, "conn", w.connID, "requestID", w.requestID, "err", err)
+			}
+		}()
+		m, err := r.GetSASLBindMessage()
+		if err != nil {
+			w.logger.Error("not a sasl bind message", "op", op, "conn", w.connID, "requestID", w.requestID, "err", err)
+			resp.SetResultCode(ResultProtocolError)
+			return
+		}
+		authzID, authcID, password, err := parseSASLPlainCredentials(m.Credentials)
+		if err != nil {
+			w.logger.Debug("invalid sasl plain credentials", "op", op, "conn", w.connID, "requestID", w.requestID, "err", err)
+			return
+		}
+		if authFn(r, authzID, authcID, password) {
+			resp.SetResultCode(ResultSuccess)
+			if authzID == "" {
+				authzID = "u:" + authcID
+			}
+			resp.SetAuthzID(authzID)
+		}
+	}, nil
+}
+
+// parseSASLPlainCredentials parses the PLAIN mechanism's message which is
+// formatted as: [authzid] UTF8NUL authcid UTF8NUL passwd
+func parseSASLPlainCredentials(creds []byte) (authzID, authcID string, password Password, err error) {
+	const op = "gldap.parseSASLPlainCredentials"
+	parts := bytes.Split(creds, []byte{0})
+	if len(parts) != 3 {
+		return "", "", "", fmt.Errorf("%s: expected 3 NUL separated parts and got %d: %w", op, len(parts), ErrInvalidParameter)
+	}
+	if len(parts[1]) == 0 {
+		return "", "", "", fmt.Errorf("%s: missing authentication identity: %w", op, ErrInvalidParameter)
+	}
+	if len(parts[2]) == 0 {
+		return "", "", "", fmt.Errorf("%s: missing password: %w", op, ErrInvalidParameter)
+	}
+	return string(parts[0]), string(parts[1]), Password(parts[2]), nil
+}
+
+// SASLExternalAuthFunc authorizes a SASL EXTERNAL bind request, which relies
+// on an authentication established outside of ldap (for example: a TLS client
+// certificate).  The authzID will be empty when the client didn't request a
+// specific authorization identity.  It returns the authorization identity
+// for the connection (for example: one derived from the client's verified
+// certificate, see: Request.PeerCertificates) and whether the bind is
+// authorized.
+type SASLExternalAuthFunc func(r *Request, authzID string) (identity string, ok bool)
+
+// NewSASLExternalHandler creates a HandlerFunc for SASL EXTERNAL bind
+// requests, which uses the authFn to authorize the request.  The bind fails
+// when the authFn returns an empty identity.  It's intended to be registered
+// using: Mux.SASLBind(SASLMechanismExternal, ...)
+func NewSASLExternalHandler(authFn SASLExternalAuthFunc) (HandlerFunc, error) {
+	const op = "gldap.NewSASLExternalHandler"
+	if authFn == nil {
+		return nil, fmt.Errorf("%s: missing auth func: %w", op, ErrInvalidParameter)
+	}
+	return newSASLExternalHandler(op, func(_ *ResponseWriter, r *Request, authzID string) (string, bool) {
+		identity, ok := authFn(r, authzID)
+		return identity, ok && identity != ""
+	}), nil
+}
+
+// SASLExternalCertMapFunc maps a verified TLS client certificate chain (leaf
+// first) to an authorization identity (for example:
+// "dn:uid=alice,ou=people,dc=example,dc=org").  An error should be returned
+// when the chain can't be mapped to an identity.
+type SASLExternalCertMapFunc func(chain []*x509.Certificate) (authzID string, err error)
+
+// NewSASLExternalCertHandler creates a HandlerFunc for SASL EXTERNAL bind
+// requests which are authenticated by the connection's verified TLS client
+// certificate (see: Request.PeerCertificates()).  The mapFn maps the
+// certificate chain to an authorization identity.  The bind succeeds when the
+// client didn't request an authorization identity or requested the same
+// identity returned by the mapFn.  It's intended to be registered using:
+// Mux.SASLBind(SASLMechanismExternal, ...)
+func NewSASLExternalCertHandler(mapFn SASLExternalCertMapFunc) (HandlerFunc, error) {
+	const op = "gldap.NewSASLExternalCertHandler"
+	if mapFn == nil {
+		return nil, fmt.Errorf("%s: missing map func: %w", op, ErrInvalidParameter)
+	}
+	return newSASLExternalHandler(op, func(w *ResponseWriter, r *Request, authzID string) (string, bool) {
+		chain := r.PeerCertificates()
+		if len(chain) == 0 {
+			w.logger.Debug("missing verified client certificate", "op", op, "conn", w.connID, "requestID", w.requestID)
+			return "", false
+		}
+		mappedID, err := mapFn(chain)
+		if err != nil {
+			w.logger.Debug("unable to map client certificate", "op", op, "conn", w.connID, "requestID", w.requestID, "subject", chain[0].Subject.String(), "err", err)
+			return "", false
+		}
+		if mappedID == "" {
+			return "", false
+		}
+		return mappedID, authzID == "" || authzID == mappedID
+	}), nil
+}
+
+// newSASLExternalHandler creates a HandlerFunc which writes a bind response
+// based on the authFn's result.  The authFn returns the authorization identity
+// for the connection and whether the bind is authorized.
+func newSASLExternalHandler(op string, authFn func(w *ResponseWriter, r *Request, authzID string) (string, bool)) HandlerFunc {
+	return func(w *ResponseWriter, r *Request) {
+		resp := r.NewBindResponse(WithResponseCode(ResultInvalidCredentials))
+		defer func() {
+			if err := w.Write(resp); err != nil {
+				w.logger.Error("error writing response", "op", op, "conn", w.connID, "requestID", w.requestID, "err", err)
+			}
+		}()
+		m, err := r.GetSASLBindMessage()
+		if err != nil {
+			w.logger.Error("not a sasl bind message", "op", op, "conn", w.connID, "requestID", w.requestID, "err", err)
+			resp.SetResultCode(ResultProtocolError)
+			return
+		}
+		if authzID, ok := authFn(w, r, string(m.Credentials)); ok {
+			resp.SetResultCode(ResultSuccess)
+			resp.SetAuthzID(authzID)
+		}
+	}
+}
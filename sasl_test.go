@@ -0,0 +1,350 @@
+// Copyright (c) Jim Lambert
+// SPDX-License-Identifier: MIT
+
+package gldap
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+	"sync"
+	"testing"
+
+	ber "github.com/go-asn1-ber/asn1-ber"
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_parseSASLPlainCredentials(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name            string
+		creds           []byte
+		wantAuthzID     string
+		wantAuthcID     string
+		wantPassword    Password
+		wantErr         bool
+		wantErrIs       error
+		wantErrContains string
+	}{
+		{
+			name:            "missing-creds",
+			wantErr:         true,
+			wantErrIs:       ErrInvalidParameter,
+			wantErrContains: "expected 3 NUL separated parts and got 1",
+		},
+		{
+			name:            "too-many-parts",
+			creds:           []byte("admin\x00alice\x00fido\x00"),
+			wantErr:         true,
+			wantErrIs:       ErrInvalidParameter,
+			wantErrContains: "expected 3 NUL separated parts and got 4",
+		},
+		{
+			name:            "missing-authcid",
+			creds:           []byte("admin\x00\x00fido"),
+			wantErr:         true,
+			wantErrIs:       ErrInvalidParameter,
+			wantErrContains: "missing authentication identity",
+		},
+		{
+			name:            "missing-password",
+			creds:           []byte("admin\x00alice\x00"),
+			wantErr:         true,
+			wantErrIs:       ErrInvalidParameter,
+			wantErrContains: "missing password",
+		},
+		{
+			name:         "valid-without-authzid",
+			creds:        []byte("\x00alice\x00fido"),
+			wantAuthcID:  "alice",
+			wantPassword: "fido",
+		},
+		{
+			name:         "valid-with-authzid",
+			creds:        []byte("admin\x00alice\x00fido"),
+			wantAuthzID:  "admin",
+			wantAuthcID:  "alice",
+			wantPassword: "fido",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert, require := assert.New(t), require.New(t)
+			authzID, authcID, password, err := parseSASLPlainCredentials(tc.creds)
+			if tc.wantErr {
+				require.Error(err)
+				if tc.wantErrIs != nil {
+					assert.ErrorIs(err, tc.wantErrIs)
+				}
+				if tc.wantErrContains != "" {
+					assert.Contains(err.Error(), tc.wantErrContains)
+				}
+				return
+			}
+			require.NoError(err)
+			assert.Equal(tc.wantAuthzID, authzID)
+			assert.Equal(tc.wantAuthcID, authcID)
+			assert.Equal(tc.wantPassword, password)
+		})
+	}
+}
+
+func TestNewSASLPlainHandler(t *testing.T) {
+	t.Parallel()
+	t.Run("missing-auth-fn", func(t *testing.T) {
+		assert, require := assert.New(t), require.New(t)
+		h, err := NewSASLPlainHandler(nil)
+		require.Error(err)
+		assert.Nil(h)
+		assert.ErrorIs(err, ErrInvalidParameter)
+		assert.Contains(err.Error(), "missing auth func")
+	})
+	authFn := func(_ *Request, authzID, authcID string, password Password) bool {
+		return authzID == "" && authcID == "alice" && password == "fido"
+	}
+	tests := []struct {
+		name        string
+		creds       []byte
+		wantCode    int
+		wantAuthzID string
+	}{
+		{
+			name:     "invalid-creds",
+			creds:    []byte("alice"),
+			wantCode: ResultInvalidCredentials,
+		},
+		{
+			name:     "bad-password",
+			creds:    []byte("\x00alice\x00bad"),
+			wantCode: ResultInvalidCredentials,
+		},
+		{
+			name:        "success",
+			creds:       []byte("\x00alice\x00fido"),
+			wantCode:    ResultSuccess,
+			wantAuthzID: "u:alice",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert, require := assert.New(t), require.New(t)
+			h, err := NewSASLPlainHandler(authFn)
+			require.NoError(err)
+			gotCode, gotAuthzID := testSASLBind(t, h, SASLBindMessage{
+				baseMessage: baseMessage{id: 1},
+				Mechanism:   SASLMechanismPlain,
+				Credentials: tc.creds,
+			})
+			assert.Equal(tc.wantCode, gotCode)
+			assert.Equal(tc.wantAuthzID, gotAuthzID)
+		})
+	}
+}
+
+func TestNewSASLExternalHandler(t *testing.T) {
+	t.Parallel()
+	t.Run("missing-auth-fn", func(t *testing.T) {
+		assert, require := assert.New(t), require.New(t)
+		h, err := NewSASLExternalHandler(nil)
+		require.Error(err)
+		assert.Nil(h)
+		assert.ErrorIs(err, ErrInvalidParameter)
+		assert.Contains(err.Error(), "missing auth func")
+	})
+	const alice = "dn:uid=alice,ou=people,dc=example,dc=org"
+	authFn := func(_ *Request, authzID string) (string, bool) {
+		switch authzID {
+		case "dn:uid=nobody,ou=people,dc=example,dc=org":
+			return "", true
+		default:
+			return alice, authzID == "" || authzID == alice
+		}
+	}
+	tests := []struct {
+		name        string
+		creds       []byte
+		wantCode    int
+		wantAuthzID string
+	}{
+		{
+			name:     "not-authorized",
+			creds:    []byte("dn:uid=eve,ou=people,dc=example,dc=org"),
+			wantCode: ResultInvalidCredentials,
+		},
+		{
+			name:     "missing-identity",
+			creds:    []byte("dn:uid=nobody,ou=people,dc=example,dc=org"),
+			wantCode: ResultInvalidCredentials,
+		},
+		{
+			name:        "success-no-authzid",
+			wantCode:    ResultSuccess,
+			wantAuthzID: alice,
+		},
+		{
+			name:        "success-with-authzid",
+			creds:       []byte("dn:uid=alice,ou=people,dc=example,dc=org"),
+			wantCode:    ResultSuccess,
+			wantAuthzID: "dn:uid=alice,ou=people,dc=example,dc=org",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert, require := assert.New(t), require.New(t)
+			h, err := NewSASLExternalHandler(authFn)
+			require.NoError(err)
+			gotCode, gotAuthzID := testSASLBind(t, h, SASLBindMessage{
+				baseMessage: baseMessage{id: 1},
+				Mechanism:   SASLMechanismExternal,
+				Credentials: tc.creds,
+			})
+			assert.Equal(tc.wantCode, gotCode)
+			assert.Equal(tc.wantAuthzID, gotAuthzID)
+		})
+	}
+}
+
+func TestNewSASLExternalCertHandler(t *testing.T) {
+	t.Parallel()
+	t.Run("missing-map-fn", func(t *testing.T) {
+		assert, require := assert.New(t), require.New(t)
+		h, err := NewSASLExternalCertHandler(nil)
+		require.Error(err)
+		assert.Nil(h)
+		assert.ErrorIs(err, ErrInvalidParameter)
+		assert.Contains(err.Error(), "missing map func")
+	})
+	aliceCert := &x509.Certificate{Subject: pkix.Name{CommonName: "alice"}}
+	eveCert := &x509.Certificate{Subject: pkix.Name{CommonName: "eve"}}
+	mapFn := func(chain []*x509.Certificate) (string, error) {
+		if chain[0].Subject.CommonName != "alice" {
+			return "", fmt.Errorf("unknown subject %q", chain[0].Subject.String())
+		}
+		return "dn:uid=alice,ou=people,dc=example,dc=org", nil
+	}
+	tests := []struct {
+		name        string
+		peerCerts   []*x509.Certificate
+		creds       []byte
+		wantCode    int
+		wantAuthzID string
+	}{
+		{
+			name:     "missing-peer-certs",
+			wantCode: ResultInvalidCredentials,
+		},
+		{
+			name:      "unmapped-cert",
+			peerCerts: []*x509.Certificate{eveCert},
+			wantCode:  ResultInvalidCredentials,
+		},
+		{
+			name:      "authzid-mismatch",
+			peerCerts: []*x509.Certificate{aliceCert},
+			creds:     []byte("dn:uid=eve,ou=people,dc=example,dc=org"),
+			wantCode:  ResultInvalidCredentials,
+		},
+		{
+			name:        "success-no-authzid",
+			peerCerts:   []*x509.Certificate{aliceCert},
+			wantCode:    ResultSuccess,
+			wantAuthzID: "dn:uid=alice,ou=people,dc=example,dc=org",
+		},
+		{
+			name:        "success-with-authzid",
+			peerCerts:   []*x509.Certificate{aliceCert},
+			creds:       []byte("dn:uid=alice,ou=people,dc=example,dc=org"),
+			wantCode:    ResultSuccess,
+			wantAuthzID: "dn:uid=alice,ou=people,dc=example,dc=org",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert, require := assert.New(t), require.New(t)
+			h, err := NewSASLExternalCertHandler(mapFn)
+			require.NoError(err)
+			gotCode, gotAuthzID := testSASLBind(t, h, SASLBindMessage{
+				baseMessage: baseMessage{id: 1},
+				Mechanism:   SASLMechanismExternal,
+				Credentials: tc.creds,
+			}, tc.peerCerts...)
+			assert.Equal(tc.wantCode, gotCode)
+			assert.Equal(tc.wantAuthzID, gotAuthzID)
+		})
+	}
+}
+
+func TestRequest_SASLBindState(t *testing.T) {
+	t.Parallel()
+	assert, require := assert.New(t), require.New(t)
+	c := &conn{}
+	newReq := func(mech SASLMechanism) *Request {
+		return &Request{conn: c, message: &SASLBindMessage{Mechanism: mech}}
+	}
+
+	t.Run("not-sasl", func(t *testing.T) {
+		r := &Request{conn: c, message: &SimpleBindMessage{}}
+		err := r.SetSASLBindState("state")
+		require.Error(err)
+		assert.ErrorIs(err, ErrInvalidParameter)
+		assert.Contains(err.Error(), "not a sasl bind request")
+		assert.Nil(r.SASLBindState())
+	})
+
+	r := newReq("DIGEST-MD5")
+	assert.Nil(r.SASLBindState())
+	require.NoError(r.SetSASLBindState("step-1"))
+	assert.Equal("step-1", newReq("DIGEST-MD5").SASLBindState())
+	assert.Equal("step-1", newReq("digest-md5").SASLBindState())
+	assert.Nil(newReq(SASLMechanismPlain).SASLBindState())
+
+	// an in-progress response keeps the state
+	resp := r.NewBindResponse(WithResponseCode(ResultSaslBindInProgress))
+	resp.completeBind()
+	assert.Equal("step-1", newReq("DIGEST-MD5").SASLBindState())
+
+	// any other response clears it
+	resp = r.NewBindResponse(WithResponseCode(ResultSuccess))
+	resp.completeBind()
+	assert.Nil(newReq("DIGEST-MD5").SASLBindState())
+}
+
+func TestBindResponse_SetServerSASLCreds(t *testing.T) {
+	t.Parallel()
+	assert := assert.New(t)
+	r := &Request{conn: &conn{}, message: &SASLBindMessage{baseMessage: baseMessage{id: 1}}}
+
+	resp := r.NewBindResponse(WithResponseCode(ResultSaslBindInProgress))
+	p := resp.packet()
+	assert.Len(p.Children[1].Children, 3)
+
+	resp.SetServerSASLCreds([]byte("challenge"))
+	p = resp.packet()
+	assert.Len(p.Children[1].Children, 4)
+	creds := p.Children[1].Children[3]
+	assert.Equal(uint8(7), uint8(creds.Tag))
+	assert.Equal("challenge", creds.Data.String())
+}
+
+// testSASLBind runs the handler for the sasl bind message and returns the
+// result code of the response it writes and the connection's resulting
+// authorization identity.
+func testSASLBind(t *testing.T, h HandlerFunc, m SASLBindMessage, peerCerts ...*x509.Certificate) (int, string) {
+	t.Helper()
+	require := require.New(t)
+	var buf bytes.Buffer
+	w, err := newResponseWriter(bufio.NewWriter(&buf), &sync.Mutex{}, hclog.NewNullLogger(), 1, 1)
+	require.NoError(err)
+	r, err := newRequest(1, &conn{}, testSASLBindRequestPacket(t, m))
+	require.NoError(err)
+	r.peerCerts = peerCerts
+	h(w, r)
+
+	resp := ber.DecodePacket(buf.Bytes())
+	require.NotNil(resp)
+	return int(resp.Children[1].Children[0].Value.(int64)), r.AuthzID()
+}
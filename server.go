@@ -30,6 +30,7 @@ type Server struct {
 	writeTimeout   time.Duration
 	onCloseHandler OnCloseHandler
 
+	maxPagingCursors     int
 	disablePanicRecovery bool
 	shutdownCancel       context.CancelFunc
 	shutdownCtx          context.Context
@@ -42,6 +43,7 @@ type Server struct {
 // - WithReadTimeout will set a read time out per connection
 // - WithWriteTimeout will set a write time out per connection
 // - WithOnClose will define a callback the server will call every time a connection is closed
+// - WithMaxPagingCursors will set the maximum number of paged search cursors per connection
 func NewServer(opt ...Option) (*Server, error) {
 	cancelCtx, cancel := context.WithCancel(context.Background())
 	opts := getConfigOpts(opt...)
@@ -62,6 +64,7 @@ func NewServer(opt ...Option) (*Server, error) {
 		readTimeout:          opts.withReadTimeout,
 		disablePanicRecovery: opts.withDisablePanicRecovery,
 		onCloseHandler:       opts.withOnClose,
+		maxPagingCursors:     opts.withMaxPagingCursors,
 	}, nil
 }
 
@@ -190,6 +193,7 @@ func (s *Server) Run(addr string, opt ...Option) error {
 		if err != nil {
 			return fmt.Errorf("%s: unable to create in-memory conn: %w", op, err)
 		}
+		conn.maxPagingCursors = s.maxPagingCursors
 		localConnID := connID
 		s.connWg.Add(1)
 		go func() {
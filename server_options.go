@@ -17,6 +17,7 @@ type configOptions struct {
 	withWriteTimeout         time.Duration
 	withDisablePanicRecovery bool
 	withOnClose              OnCloseHandler
+	withMaxPagingCursors     int
 }
 
 func configDefaults() configOptions {
@@ -94,3 +95,15 @@ func WithOnClose(handler OnCloseHandler) Option {
 		}
 	}
 }
+
+// WithMaxPagingCursors sets the maximum number of paged search cursors stored
+// per connection (see: Request.NextPage).  The least recently stored cursor is
+// evicted when a connection has the maximum number of cursors.  The default is
+// 10.
+func WithMaxPagingCursors(max int) Option {
+	return func(o interface{}) {
+		if o, ok := o.(*configOptions); ok {
+			o.withMaxPagingCursors = max
+		}
+	}
+}
@@ -61,6 +61,15 @@ func Test_WithDisablePanicRecovery(t *testing.T) {
 	assert.Equal(opts, testOpts)
 }
 
+func Test_WithMaxPagingCursors(t *testing.T) {
+	t.Parallel()
+	assert := assert.New(t)
+	opts := getConfigOpts(WithMaxPagingCursors(5))
+	testOpts := configDefaults()
+	testOpts.withMaxPagingCursors = 5
+	assert.Equal(opts, testOpts)
+}
+
 func Test_WitOnClose(t *testing.T) {
 	t.Parallel()
 	fn := func(int) {}
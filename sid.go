@@ -7,9 +7,203 @@ import (
 	"bytes"
 	"encoding/binary"
 	"fmt"
+	"strconv"
+	"strings"
 )
 
-// SIDBytes creates a SID from the provided revision and identifierAuthority
+const (
+	// sidMaxSubAuthorities is the max number of sub-authorities in a SID
+	sidMaxSubAuthorities = 15
+
+	// sidMaxIdentifierAuthority is the max identifier authority, which is a
+	// 48-bit value
+	sidMaxIdentifierAuthority = 1<<48 - 1
+
+	// sidHeaderLen is the length of a SID's revision, sub-authority count and
+	// identifier authority
+	sidHeaderLen = 8
+)
+
+// SID is a Windows security identifier (for example:
+// "S-1-5-21-2127521184-1604012920-1887927527-72713"), which is used for
+// objectSid and tokenGroups values.
+// see: https://learn.microsoft.com/en-us/openspecs/windows_protocols/ms-dtyp/78eb9013-1c3a-4970-ad1f-2b1dad588a25
+type SID struct {
+	// Revision of the SID, which is always 1 for Windows SIDs
+	Revision uint8
+	// IdentifierAuthority is the SID's 48-bit authority (for example: 5 for
+	// the NT authority)
+	IdentifierAuthority uint64
+	// SubAuthorities are the SID's sub-authorities, and the last one is the
+	// relative ID (RID) of a user or group SID
+	SubAuthorities []uint32
+}
+
+// NewSID creates a SID.  At most 15 sub-authorities are supported.
+func NewSID(revision uint8, identifierAuthority uint64, subAuthorities ...uint32) (*SID, error) {
+	const op = "gldap.NewSID"
+	if identifierAuthority > sidMaxIdentifierAuthority {
+		return nil, fmt.Errorf("%s: identifier authority %d is larger than 48 bits: %w", op, identifierAuthority, ErrInvalidParameter)
+	}
+	if len(subAuthorities) > sidMaxSubAuthorities {
+		return nil, fmt.Errorf("%s: %d sub-authorities is more than the max of %d: %w", op, len(subAuthorities), sidMaxSubAuthorities, ErrInvalidParameter)
+	}
+	return &SID{
+		Revision:            revision,
+		IdentifierAuthority: identifierAuthority,
+		SubAuthorities:      append([]uint32{}, subAuthorities...),
+	}, nil
+}
+
+// ParseSID parses a SID's string form (for example: "S-1-5-21-1-2-3-1104").
+// The identifier authority may be decimal or hex (for example: "S-1-0x5-32").
+func ParseSID(s string) (*SID, error) {
+	const op = "gldap.ParseSID"
+	parts := strings.Split(s, "-")
+	if len(parts) < 3 || !strings.EqualFold(parts[0], "S") {
+		return nil, fmt.Errorf("%s: invalid SID %q: %w", op, s, ErrInvalidParameter)
+	}
+	revision, err := strconv.ParseUint(parts[1], 10, 8)
+	if err != nil {
+		return nil, fmt.Errorf("%s: invalid SID %q revision: %w", op, s, ErrInvalidParameter)
+	}
+	var identifierAuthority uint64
+	switch authority := parts[2]; {
+	case strings.HasPrefix(authority, "0x"), strings.HasPrefix(authority, "0X"):
+		identifierAuthority, err = strconv.ParseUint(authority[2:], 16, 48)
+	default:
+		identifierAuthority, err = strconv.ParseUint(authority, 10, 48)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: invalid SID %q identifier authority: %w", op, s, ErrInvalidParameter)
+	}
+	subAuthorities := make([]uint32, 0, len(parts)-3)
+	for _, p := range parts[3:] {
+		subAuthority, err := strconv.ParseUint(p, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("%s: invalid SID %q sub-authority %q: %w", op, s, p, ErrInvalidParameter)
+		}
+		subAuthorities = append(subAuthorities, uint32(subAuthority))
+	}
+	sid, err := NewSID(uint8(revision), identifierAuthority, subAuthorities...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return sid, nil
+}
+
+// ParseSIDBytes parses a SID's binary form (for example: an objectSid value)
+func ParseSIDBytes(b []byte) (*SID, error) {
+	const op = "gldap.ParseSIDBytes"
+	if len(b) < sidHeaderLen {
+		return nil, fmt.Errorf("%s: SID is %d bytes and must be at least %d bytes: %w", op, len(b), sidHeaderLen, ErrInvalidParameter)
+	}
+	subAuthorityCount := int(b[1])
+	if subAuthorityCount > sidMaxSubAuthorities {
+		return nil, fmt.Errorf("%s: %d sub-authorities is more than the max of %d: %w", op, subAuthorityCount, sidMaxSubAuthorities, ErrInvalidParameter)
+	}
+	if wantLen := sidHeaderLen + 4*subAuthorityCount; len(b) != wantLen {
+		return nil, fmt.Errorf("%s: SID with %d sub-authorities must be %d bytes and got %d: %w", op, subAuthorityCount, wantLen, len(b), ErrInvalidParameter)
+	}
+	sid := &SID{
+		Revision:       b[0],
+		SubAuthorities: make([]uint32, 0, subAuthorityCount),
+	}
+	// the identifier authority is a 48-bit big-endian value
+	for _, v := range b[2:sidHeaderLen] {
+		sid.IdentifierAuthority = sid.IdentifierAuthority<<8 | uint64(v)
+	}
+	for i := 0; i < subAuthorityCount; i++ {
+		offset := sidHeaderLen + 4*i
+		sid.SubAuthorities = append(sid.SubAuthorities, binary.LittleEndian.Uint32(b[offset:offset+4]))
+	}
+	return sid, nil
+}
+
+// Bytes returns the SID's binary form (for example: an objectSid value)
+func (s *SID) Bytes() []byte {
+	b := make([]byte, sidHeaderLen, sidHeaderLen+4*len(s.SubAuthorities))
+	b[0] = s.Revision
+	b[1] = uint8(len(s.SubAuthorities))
+	// the identifier authority is a 48-bit big-endian value
+	for i := 0; i < 6; i++ {
+		b[sidHeaderLen-1-i] = byte(s.IdentifierAuthority >> (8 * i))
+	}
+	for _, subAuthority := range s.SubAuthorities {
+		b = binary.LittleEndian.AppendUint32(b, subAuthority)
+	}
+	return b
+}
+
+// String returns the SID's string form (for example: "S-1-5-32-544").
+// Identifier authorities which don't fit in 32 bits are written in hex.
+func (s *SID) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "S-%d-", s.Revision)
+	if s.IdentifierAuthority >= 1<<32 {
+		fmt.Fprintf(&b, "0x%012X", s.IdentifierAuthority)
+	} else {
+		fmt.Fprintf(&b, "%d", s.IdentifierAuthority)
+	}
+	for _, subAuthority := range s.SubAuthorities {
+		fmt.Fprintf(&b, "-%d", subAuthority)
+	}
+	return b.String()
+}
+
+// RID returns the SID's relative ID, which is its last sub-authority.  It
+// returns false when the SID doesn't have any sub-authorities.
+func (s *SID) RID() (uint32, bool) {
+	if len(s.SubAuthorities) == 0 {
+		return 0, false
+	}
+	return s.SubAuthorities[len(s.SubAuthorities)-1], true
+}
+
+// Domain returns the SID of the SID's domain, which is the SID without its
+// RID (for example: "S-1-5-21-1-2-3" for "S-1-5-21-1-2-3-1104").  It returns
+// nil when the SID doesn't have any sub-authorities.
+func (s *SID) Domain() *SID {
+	if len(s.SubAuthorities) == 0 {
+		return nil
+	}
+	return &SID{
+		Revision:            s.Revision,
+		IdentifierAuthority: s.IdentifierAuthority,
+		SubAuthorities:      append([]uint32{}, s.SubAuthorities[:len(s.SubAuthorities)-1]...),
+	}
+}
+
+// WithRID returns a new SID within the SID's domain for the relative ID (for
+// example: a user or group SID for a domain SID).  It returns an error when
+// the new SID would have too many sub-authorities.
+func (s *SID) WithRID(rid uint32) (*SID, error) {
+	const op = "gldap.(SID).WithRID"
+	sid, err := NewSID(s.Revision, s.IdentifierAuthority, append(append([]uint32{}, s.SubAuthorities...), rid)...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return sid, nil
+}
+
+// BaseDN returns the SID's base DN form (for example:
+// "<SID=S-1-5-21-1-2-3-1104>"), which can be used as the base DN of a search
+// request.  See: ParseSIDBaseDN
+func (s *SID) BaseDN() string {
+	return fmt.Sprintf("<SID=%s>", s)
+}
+
+// Equal returns true when the SIDs are equal
+func (s *SID) Equal(other *SID) bool {
+	if s == nil || other == nil {
+		return s == other
+	}
+	return bytes.Equal(s.Bytes(), other.Bytes())
+}
+
+// SIDBytes creates a SID from the provided revision and identifierAuthority,
+// without any sub-authorities.  See SID for building SIDs with
+// sub-authorities.
 func SIDBytes(revision uint8, identifierAuthority uint16) ([]byte, error) {
 	const op = "gldap.SidBytes"
 	var identifierAuthorityParts [3]uint16
@@ -6,6 +6,7 @@ package gldap
 import (
 	"testing"
 
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
@@ -28,3 +29,181 @@ func Test_SIDBytesToString(t *testing.T) {
 		}
 	}
 }
+
+func TestParseSID(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name            string
+		sid             string
+		want            *SID
+		wantBytes       []byte
+		wantString      string
+		wantErrContains string
+	}{
+		{
+			name: "user",
+			sid:  "S-1-5-21-2127521184-1604012920-1887927527-72713",
+			want: &SID{
+				Revision:            1,
+				IdentifierAuthority: 5,
+				SubAuthorities:      []uint32{21, 2127521184, 1604012920, 1887927527, 72713},
+			},
+			wantBytes: []byte{0x01, 0x05, 0x00, 0x00, 0x00, 0x00, 0x00, 0x05, 0x15, 0x00, 0x00, 0x00, 0xA0, 0x65, 0xCF, 0x7E, 0x78, 0x4B, 0x9B, 0x5F, 0xE7, 0x7C, 0x87, 0x70, 0x09, 0x1C, 0x01, 0x00},
+		},
+		{
+			name:      "everyone",
+			sid:       "S-1-1-0",
+			want:      &SID{Revision: 1, IdentifierAuthority: 1, SubAuthorities: []uint32{0}},
+			wantBytes: []byte{0x01, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00},
+		},
+		{
+			name:      "no-sub-authorities",
+			sid:       "S-1-5",
+			want:      &SID{Revision: 1, IdentifierAuthority: 5, SubAuthorities: []uint32{}},
+			wantBytes: []byte{0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x05},
+		},
+		{
+			name:       "hex-authority",
+			sid:        "S-1-0x5-32-544",
+			want:       &SID{Revision: 1, IdentifierAuthority: 5, SubAuthorities: []uint32{32, 544}},
+			wantBytes:  []byte{0x01, 0x02, 0x00, 0x00, 0x00, 0x00, 0x00, 0x05, 0x20, 0x00, 0x00, 0x00, 0x20, 0x02, 0x00, 0x00},
+			wantString: "S-1-5-32-544",
+		},
+		{
+			name:      "large-authority",
+			sid:       "S-1-0x0102030405FF-1",
+			want:      &SID{Revision: 1, IdentifierAuthority: 0x0102030405FF, SubAuthorities: []uint32{1}},
+			wantBytes: []byte{0x01, 0x01, 0x01, 0x02, 0x03, 0x04, 0x05, 0xFF, 0x01, 0x00, 0x00, 0x00},
+		},
+		{
+			name:            "missing-prefix",
+			sid:             "X-1-5",
+			wantErrContains: "invalid SID",
+		},
+		{
+			name:            "too-short",
+			sid:             "S-1",
+			wantErrContains: "invalid SID",
+		},
+		{
+			name:            "invalid-revision",
+			sid:             "S-256-5",
+			wantErrContains: "revision",
+		},
+		{
+			name:            "invalid-authority",
+			sid:             "S-1-281474976710656",
+			wantErrContains: "identifier authority",
+		},
+		{
+			name:            "invalid-sub-authority",
+			sid:             "S-1-5-4294967296",
+			wantErrContains: "sub-authority",
+		},
+		{
+			name:            "too-many-sub-authorities",
+			sid:             "S-1-5-1-2-3-4-5-6-7-8-9-10-11-12-13-14-15-16",
+			wantErrContains: "16 sub-authorities is more than the max of 15",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert, require := assert.New(t), require.New(t)
+			got, err := ParseSID(tc.sid)
+			if tc.wantErrContains != "" {
+				require.Error(err)
+				assert.Nil(got)
+				assert.ErrorIs(err, ErrInvalidParameter)
+				assert.Contains(err.Error(), tc.wantErrContains)
+				return
+			}
+			require.NoError(err)
+			assert.Equal(tc.want, got)
+			assert.Equal(tc.wantBytes, got.Bytes())
+
+			wantString := tc.wantString
+			if wantString == "" {
+				wantString = tc.sid
+			}
+			assert.Equal(wantString, got.String())
+
+			fromBytes, err := ParseSIDBytes(got.Bytes())
+			require.NoError(err)
+			assert.True(got.Equal(fromBytes))
+			assert.Equal(wantString, fromBytes.String())
+
+			// the existing string conversion agrees with the SID type
+			converted, err := SIDBytesToString(got.Bytes())
+			require.NoError(err)
+			if got.IdentifierAuthority < 1<<32 {
+				assert.Equal(wantString, converted)
+			}
+		})
+	}
+}
+
+func TestParseSIDBytes(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name            string
+		b               []byte
+		wantErrContains string
+	}{
+		{
+			name:            "too-short",
+			b:               []byte{0x01, 0x00, 0x00},
+			wantErrContains: "SID is 3 bytes and must be at least 8 bytes",
+		},
+		{
+			name:            "too-many-sub-authorities",
+			b:               []byte{0x01, 0x10, 0x00, 0x00, 0x00, 0x00, 0x00, 0x05},
+			wantErrContains: "16 sub-authorities is more than the max of 15",
+		},
+		{
+			name:            "missing-sub-authority",
+			b:               []byte{0x01, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x05, 0x20, 0x00},
+			wantErrContains: "SID with 1 sub-authorities must be 12 bytes and got 10",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert, require := assert.New(t), require.New(t)
+			got, err := ParseSIDBytes(tc.b)
+			require.Error(err)
+			assert.Nil(got)
+			assert.ErrorIs(err, ErrInvalidParameter)
+			assert.Contains(err.Error(), tc.wantErrContains)
+		})
+	}
+}
+
+func TestSID_domain(t *testing.T) {
+	t.Parallel()
+	assert, require := assert.New(t), require.New(t)
+
+	domain, err := NewSID(1, 5, 21, 2127521184, 1604012920, 1887927527)
+	require.NoError(err)
+	_, ok := (&SID{Revision: 1, IdentifierAuthority: 5}).RID()
+	assert.False(ok)
+	assert.Nil((&SID{Revision: 1, IdentifierAuthority: 5}).Domain())
+
+	user, err := domain.WithRID(1104)
+	require.NoError(err)
+	assert.Equal("S-1-5-21-2127521184-1604012920-1887927527-1104", user.String())
+	rid, ok := user.RID()
+	assert.True(ok)
+	assert.Equal(uint32(1104), rid)
+	assert.True(user.Domain().Equal(domain))
+	assert.False(user.Equal(domain))
+	assert.Len(domain.SubAuthorities, 4, "WithRID must not modify the domain SID")
+
+	full, err := NewSID(1, 5, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15)
+	require.NoError(err)
+	_, err = full.WithRID(16)
+	require.Error(err)
+	assert.ErrorIs(err, ErrInvalidParameter)
+
+	_, err = NewSID(1, 1<<48)
+	require.Error(err)
+	assert.ErrorIs(err, ErrInvalidParameter)
+}
@@ -5,8 +5,10 @@ package testdirectory
 
 import (
 	"bytes"
+	"crypto/rand"
 	"crypto/tls"
 	"crypto/x509"
+	"encoding/hex"
 	"encoding/pem"
 	"fmt"
 	"regexp"
@@ -50,10 +52,16 @@ const (
 // test ldap operations are supported:
 //
 //   - Bind
+//   - SASL EXTERNAL Bind (mTLS client certificates)
 //   - StartTLS
+//   - Password Modify
+//   - WhoAmI
 //   - Search
 //   - Modify
 //   - Add
+//   - Delete
+//   - Compare
+//   - ModifyDN
 //
 // Making requests to the Directory is facilitated by:
 //   - Directory.Conn()		returns a *ldap.Conn connected to the Directory (honors WithMTLS options from start)
@@ -78,6 +86,10 @@ type Directory struct {
 	allowAnonymousBind bool
 	controls           []gldap.Control
 
+	// evaluateFilters enables evaluating search filters against the
+	// attributes of entries (see: WithEvaluateFilters)
+	evaluateFilters bool
+
 	// userDN is the base distinguished name to use when searching for users
 	userDN string
 	// groupDN is the base distinguished name to use when searching for groups
@@ -86,7 +98,7 @@ type Directory struct {
 
 // Start creates and starts a running Directory ldap server.
 // Support options: WithPort, WithMTLS, WithNoTLS, WithDefaults,
-// WithLogger.
+// WithLogger, WithEvaluateFilters.
 //
 // The Directory will be shutdown when the test and all its
 // subtests are compted via a registered function with t.Cleanup(...)
@@ -110,6 +122,7 @@ func Start(t TestingT, opt ...Option) *Directory {
 		userDN:             opts.withDefaults.UserDN,
 		groupDN:            opts.withDefaults.GroupDN,
 		allowAnonymousBind: opts.withDefaults.AllowAnonymousBind,
+		evaluateFilters:    opts.withEvaluateFilters,
 	}
 
 	var err error
@@ -129,13 +142,18 @@ func Start(t TestingT, opt ...Option) *Directory {
 	require.NoError(err)
 	require.NoError(mux.DefaultRoute(d.handleNotFound(t)))
 	require.NoError(mux.Bind(d.handleBind(t)))
+	require.NoError(mux.SASLBind(gldap.SASLMechanismExternal, d.handleSASLExternal(t), gldap.WithLabel("SASL External Bind")))
 	require.NoError(mux.ExtendedOperation(d.handleStartTLS(t), gldap.ExtendedOperationStartTLS))
+	require.NoError(mux.ExtendedOperation(d.handlePasswordModify(t), gldap.ExtendedOperationPasswordModify, gldap.WithLabel("Password Modify")))
+	require.NoError(mux.ExtendedOperation(gldap.NewWhoAmIHandler(), gldap.ExtendedOperationWhoAmI, gldap.WithLabel("WhoAmI")))
 	require.NoError(mux.Search(d.handleSearchUsers(t), gldap.WithBaseDN(d.userDN), gldap.WithLabel("Search - Users")))
 	require.NoError(mux.Search(d.handleSearchGroups(t), gldap.WithBaseDN(d.groupDN), gldap.WithLabel("Search - Groups")))
 	require.NoError(mux.Search(d.handleSearchGeneric(t), gldap.WithLabel("Search - Generic")))
 	require.NoError(mux.Modify(d.handleModify(t), gldap.WithLabel("Modify")))
 	require.NoError(mux.Add(d.handleAdd(t), gldap.WithLabel("Add")))
 	require.NoError(mux.Delete(d.handleDelete(t), gldap.WithLabel("Delete")))
+	require.NoError(mux.Compare(d.handleCompare(t), gldap.WithLabel("Compare")))
+	require.NoError(mux.ModifyDN(d.handleModifyDN(t), gldap.WithLabel("ModifyDN")))
 
 	require.NoError(d.s.Router(mux))
 
@@ -237,6 +255,42 @@ func (d *Directory) handleBind(t TestingT) func(w *gldap.ResponseWriter, r *glda
 	}
 }
 
+// handleSASLExternal supports SASL EXTERNAL binds for connections with a
+// verified client certificate (see WithMTLS). The certificate is mapped to the
+// user with an "email" attribute matching one of the certificate's email
+// addresses.
+func (d *Directory) handleSASLExternal(t TestingT) func(w *gldap.ResponseWriter, r *gldap.Request) {
+	const op = "testdirectory.(Directory).handleSASLExternal"
+	if v, ok := interface{}(t).(HelperT); ok {
+		v.Helper()
+	}
+	h, err := gldap.NewSASLExternalCertHandler(d.mapClientCert)
+	require.NoError(t, err)
+	return func(w *gldap.ResponseWriter, r *gldap.Request) {
+		d.logger.Debug(op)
+		h(w, r)
+	}
+}
+
+// mapClientCert maps a client certificate chain to the "dn:" authorization
+// identity of the user with a matching email address
+func (d *Directory) mapClientCert(chain []*x509.Certificate) (string, error) {
+	const op = "testdirectory.(Directory).mapClientCert"
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for _, email := range chain[0].EmailAddresses {
+		for _, u := range d.users {
+			for _, v := range u.GetAttributeValues("email") {
+				if strings.EqualFold(v, email) {
+					d.logger.Debug("found client certificate user", "op", op, "DN", u.DN)
+					return "dn:" + u.DN, nil
+				}
+			}
+		}
+	}
+	return "", fmt.Errorf("%s: no user found for certificate %q", op, chain[0].Subject.String())
+}
+
 func (d *Directory) handleNotFound(t TestingT) func(w *gldap.ResponseWriter, r *gldap.Request) {
 	const op = "testdirectory.(Directory).handleNotFound"
 	if v, ok := interface{}(t).(HelperT); ok {
@@ -279,6 +333,77 @@ func (d *Directory) handleStartTLS(t TestingT) func(w *gldap.ResponseWriter, r *
 	}
 }
 
+// handlePasswordModify supports password modify requests for a user's
+// "password" attribute.  The connection's bound user is modified when the
+// request doesn't include a user identity and a password is generated when the
+// request doesn't include a new password.
+func (d *Directory) handlePasswordModify(t TestingT) func(w *gldap.ResponseWriter, r *gldap.Request) {
+	const op = "testdirectory.(Directory).handlePasswordModify"
+	if v, ok := interface{}(t).(HelperT); ok {
+		v.Helper()
+	}
+	return func(w *gldap.ResponseWriter, r *gldap.Request) {
+		d.logger.Debug(op)
+		res := r.NewPasswordModifyResponse(gldap.WithResponseCode(gldap.ResultNoSuchObject))
+		defer func() {
+			if err := w.Write(res); err != nil {
+				d.logger.Error("error writing response", "op", op, "err", err)
+			}
+		}()
+		m, err := r.GetPasswordModifyMessage()
+		if err != nil {
+			d.logger.Error("not a password modify message", "op", op, "err", err)
+			res.SetResultCode(gldap.ResultProtocolError)
+			return
+		}
+		userIdentity := m.UserIdentity
+		if userIdentity == "" {
+			userIdentity = r.BoundDN()
+		}
+		if userIdentity == "" {
+			res.SetResultCode(gldap.ResultUnwillingToPerform)
+			res.SetDiagnosticMessage("missing user identity")
+			return
+		}
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		for _, u := range d.users {
+			if u.DN != userIdentity {
+				continue
+			}
+			d.logger.Debug("found password modify user", "op", op, "DN", u.DN)
+			values := u.GetAttributeValues("password")
+			if m.OldPassword != "" && (len(values) == 0 || string(m.OldPassword) != values[0]) {
+				res.SetResultCode(gldap.ResultInvalidCredentials)
+				return
+			}
+			newPassword := string(m.NewPassword)
+			if newPassword == "" {
+				b := make([]byte, 16)
+				if _, err := rand.Read(b); err != nil {
+					d.logger.Error("unable to generate password", "op", op, "err", err)
+					res.SetResultCode(gldap.ResultOperationsError)
+					return
+				}
+				newPassword = hex.EncodeToString(b)
+				res.SetGeneratedPassword(gldap.Password(newPassword))
+			}
+			replaced := false
+			for i, a := range u.Attributes {
+				if a.Name == "password" {
+					u.Attributes[i] = gldap.NewEntryAttribute("password", []string{newPassword})
+					replaced = true
+				}
+			}
+			if !replaced {
+				u.Attributes = append(u.Attributes, gldap.NewEntryAttribute("password", []string{newPassword}))
+			}
+			res.SetResultCode(gldap.ResultSuccess)
+			return
+		}
+	}
+}
+
 func (d *Directory) handleSearchGeneric(t TestingT) func(w *gldap.ResponseWriter, r *gldap.Request) {
 	const op = "testdirectory.(Directory).handleSearchGeneric"
 	if v, ok := interface{}(t).(HelperT); ok {
@@ -319,16 +444,11 @@ func (d *Directory) handleSearchGeneric(t TestingT) func(w *gldap.ResponseWriter
 		var foundEntries int
 
 		// if our search base is a SID, then we're searching for tokenGroups
-		if len(d.tokenGroups) > 0 && strings.HasPrefix(string(m.BaseDN), "<SID=") {
-			sid := string(m.BaseDN)
-			sid = strings.TrimPrefix(sid, "<SID=")
-			sid = strings.TrimSuffix(sid, ">")
-			for _, g := range d.tokenGroups[sid] {
+		if tokenGroups, ok := d.findTokenGroups(string(m.BaseDN)); ok {
+			for _, g := range tokenGroups {
 				d.logger.Debug("found tokenGroup", "op", op, "group DN", g.DN)
 				result := r.NewSearchResponseEntry(g.DN)
-				for _, attr := range g.Attributes {
-					result.AddAttribute(attr.Name, attr.Values)
-				}
+				addEntryAttributes(result, g.Attributes)
 				foundEntries += 1
 				err = w.Write(result)
 				if err != nil {
@@ -344,14 +464,14 @@ func (d *Directory) handleSearchGeneric(t TestingT) func(w *gldap.ResponseWriter
 		d.logger.Debug("filter", "op", op, "value", filter)
 		var entries []*gldap.Entry
 		for _, e := range d.users {
-			if ok, _ := match(filter, e.DN); !ok {
+			if !d.matchSearch(m, filter, e) {
 				continue
 			}
 			entries = append(entries, e)
 			foundEntries += 1
 		}
 		for _, e := range d.groups {
-			if ok, _ := match(filter, e.DN); !ok {
+			if !d.matchSearch(m, filter, e) {
 				continue
 			}
 			switch {
@@ -366,9 +486,7 @@ func (d *Directory) handleSearchGeneric(t TestingT) func(w *gldap.ResponseWriter
 			d.logger.Debug("found entries", "op", op, "count", foundEntries)
 			for _, e := range entries {
 				result := r.NewSearchResponseEntry(e.DN)
-				for _, attr := range e.Attributes {
-					result.AddAttribute(attr.Name, attr.Values)
-				}
+				addEntryAttributes(result, e.Attributes)
 				foundEntries += 1
 				err := w.Write(result)
 				if err != nil {
@@ -408,11 +526,16 @@ func (d *Directory) handleSearchGroups(t TestingT) func(w *gldap.ResponseWriter,
 		}
 		d.logSearchRequest(m)
 
-		_, entries := d.findMembers(m.Filter)
+		var entries []*gldap.Entry
+		if !d.evaluateFilters {
+			// an evaluated filter matches a group's member attribute
+			// directly
+			_, entries = d.findMembers(m.Filter)
+		}
 		foundEntries := len(entries)
 
 		for _, e := range d.groups {
-			if ok, _ := match(m.Filter, e.DN); !ok {
+			if !d.matchSearch(m, m.Filter, e) {
 				continue
 			}
 			switch {
@@ -427,9 +550,7 @@ func (d *Directory) handleSearchGroups(t TestingT) func(w *gldap.ResponseWriter,
 		if foundEntries > 0 {
 			for _, e := range entries {
 				result := r.NewSearchResponseEntry(e.DN)
-				for _, attr := range e.Attributes {
-					result.AddAttribute(attr.Name, attr.Values)
-				}
+				addEntryAttributes(result, e.Attributes)
 				foundEntries += 1
 				err = w.Write(result)
 				if err != nil {
@@ -472,15 +593,18 @@ func (d *Directory) handleSearchUsers(t TestingT) func(w *gldap.ResponseWriter,
 		d.logSearchRequest(m)
 
 		var foundEntries int
-		_, _, entries := find(d.t, m.Filter, d.users)
+		var entries []*gldap.Entry
+		for _, e := range d.users {
+			if d.matchSearch(m, m.Filter, e) {
+				entries = append(entries, e)
+			}
+		}
 		if len(entries) == 0 {
 			return
 		}
 		for _, e := range entries {
 			result := r.NewSearchResponseEntry(e.DN)
-			for _, attr := range e.Attributes {
-				result.AddAttribute(attr.Name, attr.Values)
-			}
+			addEntryAttributes(result, e.Attributes)
 			foundEntries += 1
 			err := w.Write(result)
 			if err != nil {
@@ -526,7 +650,7 @@ func (d *Directory) handleModify(t TestingT) func(w *gldap.ResponseWriter, r *gl
 		var entries []*gldap.Entry
 		_, _, entries = find(d.t, fmt.Sprintf("(%s)", m.DN), d.users)
 		if len(entries) == 0 {
-			_, _, entries = find(d.t, m.DN, d.groups)
+			_, _, entries = find(d.t, fmt.Sprintf("(%s)", m.DN), d.groups)
 		}
 		if len(entries) == 0 {
 			return
@@ -671,6 +795,168 @@ func (d *Directory) handleDelete(t TestingT) func(w *gldap.ResponseWriter, r *gl
 	}
 }
 
+func (d *Directory) handleCompare(t TestingT) func(w *gldap.ResponseWriter, r *gldap.Request) {
+	const op = "testdirectory.(Directory).handleCompare"
+	if v, ok := interface{}(t).(HelperT); ok {
+		v.Helper()
+	}
+	return func(w *gldap.ResponseWriter, r *gldap.Request) {
+		d.logger.Debug(op)
+		res := r.NewCompareResponse(gldap.WithResponseCode(gldap.ResultNoSuchObject))
+		defer func() {
+			err := w.Write(res)
+			if err != nil {
+				d.logger.Error("error writing response: %s", "op", op, "err", err)
+				return
+			}
+		}()
+		m, err := r.GetCompareMessage()
+		if err != nil {
+			d.logger.Error("not a compare message: %s", "op", op, "err", err)
+			return
+		}
+		d.logger.Info("compare request", "dn", m.DN, "attribute", m.Assertion.AttributeDesc)
+
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		var found *gldap.Entry
+		for _, e := range append(append([]*gldap.Entry{}, d.users...), d.groups...) {
+			if strings.EqualFold(e.DN, m.DN) {
+				found = e
+				break
+			}
+		}
+		if found == nil {
+			return
+		}
+		for _, a := range found.Attributes {
+			if !strings.EqualFold(a.Name, m.Assertion.AttributeDesc) {
+				continue
+			}
+			for _, v := range a.Values {
+				if strings.EqualFold(v, m.Assertion.AssertionValue) {
+					res.SetCompareResult(true)
+					return
+				}
+			}
+			res.SetCompareResult(false)
+			return
+		}
+		res.SetResultCode(gldap.ResultNoSuchAttribute)
+	}
+}
+
+func (d *Directory) handleModifyDN(t TestingT) func(w *gldap.ResponseWriter, r *gldap.Request) {
+	const op = "testdirectory.(Directory).handleModifyDN"
+	if v, ok := interface{}(t).(HelperT); ok {
+		v.Helper()
+	}
+	return func(w *gldap.ResponseWriter, r *gldap.Request) {
+		d.logger.Debug(op)
+		res := r.NewModifyDNResponse(gldap.WithResponseCode(gldap.ResultNoSuchObject))
+		defer func() {
+			err := w.Write(res)
+			if err != nil {
+				d.logger.Error("error writing response: %s", "op", op, "err", err)
+				return
+			}
+		}()
+		m, err := r.GetModifyDNMessage()
+		if err != nil {
+			d.logger.Error("not a modify DN message: %s", "op", op, "err", err)
+			return
+		}
+		d.logger.Info("modify DN request", "dn", m.DN, "newRDN", m.NewRDN, "newSuperior", m.NewSuperior)
+
+		dn, err := gldap.ParseDN(m.DN)
+		if err != nil || dn.IsEmpty() {
+			res.SetResultCode(gldap.ResultInvalidDNSyntax)
+			res.SetDiagnosticMessage(fmt.Sprintf("invalid DN: %s", m.DN))
+			return
+		}
+		newRDN, err := gldap.ParseDN(m.NewRDN)
+		if err != nil || len(newRDN.RDNs) != 1 {
+			res.SetResultCode(gldap.ResultInvalidDNSyntax)
+			res.SetDiagnosticMessage(fmt.Sprintf("invalid new RDN: %s", m.NewRDN))
+			return
+		}
+		parentDN := dn.Parent().String()
+		if m.NewSuperior != "" {
+			parentDN = m.NewSuperior
+		}
+		newDN := m.NewRDN
+		if parentDN != "" {
+			newDN = fmt.Sprintf("%s,%s", m.NewRDN, parentDN)
+		}
+
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		var found *gldap.Entry
+		for _, e := range append(append([]*gldap.Entry{}, d.users...), d.groups...) {
+			switch {
+			case strings.EqualFold(e.DN, newDN) && !strings.EqualFold(e.DN, m.DN):
+				res.SetResultCode(gldap.ResultEntryAlreadyExists)
+				res.SetDiagnosticMessage(fmt.Sprintf("entry exists for DN: %s", newDN))
+				return
+			case strings.EqualFold(e.DN, m.DN):
+				found = e
+			}
+		}
+		if found == nil {
+			return
+		}
+		if m.DeleteOldRDN {
+			for _, a := range dn.RDNs[0].Attributes {
+				removeAttributeValue(found, a.Type, a.Value)
+			}
+		}
+		for _, a := range newRDN.RDNs[0].Attributes {
+			removeAttributeValue(found, a.Type, a.Value)
+			switch attr := findAttribute(found, a.Type); attr {
+			case nil:
+				found.Attributes = append(found.Attributes, gldap.NewEntryAttribute(a.Type, []string{a.Value}))
+			default:
+				attr.AddValue(a.Value)
+			}
+		}
+		found.DN = newDN
+		res.SetResultCode(gldap.ResultSuccess)
+	}
+}
+
+// findAttribute returns the entry's attribute with the given name (case
+// insensitive) or nil when it's not found.
+func findAttribute(e *gldap.Entry, name string) *gldap.EntryAttribute {
+	for _, a := range e.Attributes {
+		if strings.EqualFold(a.Name, name) {
+			return a
+		}
+	}
+	return nil
+}
+
+// removeAttributeValue removes the value (case insensitive) from the entry's
+// named attribute and removes the attribute when it has no remaining values.
+func removeAttributeValue(e *gldap.Entry, name, value string) {
+	for i, a := range e.Attributes {
+		if !strings.EqualFold(a.Name, name) {
+			continue
+		}
+		values := make([]string, 0, len(a.Values))
+		for _, v := range a.Values {
+			if !strings.EqualFold(v, value) {
+				values = append(values, v)
+			}
+		}
+		if len(values) == 0 {
+			e.Attributes = append(e.Attributes[:i], e.Attributes[i+1:]...)
+			return
+		}
+		e.Attributes[i] = gldap.NewEntryAttribute(a.Name, values)
+		return
+	}
+}
+
 func (d *Directory) findMembers(filter string, opt ...Option) (bool, []*gldap.Entry) {
 	opts := getOpts(d.t, opt...)
 	var matches []*gldap.Entry
@@ -710,6 +996,62 @@ func find(t TestingT, filter string, entries []*gldap.Entry, opt ...Option) (boo
 	return false, nil, nil
 }
 
+// findTokenGroups returns the tokenGroups for a SID base DN (for example:
+// "<SID=S-1-5-21-1-2-3-1104>") and false when the base DN isn't a SID base DN
+// or there are no tokenGroups.  The tokenGroups keys are parsed the same way
+// as the base DN, so a SID in either its string form or its binary form
+// encoded as hex finds the key in either form.
+func (d *Directory) findTokenGroups(baseDN string) ([]*gldap.Entry, bool) {
+	if len(d.tokenGroups) == 0 {
+		return nil, false
+	}
+	sid, err := gldap.ParseSIDBaseDN(baseDN)
+	if err != nil {
+		return nil, false
+	}
+	for key, tokenGroups := range d.tokenGroups {
+		keySID, err := gldap.ParseSIDBaseDN(fmt.Sprintf("<SID=%s>", key))
+		if err == nil && keySID.Equal(sid) {
+			return tokenGroups, true
+		}
+	}
+	return nil, true
+}
+
+// matchSearch returns true when the search matches the entry.  By default,
+// the search filter's values are matched with the entry's DN (see: match).
+// When the directory was started WithEvaluateFilters, the entry must be within
+// the search's base DN and scope, and the search filter is evaluated against
+// the entry's attributes.
+func (d *Directory) matchSearch(m *gldap.SearchMessage, filter string, e *gldap.Entry) bool {
+	if !d.evaluateFilters {
+		ok, _ := match(filter, e.DN)
+		return ok
+	}
+	return inScope(m, e) && m.ParsedFilter != nil && m.ParsedFilter.Match(e)
+}
+
+// inScope returns true when the entry is within the search's base DN and
+// scope.
+func inScope(m *gldap.SearchMessage, e *gldap.Entry) bool {
+	base, err := gldap.ParseDN(string(m.BaseDN))
+	if err != nil {
+		return false
+	}
+	dn, err := gldap.ParseDN(e.DN)
+	if err != nil {
+		return false
+	}
+	switch m.Scope {
+	case gldap.BaseObject:
+		return dn.Equal(base)
+	case gldap.SingleLevel:
+		return dn.IsChildOf(base)
+	default:
+		return dn.Equal(base) || dn.IsDescendantOf(base)
+	}
+}
+
 func match(filter string, attr string) (bool, error) {
 	// TODO: make this actually do something more reasonable with the search
 	// request filter
@@ -878,6 +1220,18 @@ func (d *Directory) SetGroups(groups ...*gldap.Entry) {
 	d.groups = groups
 }
 
+// addEntryAttributes adds the attributes to the search result entry, which
+// preserves the raw values of binary attributes (like objectSid)
+func addEntryAttributes(result *gldap.SearchResponseEntry, attrs []*gldap.EntryAttribute) {
+	for _, attr := range attrs {
+		if len(attr.Values) == 0 && len(attr.ByteValues) > 0 {
+			result.AddBinaryAttribute(attr.Name, attr.ByteValues)
+			continue
+		}
+		result.AddAttribute(attr.Name, attr.Values)
+	}
+}
+
 // SetTokenGroups will set the tokenGroup entries.
 func (d *Directory) SetTokenGroups(tokenGroups map[string][]*gldap.Entry) {
 	if v, ok := interface{}(d.t).(HelperT); ok {
@@ -6,13 +6,13 @@ package testdirectory_test
 import (
 	"crypto/tls"
 	"crypto/x509"
+	"encoding/hex"
 	"fmt"
 	"strings"
 	"sync"
 	"testing"
 	"time"
 
-	ber "github.com/go-asn1-ber/asn1-ber"
 	"github.com/go-ldap/ldap/v3"
 	"github.com/hashicorp/go-hclog"
 	"github.com/jimlambrt/gldap"
@@ -236,6 +236,175 @@ func TestDirectory_SimpleBindResponse(t *testing.T) {
 	}
 }
 
+func TestDirectory_SASLExternalBindResponse(t *testing.T) {
+	t.Parallel()
+	testLogger := hclog.New(&hclog.LoggerOptions{
+		Name:  "TestDirectory_SASLExternalBindResponse-logger",
+		Level: hclog.Error,
+	})
+	// the directory's mTLS client cert has an email of mtls.client@example.com
+	certUsers := testdirectory.NewUsers(t, []string{"mtls.client"})
+	otherUsers := testdirectory.NewUsers(t, []string{"alice"})
+	tests := []struct {
+		name     string
+		users    []*gldap.Entry
+		opts     []testdirectory.Option
+		wantCode uint16
+	}{
+		{
+			name:     "success",
+			users:    certUsers,
+			opts:     []testdirectory.Option{testdirectory.WithMTLS(t)},
+			wantCode: ldap.LDAPResultSuccess,
+		},
+		{
+			name:     "no-matching-user",
+			users:    otherUsers,
+			opts:     []testdirectory.Option{testdirectory.WithMTLS(t)},
+			wantCode: ldap.LDAPResultInvalidCredentials,
+		},
+		{
+			name:     "no-client-cert",
+			users:    certUsers,
+			wantCode: ldap.LDAPResultInvalidCredentials,
+		},
+		{
+			name:     "no-tls",
+			users:    certUsers,
+			opts:     []testdirectory.Option{testdirectory.WithNoTLS(t)},
+			wantCode: ldap.LDAPResultInvalidCredentials,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert, require := assert.New(t), require.New(t)
+			opts := append([]testdirectory.Option{
+				testdirectory.WithDefaults(t, &testdirectory.Defaults{Users: tc.users}),
+				testdirectory.WithLogger(t, testLogger),
+			}, tc.opts...)
+			td := testdirectory.Start(t, opts...)
+			c := td.Conn()
+			defer c.Close()
+
+			err := c.ExternalBind()
+			if tc.wantCode == ldap.LDAPResultSuccess {
+				require.NoError(err)
+				return
+			}
+			require.Error(err)
+			assert.True(ldap.IsErrorWithCode(err, tc.wantCode))
+		})
+	}
+}
+
+func TestDirectory_PasswordModifyResponse(t *testing.T) {
+	t.Parallel()
+	testLogger := hclog.New(&hclog.LoggerOptions{
+		Name:  "TestDirectory_PasswordModifyResponse-logger",
+		Level: hclog.Error,
+	})
+	td := testdirectory.Start(t,
+		testdirectory.WithLogger(t, testLogger),
+	)
+	users := testdirectory.NewUsers(t, []string{"alice", "bob"})
+	td.SetUsers(users...)
+
+	aliceDN := fmt.Sprintf("%s=alice,%s", testdirectory.DefaultUserAttr, testdirectory.DefaultUserDN)
+	tests := []struct {
+		name          string
+		req           *ldap.PasswordModifyRequest
+		wantErr       bool
+		wantErrCode   uint16
+		wantGenerated bool
+		wantPassword  string
+	}{
+		{
+			name:        "missing-user-identity",
+			req:         ldap.NewPasswordModifyRequest("", "password", "new-password"),
+			wantErr:     true,
+			wantErrCode: gldap.ResultUnwillingToPerform,
+		},
+		{
+			name:        "unknown-user",
+			req:         ldap.NewPasswordModifyRequest(fmt.Sprintf("%s=eve,%s", testdirectory.DefaultUserAttr, testdirectory.DefaultUserDN), "", "new-password"),
+			wantErr:     true,
+			wantErrCode: gldap.ResultNoSuchObject,
+		},
+		{
+			name:        "invalid-old-password",
+			req:         ldap.NewPasswordModifyRequest(aliceDN, "invalid-password", "new-password"),
+			wantErr:     true,
+			wantErrCode: gldap.ResultInvalidCredentials,
+		},
+		{
+			name:         "success",
+			req:          ldap.NewPasswordModifyRequest(aliceDN, "password", "new-password"),
+			wantPassword: "new-password",
+		},
+		{
+			name:          "generated-password",
+			req:           ldap.NewPasswordModifyRequest(aliceDN, "new-password", ""),
+			wantGenerated: true,
+		},
+	}
+	// the subtests are not parallel, since each one modifies alice's password
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert, require := assert.New(t), require.New(t)
+			client := td.Conn()
+			defer func() { client.Close() }()
+
+			res, err := client.PasswordModify(tc.req)
+			if tc.wantErr {
+				require.Error(err)
+				assert.True(ldap.IsErrorWithCode(err, tc.wantErrCode))
+				return
+			}
+			require.NoError(err)
+			password := tc.wantPassword
+			if tc.wantGenerated {
+				require.NotEmpty(res.GeneratedPassword)
+				password = res.GeneratedPassword
+			} else {
+				assert.Empty(res.GeneratedPassword)
+			}
+			assert.NoError(client.Bind(aliceDN, password))
+		})
+	}
+}
+
+func TestDirectory_WhoAmIResponse(t *testing.T) {
+	t.Parallel()
+	assert, require := assert.New(t), require.New(t)
+	testLogger := hclog.New(&hclog.LoggerOptions{
+		Name:  "TestDirectory_WhoAmIResponse-logger",
+		Level: hclog.Error,
+	})
+	td := testdirectory.Start(t,
+		testdirectory.WithLogger(t, testLogger),
+	)
+	users := testdirectory.NewUsers(t, []string{"alice", "bob"})
+	td.SetUsers(users...)
+
+	aliceDN := fmt.Sprintf("%s=alice,%s", testdirectory.DefaultUserAttr, testdirectory.DefaultUserDN)
+	client := td.Conn()
+	defer func() { client.Close() }()
+
+	res, err := client.WhoAmI(nil)
+	require.NoError(err)
+	assert.Empty(res.AuthzID)
+
+	require.NoError(client.Bind(aliceDN, "password"))
+	res, err = client.WhoAmI(nil)
+	require.NoError(err)
+	assert.Equal("dn:"+aliceDN, res.AuthzID)
+
+	// password modify requests without a user identity modify the bound user
+	_, err = client.PasswordModify(ldap.NewPasswordModifyRequest("", "password", "new-password"))
+	require.NoError(err)
+	assert.NoError(client.Bind(aliceDN, "new-password"))
+}
+
 func TestDirectory_SearchResponse(t *testing.T) {
 	t.Parallel()
 	testLogger := hclog.New(&hclog.LoggerOptions{
@@ -371,6 +540,238 @@ func TestDirectory_SearchResponse(t *testing.T) {
 	}
 }
 
+func TestDirectory_SearchResponse_evaluateFilters(t *testing.T) {
+	t.Parallel()
+	testLogger := hclog.New(&hclog.LoggerOptions{
+		Name:  "TestDirectory_SearchResponse_evaluateFilters-logger",
+		Level: hclog.Error,
+	})
+
+	td := testdirectory.Start(t,
+		testdirectory.WithLogger(t, testLogger),
+		testdirectory.WithDefaults(t, &testdirectory.Defaults{AllowAnonymousBind: true}),
+		testdirectory.WithEvaluateFilters(t),
+	)
+	groups := []*gldap.Entry{
+		testdirectory.NewGroup(t, "admin", []string{"alice"}),
+		testdirectory.NewGroup(t, "users", []string{"alice", "bob"}),
+	}
+	users := testdirectory.NewUsers(t, []string{"alice", "bob", "eve"})
+	td.SetUsers(users...)
+	td.SetGroups(groups...)
+
+	aliceDN := fmt.Sprintf("%s=alice,%s", testdirectory.DefaultUserAttr, testdirectory.DefaultUserDN)
+	tests := []struct {
+		name            string
+		filter          string
+		baseDN          string
+		scope           int
+		wantEntries     []*gldap.Entry
+		wantErr         bool
+		wantErrContains string
+	}{
+		{
+			name:        "and-filter-found",
+			filter:      "(&(objectClass=person)(name=alice))",
+			baseDN:      testdirectory.DefaultUserDN,
+			scope:       ldap.ScopeWholeSubtree,
+			wantEntries: []*gldap.Entry{users[0]},
+		},
+		{
+			name:        "not-filter-found",
+			filter:      "(!(name=alice))",
+			baseDN:      testdirectory.DefaultUserDN,
+			scope:       ldap.ScopeWholeSubtree,
+			wantEntries: []*gldap.Entry{users[1], users[2]},
+		},
+		{
+			name:        "member-filter-found",
+			filter:      fmt.Sprintf("(&(objectClass=group)(member=%s))", aliceDN),
+			baseDN:      testdirectory.DefaultGroupDN,
+			scope:       ldap.ScopeWholeSubtree,
+			wantEntries: []*gldap.Entry{groups[0], groups[1]},
+		},
+		{
+			name:        "single-level-found",
+			filter:      "(objectClass=group)",
+			baseDN:      testdirectory.DefaultGroupDN,
+			scope:       ldap.ScopeSingleLevel,
+			wantEntries: []*gldap.Entry{groups[0], groups[1]},
+		},
+		{
+			name:        "base-object-found",
+			filter:      "(objectClass=*)",
+			baseDN:      aliceDN,
+			scope:       ldap.ScopeBaseObject,
+			wantEntries: []*gldap.Entry{users[0]},
+		},
+		{
+			name:            "base-object-out-of-scope",
+			filter:          "(objectClass=*)",
+			baseDN:          testdirectory.DefaultUserDN,
+			scope:           ldap.ScopeBaseObject,
+			wantErr:         true,
+			wantErrContains: `LDAP Result Code 32 "No Such Object"`,
+		},
+		{
+			name:            "not-found",
+			filter:          "(name=mallory)",
+			baseDN:          testdirectory.DefaultUserDN,
+			scope:           ldap.ScopeWholeSubtree,
+			wantErr:         true,
+			wantErrContains: `LDAP Result Code 32 "No Such Object"`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert, require := assert.New(t), require.New(t)
+			client := td.Conn()
+			defer func() { client.Close() }()
+			results, err := client.Search(&ldap.SearchRequest{
+				BaseDN:     tc.baseDN,
+				Scope:      tc.scope,
+				Filter:     tc.filter,
+				Attributes: []string{"objectClass", "name", "email", "password", "member"},
+			})
+			if tc.wantErr {
+				require.Error(err)
+				if tc.wantErrContains != "" {
+					assert.Contains(err.Error(), tc.wantErrContains)
+				}
+				return
+			}
+			assert.NoError(err)
+			found := []*gldap.Entry{}
+			for _, e := range results.Entries {
+				attrs := map[string][]string{}
+				for _, a := range e.Attributes {
+					attrs[a.Name] = a.Values
+				}
+				found = append(found, gldap.NewEntry(e.DN, attrs))
+			}
+			assert.Equal(tc.wantEntries, found)
+		})
+	}
+}
+
+func TestDirectory_SearchResponse_SID(t *testing.T) {
+	t.Parallel()
+	testLogger := hclog.New(&hclog.LoggerOptions{
+		Name:  "TestDirectory_SearchResponse_SID-logger",
+		Level: hclog.Error,
+	})
+
+	td := testdirectory.Start(t,
+		testdirectory.WithLogger(t, testLogger),
+		testdirectory.WithDefaults(t, &testdirectory.Defaults{AllowAnonymousBind: true}),
+	)
+	domainSID, err := gldap.ParseSID("S-1-5-21-2127521184-1604012920-1887927527")
+	require.NoError(t, err)
+	userSID, err := domainSID.WithRID(1104)
+	require.NoError(t, err)
+	groupSID, err := domainSID.WithRID(512)
+	require.NoError(t, err)
+
+	usersGroupSID, err := domainSID.WithRID(513)
+	require.NoError(t, err)
+	usersGroupHexSID := hex.EncodeToString(usersGroupSID.Bytes())
+
+	group := testdirectory.NewGroup(t, "admin", []string{"alice"})
+	group.Attributes = append(group.Attributes, gldap.NewEntryAttributeBytes("objectSid", [][]byte{groupSID.Bytes()}))
+	usersGroup := testdirectory.NewGroup(t, "users", []string{"alice"})
+	usersGroup.Attributes = append(usersGroup.Attributes, gldap.NewEntryAttributeBytes("objectSid", [][]byte{usersGroupSID.Bytes()}))
+	td.SetTokenGroups(map[string][]*gldap.Entry{
+		groupSID.String(): {group},
+		// keyed by the SID's hex form, which is found by either form
+		usersGroupHexSID: {usersGroup},
+	})
+
+	users := testdirectory.NewUsers(t, []string{"alice"}, testdirectory.WithTokenGroups(t, groupSID.Bytes()))
+	users[0].Attributes = append(users[0].Attributes, gldap.NewEntryAttributeBytes("objectSid", [][]byte{userSID.Bytes()}))
+	td.SetUsers(users...)
+
+	client := td.Conn()
+	defer func() { client.Close() }()
+
+	t.Run("user", func(t *testing.T) {
+		assert, require := assert.New(t), require.New(t)
+		results, err := client.Search(&ldap.SearchRequest{
+			BaseDN:     testdirectory.DefaultUserDN,
+			Filter:     fmt.Sprintf("(%s=alice,%s)", testdirectory.DefaultUserAttr, testdirectory.DefaultUserDN),
+			Attributes: []string{"objectSid", "tokenGroups"},
+		})
+		require.NoError(err)
+		require.Len(results.Entries, 1)
+
+		gotUserSID, err := gldap.ParseSIDBytes(results.Entries[0].GetRawAttributeValue("objectSid"))
+		require.NoError(err)
+		assert.True(userSID.Equal(gotUserSID))
+		rid, ok := gotUserSID.RID()
+		assert.True(ok)
+		assert.Equal(uint32(1104), rid)
+
+		rawGroups := results.Entries[0].GetRawAttributeValues("tokenGroups")
+		require.Len(rawGroups, 1)
+		gotGroupSID, err := gldap.ParseSIDBytes(rawGroups[0])
+		require.NoError(err)
+		assert.Equal(groupSID.String(), gotGroupSID.String())
+		assert.True(domainSID.Equal(gotGroupSID.Domain()))
+	})
+	tests := []struct {
+		name      string
+		baseDN    string
+		wantGroup *gldap.Entry
+		wantSID   *gldap.SID
+	}{
+		{
+			name:      "token-group",
+			baseDN:    groupSID.BaseDN(),
+			wantGroup: group,
+			wantSID:   groupSID,
+		},
+		{
+			name:      "token-group-hex",
+			baseDN:    "<SID=" + hex.EncodeToString(groupSID.Bytes()) + ">",
+			wantGroup: group,
+			wantSID:   groupSID,
+		},
+		{
+			name:      "token-group-raw-key",
+			baseDN:    "<SID=" + usersGroupHexSID + ">",
+			wantGroup: usersGroup,
+			wantSID:   usersGroupSID,
+		},
+		{
+			name:      "token-group-hex-key-string-form",
+			baseDN:    usersGroupSID.BaseDN(),
+			wantGroup: usersGroup,
+			wantSID:   usersGroupSID,
+		},
+		{
+			name:      "token-group-lowercase-prefix",
+			baseDN:    "<sid=" + groupSID.String() + ">",
+			wantGroup: group,
+			wantSID:   groupSID,
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			assert, require := assert.New(t), require.New(t)
+			results, err := client.Search(&ldap.SearchRequest{
+				BaseDN:     tc.baseDN,
+				Filter:     "(objectClass=*)",
+				Attributes: []string{"objectSid"},
+			})
+			require.NoError(err)
+			require.Len(results.Entries, 1)
+			assert.Equal(tc.wantGroup.DN, results.Entries[0].DN)
+			assert.Equal(tc.wantSID.Bytes(), results.Entries[0].GetRawAttributeValue("objectSid"))
+		})
+	}
+}
+
 func TestDirectory_ModifyResponse(t *testing.T) {
 	t.Parallel()
 	testLogger := hclog.New(&hclog.LoggerOptions{
@@ -414,7 +815,7 @@ func TestDirectory_ModifyResponse(t *testing.T) {
 				DN: users[alice].DN,
 				Attributes: func() []*gldap.EntryAttribute {
 					attrs := append([]*gldap.EntryAttribute{}, users[alice].Attributes...)
-					attrs = append(attrs, gldap.NewEntryAttribute("description", []string{gldap.TestEncodeString(t, ber.TagOctetString, "test-add-attribute")}))
+					attrs = append(attrs, gldap.NewEntryAttribute("description", []string{"test-add-attribute"}))
 					return attrs
 				}(),
 			},
@@ -661,6 +1062,183 @@ func TestGetters(t *testing.T) {
 	assert.Equal([]gldap.Control{ctrl}, td.Controls())
 }
 
+func TestDirectory_CompareResponse(t *testing.T) {
+	t.Parallel()
+	testLogger := hclog.New(&hclog.LoggerOptions{
+		Name:  "TestDirectory_CompareResponse-logger",
+		Level: hclog.Error,
+	})
+	td := testdirectory.Start(t,
+		testdirectory.WithLogger(t, testLogger),
+		testdirectory.WithDefaults(t, &testdirectory.Defaults{AllowAnonymousBind: true}),
+	)
+	users := testdirectory.NewUsers(t, []string{"alice", "bob"})
+	groups := testdirectory.NewGroup(t, "admin", []string{"alice"})
+	td.SetUsers(users...)
+	td.SetGroups(groups)
+
+	tests := []struct {
+		name            string
+		dn              string
+		attribute       string
+		value           string
+		want            bool
+		wantErr         bool
+		wantErrContains string
+	}{
+		{
+			name:      "member-true",
+			dn:        groups.DN,
+			attribute: "member",
+			value:     users[0].DN,
+			want:      true,
+		},
+		{
+			name:      "member-false",
+			dn:        groups.DN,
+			attribute: "member",
+			value:     users[1].DN,
+			want:      false,
+		},
+		{
+			name:      "user-attribute-case-insensitive",
+			dn:        users[1].DN,
+			attribute: "EMAIL",
+			value:     "bob@example.com",
+			want:      true,
+		},
+		{
+			name:            "no-such-attribute",
+			dn:              users[1].DN,
+			attribute:       "description",
+			value:           "bob",
+			wantErr:         true,
+			wantErrContains: "No Such Attribute",
+		},
+		{
+			name:            "not-found",
+			dn:              fmt.Sprintf("%s=%s,%s", testdirectory.DefaultGroupAttr, "not-found", testdirectory.DefaultGroupDN),
+			attribute:       "member",
+			value:           users[0].DN,
+			wantErr:         true,
+			wantErrContains: "No Such Object",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert, require := assert.New(t), require.New(t)
+			client := td.Conn()
+			defer func() { client.Close() }()
+
+			got, err := client.Compare(tc.dn, tc.attribute, tc.value)
+			if tc.wantErr {
+				require.Error(err)
+				if tc.wantErrContains != "" {
+					assert.Contains(err.Error(), tc.wantErrContains)
+				}
+				return
+			}
+			require.NoError(err)
+			assert.Equal(tc.want, got)
+		})
+	}
+}
+
+func TestDirectory_ModifyDNResponse(t *testing.T) {
+	t.Parallel()
+	testLogger := hclog.New(&hclog.LoggerOptions{
+		Name:  "TestDirectory_ModifyDNResponse-logger",
+		Level: hclog.Error,
+	})
+	td := testdirectory.Start(t,
+		testdirectory.WithLogger(t, testLogger),
+		testdirectory.WithDefaults(t, &testdirectory.Defaults{AllowAnonymousBind: true}),
+	)
+	users := testdirectory.NewUsers(t, []string{"alice", "bob", "eve"})
+	users = append(users, gldap.NewEntry(
+		fmt.Sprintf("%s=%s,%s", testdirectory.DefaultUserAttr, `Smith\, John`, testdirectory.DefaultUserDN),
+		map[string][]string{testdirectory.DefaultUserAttr: {"Smith, John"}},
+	))
+	td.SetUsers(users...)
+
+	tests := []struct {
+		name            string
+		dn              string
+		newRDN          string
+		deleteOldRDN    bool
+		newSuperior     string
+		wantDN          string
+		wantRDNValues   []string
+		wantErr         bool
+		wantErrContains string
+	}{
+		{
+			name:          "rename-escaped-comma",
+			dn:            users[3].DN,
+			newRDN:        fmt.Sprintf("%s=%s", testdirectory.DefaultUserAttr, `Smith\, Jane`),
+			deleteOldRDN:  true,
+			wantDN:        fmt.Sprintf("%s=%s,%s", testdirectory.DefaultUserAttr, `Smith\, Jane`, testdirectory.DefaultUserDN),
+			wantRDNValues: []string{"Smith, Jane"},
+		},
+		{
+			name:         "rename",
+			dn:           users[0].DN,
+			newRDN:       fmt.Sprintf("%s=%s", testdirectory.DefaultUserAttr, "alice-smith"),
+			deleteOldRDN: true,
+			wantDN:       fmt.Sprintf("%s=%s,%s", testdirectory.DefaultUserAttr, "alice-smith", testdirectory.DefaultUserDN),
+		},
+		{
+			name:        "move",
+			dn:          users[1].DN,
+			newRDN:      fmt.Sprintf("%s=%s", testdirectory.DefaultUserAttr, "bob"),
+			newSuperior: "ou=admins,dc=example,dc=org",
+			wantDN:      fmt.Sprintf("%s=%s,%s", testdirectory.DefaultUserAttr, "bob", "ou=admins,dc=example,dc=org"),
+		},
+		{
+			name:            "already-exists",
+			dn:              users[2].DN,
+			newRDN:          fmt.Sprintf("%s=%s", testdirectory.DefaultUserAttr, "alice-smith"),
+			wantErr:         true,
+			wantErrContains: "Entry Already Exists",
+		},
+		{
+			name:            "not-found",
+			dn:              fmt.Sprintf("%s=%s,%s", testdirectory.DefaultUserAttr, "joe", testdirectory.DefaultUserDN),
+			newRDN:          fmt.Sprintf("%s=%s", testdirectory.DefaultUserAttr, "joseph"),
+			wantErr:         true,
+			wantErrContains: "No Such Object",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert, require := assert.New(t), require.New(t)
+			client := td.Conn()
+			defer func() { client.Close() }()
+
+			err := client.ModifyDN(ldap.NewModifyDNRequest(tc.dn, tc.newRDN, tc.deleteOldRDN, tc.newSuperior))
+			if tc.wantErr {
+				require.Error(err)
+				if tc.wantErrContains != "" {
+					assert.Contains(err.Error(), tc.wantErrContains)
+				}
+				return
+			}
+			require.NoError(err)
+			var found bool
+			for _, u := range td.Users() {
+				if u.DN == tc.wantDN {
+					found = true
+					if tc.wantRDNValues != nil {
+						assert.Equal(tc.wantRDNValues, u.GetAttributeValues(testdirectory.DefaultUserAttr))
+					}
+				}
+				assert.NotEqual(tc.dn, u.DN)
+			}
+			assert.True(found)
+		})
+	}
+}
+
 func TestDirectory_DeleteResponse(t *testing.T) {
 	t.Parallel()
 	testLogger := hclog.New(&hclog.LoggerOptions{
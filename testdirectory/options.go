@@ -43,6 +43,7 @@ type options struct {
 	withNoTLS                bool
 	withMTLS                 bool
 	withDisablePanicRecovery bool
+	withEvaluateFilters      bool
 	withDefaults             *Defaults
 
 	withMembersOf      []string
@@ -224,3 +225,14 @@ func WithDisablePanicRecovery(t TestingT, disable bool) Option {
 		}
 	}
 }
+
+// WithEvaluateFilters provides the option to evaluate search filters against
+// the attributes of the directory's entries.  By default, the directory only
+// matches the values in a search filter with the DNs of its entries.
+func WithEvaluateFilters(t TestingT) Option {
+	return func(o interface{}) {
+		if o, ok := o.(*options); ok {
+			o.withEvaluateFilters = true
+		}
+	}
+}
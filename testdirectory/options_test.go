@@ -68,6 +68,14 @@ func TestAllOptions(t *testing.T) {
 		testOpts.withDisablePanicRecovery = true
 		assert.Equal(opts, testOpts)
 	})
+	t.Run("WithEvaluateFilters", func(t *testing.T) {
+		assert := assert.New(t)
+		opts := getOpts(t, WithLogger(t, testLogger), WithEvaluateFilters(t))
+		testOpts := defaults(t)
+		testOpts.withLogger = testLogger
+		testOpts.withEvaluateFilters = true
+		assert.Equal(opts, testOpts)
+	})
 }
 
 func Test_applyOpts(t *testing.T) {
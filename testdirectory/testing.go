@@ -39,9 +39,10 @@ func NewUsers(t TestingT, userNames []string, opt ...Option) []*gldap.Entry {
 	entries := make([]*gldap.Entry, 0, len(userNames))
 	for _, n := range userNames {
 		entryAttrs := map[string][]string{
-			"name":     {n},
-			"email":    {fmt.Sprintf("%s@example.com", n)},
-			"password": {"password"},
+			"objectClass": {"top", "person", "organizationalPerson", "user"},
+			"name":        {n},
+			"email":       {fmt.Sprintf("%s@example.com", n)},
+			"password":    {"password"},
 		}
 		if len(opts.withMembersOf) > 0 {
 			entryAttrs["memberOf"] = opts.withMembersOf
@@ -88,7 +89,8 @@ func NewGroup(t TestingT, groupName string, memberNames []string, opt ...Option)
 	return gldap.NewEntry(
 		fmt.Sprintf("%s=%s,%s", opts.withDefaults.GroupAttr, groupName, opts.withDefaults.GroupDN),
 		map[string][]string{
-			"member": members,
+			"objectClass": {"top", "group"},
+			"member":      members,
 		})
 }
 
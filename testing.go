@@ -64,6 +64,25 @@ func testStartTLSRequestPacket(t *testing.T, messageID int) *packet {
 	}
 }
 
+func testExtendedOperationRequestPacket(t *testing.T, m ExtendedOperationMessage) *packet {
+	t.Helper()
+	envelope := testRequestEnvelope(t, int(m.GetID()))
+
+	request := ber.Encode(ber.ClassApplication, ber.TypeConstructed, ApplicationExtendedRequest, nil, "Extended Request")
+	request.AppendChild(ber.NewString(ber.ClassContext, ber.TypePrimitive, 0, string(m.Name), "Request Name"))
+	if m.ByteValue != nil {
+		request.AppendChild(ber.NewString(ber.ClassContext, ber.TypePrimitive, 1, string(m.ByteValue), "Request Value"))
+	}
+	envelope.AppendChild(request)
+	if len(m.Controls) > 0 {
+		envelope.AppendChild(encodeControls(m.Controls))
+	}
+
+	return &packet{
+		Packet: envelope,
+	}
+}
+
 func testSearchRequestPacket(t *testing.T, s SearchMessage) *packet {
 	t.Helper()
 	require := require.New(t)
@@ -116,6 +135,30 @@ func testSimpleBindRequestPacket(t *testing.T, m SimpleBindMessage) *packet {
 	}
 }
 
+func testSASLBindRequestPacket(t *testing.T, m SASLBindMessage) *packet {
+	t.Helper()
+
+	envelope := testRequestEnvelope(t, int(m.GetID()))
+	pkt := ber.Encode(ber.ClassApplication, ber.TypeConstructed, ApplicationBindRequest, nil, "Bind Request")
+	pkt.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagInteger, int64(3), "Version"))
+	pkt.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, m.UserName, "User Name"))
+	sasl := ber.Encode(ber.ClassContext, ber.TypeConstructed, 3, nil, "SASL Credentials")
+	sasl.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, string(m.Mechanism), "Mechanism"))
+	if m.Credentials != nil {
+		sasl.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, string(m.Credentials), "Credentials"))
+	}
+	pkt.AppendChild(sasl)
+	envelope.AppendChild(pkt)
+
+	if len(m.Controls) > 0 {
+		envelope.AppendChild(encodeControls(m.Controls))
+	}
+
+	return &packet{
+		Packet: envelope,
+	}
+}
+
 func testUnbindRequestPacket(t *testing.T, m UnbindMessage) *packet {
 	t.Helper()
 
@@ -128,6 +171,44 @@ func testUnbindRequestPacket(t *testing.T, m UnbindMessage) *packet {
 	}
 }
 
+func testAbandonRequestPacket(t *testing.T, m AbandonMessage) *packet {
+	t.Helper()
+
+	envelope := testRequestEnvelope(t, int(m.GetID()))
+	envelope.AppendChild(ber.NewInteger(ber.ClassApplication, ber.TypePrimitive, ApplicationAbandonRequest, m.MessageID, "Abandon Request"))
+
+	if len(m.Controls) > 0 {
+		envelope.AppendChild(encodeControls(m.Controls))
+	}
+
+	return &packet{
+		Packet: envelope,
+	}
+}
+
+func testCancelRequestValue(t *testing.T, cancelID int64) []byte {
+	t.Helper()
+	value := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "Cancel Request Value")
+	value.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagInteger, cancelID, "Cancel ID"))
+	return value.Bytes()
+}
+
+func testCancelRequestPacket(t *testing.T, m CancelMessage) *packet {
+	t.Helper()
+
+	envelope := testRequestEnvelope(t, int(m.GetID()))
+	pkt := ber.Encode(ber.ClassApplication, ber.TypeConstructed, ApplicationExtendedRequest, nil, "Extended Request")
+	pkt.AppendChild(ber.NewString(ber.ClassContext, ber.TypePrimitive, 0, string(ExtendedOperationCancel), "Request Name"))
+	if m.Value != nil {
+		pkt.AppendChild(ber.NewString(ber.ClassContext, ber.TypePrimitive, 1, string(m.Value), "Request Value"))
+	}
+	envelope.AppendChild(pkt)
+
+	return &packet{
+		Packet: envelope,
+	}
+}
+
 func testModifyRequestPacket(t *testing.T, m ModifyMessage) *packet {
 	t.Helper()
 	envelope := testRequestEnvelope(t, int(m.GetID()))
@@ -183,6 +264,42 @@ func testAddRequestPacket(t *testing.T, m AddMessage) *packet {
 	}
 }
 
+func testCompareRequestPacket(t *testing.T, m CompareMessage) *packet {
+	t.Helper()
+	envelope := testRequestEnvelope(t, int(m.GetID()))
+	pkt := ber.Encode(ber.ClassApplication, ber.TypeConstructed, ApplicationCompareRequest, nil, "Compare Request")
+	pkt.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, m.DN, "DN"))
+	pkt.AppendChild(m.Assertion.encode())
+
+	envelope.AppendChild(pkt)
+	if len(m.Controls) > 0 {
+		envelope.AppendChild(encodeControls(m.Controls))
+	}
+	return &packet{
+		Packet: envelope,
+	}
+}
+
+func testModifyDNRequestPacket(t *testing.T, m ModifyDNMessage) *packet {
+	t.Helper()
+	envelope := testRequestEnvelope(t, int(m.GetID()))
+	pkt := ber.Encode(ber.ClassApplication, ber.TypeConstructed, ApplicationModifyDNRequest, nil, "Modify DN Request")
+	pkt.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, m.DN, "DN"))
+	pkt.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, m.NewRDN, "New RDN"))
+	pkt.AppendChild(ber.NewBoolean(ber.ClassUniversal, ber.TypePrimitive, ber.TagBoolean, m.DeleteOldRDN, "Delete old RDN"))
+	if m.NewSuperior != "" {
+		pkt.AppendChild(ber.NewString(ber.ClassContext, ber.TypePrimitive, 0, m.NewSuperior, "New Superior"))
+	}
+
+	envelope.AppendChild(pkt)
+	if len(m.Controls) > 0 {
+		envelope.AppendChild(encodeControls(m.Controls))
+	}
+	return &packet{
+		Packet: envelope,
+	}
+}
+
 func testRequestEnvelope(t *testing.T, messageID int) *ber.Packet {
 	t.Helper()
 	p := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "LDAP Request")
@@ -7,11 +7,12 @@ import (
 	"crypto/tls"
 	"crypto/x509"
 	"fmt"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
-	ber "github.com/go-asn1-ber/asn1-ber"
 	"github.com/go-ldap/ldap/v3"
 	"github.com/hashicorp/go-hclog"
 	"github.com/jimlambrt/gldap"
@@ -356,7 +357,7 @@ func TestDirectory_ModifyResponse(t *testing.T) {
 				DN: users[alice].DN,
 				Attributes: func() []*gldap.EntryAttribute {
 					attrs := append([]*gldap.EntryAttribute{}, users[alice].Attributes...)
-					attrs = append(attrs, gldap.NewEntryAttribute("description", []string{gldap.TestEncodeString(t, ber.TagOctetString, "test-add-attribute")}))
+					attrs = append(attrs, gldap.NewEntryAttribute("description", []string{"test-add-attribute"}))
 					return attrs
 				}(),
 			},
@@ -617,6 +618,167 @@ func TestDirectory_DeleteResponse(t *testing.T) {
 	}
 }
 
+func TestDirectory_CompareResponse(t *testing.T) {
+	t.Parallel()
+	testLogger := hclog.New(&hclog.LoggerOptions{
+		Name:  "TestDirectory_CompareResponse-logger",
+		Level: hclog.Error,
+	})
+	td := testdirectory.Start(t,
+		testdirectory.WithLogger(t, testLogger),
+		testdirectory.WithDefaults(t, &testdirectory.Defaults{AllowAnonymousBind: true}),
+	)
+	users := testdirectory.NewUsers(t, []string{"alice", "bob"})
+	groups := testdirectory.NewGroup(t, "admin", []string{"alice"})
+	td.SetUsers(users...)
+	td.SetGroups(groups)
+
+	tests := []struct {
+		name            string
+		dn              string
+		attribute       string
+		value           string
+		want            bool
+		wantErr         bool
+		wantErrContains string
+	}{
+		{
+			name:      "member-true",
+			dn:        groups.DN,
+			attribute: "member",
+			value:     users[0].DN,
+			want:      true,
+		},
+		{
+			name:      "member-false",
+			dn:        groups.DN,
+			attribute: "member",
+			value:     users[1].DN,
+			want:      false,
+		},
+		{
+			name:      "user-attribute-case-insensitive",
+			dn:        users[1].DN,
+			attribute: "EMAIL",
+			value:     "bob@example.com",
+			want:      true,
+		},
+		{
+			name:            "no-such-attribute",
+			dn:              users[1].DN,
+			attribute:       "description",
+			value:           "bob",
+			wantErr:         true,
+			wantErrContains: "No Such Attribute",
+		},
+		{
+			name:            "not-found",
+			dn:              fmt.Sprintf("%s=%s,%s", testdirectory.DefaultGroupAttr, "not-found", testdirectory.DefaultGroupDN),
+			attribute:       "member",
+			value:           users[0].DN,
+			wantErr:         true,
+			wantErrContains: "No Such Object",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert, require := assert.New(t), require.New(t)
+			client := td.Conn()
+			defer func() { client.Close() }()
+
+			got, err := client.Compare(tc.dn, tc.attribute, tc.value)
+			if tc.wantErr {
+				require.Error(err)
+				if tc.wantErrContains != "" {
+					assert.Contains(err.Error(), tc.wantErrContains)
+				}
+				return
+			}
+			require.NoError(err)
+			assert.Equal(tc.want, got)
+		})
+	}
+}
+
+func TestDirectory_ModifyDNResponse(t *testing.T) {
+	t.Parallel()
+	testLogger := hclog.New(&hclog.LoggerOptions{
+		Name:  "TestDirectory_ModifyDNResponse-logger",
+		Level: hclog.Error,
+	})
+	td := testdirectory.Start(t,
+		testdirectory.WithLogger(t, testLogger),
+		testdirectory.WithDefaults(t, &testdirectory.Defaults{AllowAnonymousBind: true}),
+	)
+	users := testdirectory.NewUsers(t, []string{"alice", "bob", "eve"})
+	td.SetUsers(users...)
+
+	tests := []struct {
+		name            string
+		dn              string
+		newRDN          string
+		deleteOldRDN    bool
+		newSuperior     string
+		wantDN          string
+		wantErr         bool
+		wantErrContains string
+	}{
+		{
+			name:         "rename",
+			dn:           users[0].DN,
+			newRDN:       fmt.Sprintf("%s=%s", testdirectory.DefaultUserAttr, "alice-smith"),
+			deleteOldRDN: true,
+			wantDN:       fmt.Sprintf("%s=%s,%s", testdirectory.DefaultUserAttr, "alice-smith", testdirectory.DefaultUserDN),
+		},
+		{
+			name:        "move",
+			dn:          users[1].DN,
+			newRDN:      fmt.Sprintf("%s=%s", testdirectory.DefaultUserAttr, "bob"),
+			newSuperior: "ou=admins,dc=example,dc=org",
+			wantDN:      fmt.Sprintf("%s=%s,%s", testdirectory.DefaultUserAttr, "bob", "ou=admins,dc=example,dc=org"),
+		},
+		{
+			name:            "already-exists",
+			dn:              users[2].DN,
+			newRDN:          fmt.Sprintf("%s=%s", testdirectory.DefaultUserAttr, "alice-smith"),
+			wantErr:         true,
+			wantErrContains: "Entry Already Exists",
+		},
+		{
+			name:            "not-found",
+			dn:              fmt.Sprintf("%s=%s,%s", testdirectory.DefaultUserAttr, "joe", testdirectory.DefaultUserDN),
+			newRDN:          fmt.Sprintf("%s=%s", testdirectory.DefaultUserAttr, "joseph"),
+			wantErr:         true,
+			wantErrContains: "No Such Object",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert, require := assert.New(t), require.New(t)
+			client := td.Conn()
+			defer func() { client.Close() }()
+
+			err := client.ModifyDN(ldap.NewModifyDNRequest(tc.dn, tc.newRDN, tc.deleteOldRDN, tc.newSuperior))
+			if tc.wantErr {
+				require.Error(err)
+				if tc.wantErrContains != "" {
+					assert.Contains(err.Error(), tc.wantErrContains)
+				}
+				return
+			}
+			require.NoError(err)
+			var found bool
+			for _, u := range td.Users() {
+				if u.DN == tc.wantDN {
+					found = true
+				}
+				assert.NotEqual(tc.dn, u.DN)
+			}
+			assert.True(found)
+		})
+	}
+}
+
 func Test_Start_Unbind(t *testing.T) {
 	t.Parallel()
 	t.Run("unbind", func(t *testing.T) {
@@ -680,3 +842,527 @@ func Test_Start_Unbind(t *testing.T) {
 		assert.Equal("unbind-success", got)
 	})
 }
+
+func Test_Start_SASLBind(t *testing.T) {
+	t.Parallel()
+	newServer := func(t *testing.T, r *gldap.Mux) int {
+		t.Helper()
+		require := require.New(t)
+		port := testdirectory.FreePort(t)
+		l := hclog.New(&hclog.LoggerOptions{
+			Name:  "sasl-bind-logger",
+			Level: hclog.Error,
+		})
+		s, err := gldap.NewServer(gldap.WithLogger(l), gldap.WithDisablePanicRecovery())
+		require.NoError(err)
+		require.NoError(s.Router(r))
+		go func() { require.NoError(s.Run(fmt.Sprintf(":%d", port))) }()
+		t.Cleanup(func() { require.NoError(s.Stop()) })
+		time.Sleep(1 * time.Second)
+		return port
+	}
+	t.Run("external", func(t *testing.T) {
+		assert, require := assert.New(t), require.New(t)
+		r, err := gldap.NewMux()
+		require.NoError(err)
+
+		var allow atomic.Bool
+		allow.Store(true)
+		h, err := gldap.NewSASLExternalHandler(func(_ *gldap.Request, authzID string) (string, bool) {
+			return "dn:uid=alice,ou=people,dc=example,dc=org", allow.Load() && authzID == ""
+		})
+		require.NoError(err)
+		require.NoError(r.SASLBind(gldap.SASLMechanismExternal, h))
+		require.NoError(r.ExtendedOperation(gldap.NewWhoAmIHandler(), gldap.ExtendedOperationWhoAmI))
+		port := newServer(t, r)
+
+		conn, err := ldap.DialURL(fmt.Sprintf("ldap://localhost:%d", port))
+		require.NoError(err)
+		defer conn.Close()
+
+		// the bind without a requested authzid uses the auth func's identity
+		require.NoError(conn.ExternalBind())
+		res, err := conn.WhoAmI(nil)
+		require.NoError(err)
+		assert.Equal("dn:uid=alice,ou=people,dc=example,dc=org", res.AuthzID)
+
+		allow.Store(false)
+		err = conn.ExternalBind()
+		require.Error(err)
+		assert.True(ldap.IsErrorWithCode(err, ldap.LDAPResultInvalidCredentials))
+
+		// simple binds aren't routed to the sasl handler
+		err = conn.Bind("alice", "fido")
+		require.Error(err)
+		assert.True(ldap.IsErrorWithCode(err, ldap.LDAPResultUnwillingToPerform))
+	})
+	t.Run("multi-step", func(t *testing.T) {
+		assert, require := assert.New(t), require.New(t)
+		r, err := gldap.NewMux()
+		require.NoError(err)
+
+		const challenge = `realm="example.org",nonce="OA6MG9tEQGm2hh",qop="auth",charset=utf-8,algorithm=md5-sess`
+		err = r.SASLBind("DIGEST-MD5", func(w *gldap.ResponseWriter, req *gldap.Request) {
+			resp := req.NewBindResponse(gldap.WithResponseCode(gldap.ResultInvalidCredentials))
+			defer func() {
+				_ = w.Write(resp)
+			}()
+			m, err := req.GetSASLBindMessage()
+			if err != nil {
+				return
+			}
+			state := req.SASLBindState()
+			switch {
+			case state == nil && len(m.Credentials) == 0:
+				if err := req.SetSASLBindState("challenge-sent"); err != nil {
+					return
+				}
+				resp.SetResultCode(gldap.ResultSaslBindInProgress)
+				resp.SetServerSASLCreds([]byte(challenge))
+			case state == "challenge-sent" && strings.Contains(string(m.Credentials), `username="alice"`):
+				resp.SetResultCode(gldap.ResultSuccess)
+			}
+		})
+		require.NoError(err)
+		port := newServer(t, r)
+
+		conn, err := ldap.DialURL(fmt.Sprintf("ldap://localhost:%d", port))
+		require.NoError(err)
+		defer conn.Close()
+
+		require.NoError(conn.MD5Bind("localhost", "alice", "fido"))
+
+		// the state was cleared when the previous bind completed
+		err = conn.MD5Bind("localhost", "eve", "fido")
+		require.Error(err)
+		assert.True(ldap.IsErrorWithCode(err, ldap.LDAPResultInvalidCredentials))
+	})
+}
+
+func Test_Start_ExtendedOperationValue(t *testing.T) {
+	t.Parallel()
+	assert, require := assert.New(t), require.New(t)
+	port := testdirectory.FreePort(t)
+
+	l := hclog.New(&hclog.LoggerOptions{
+		Name:  "extended-operation-value-logger",
+		Level: hclog.Error,
+	})
+	s, err := gldap.NewServer(gldap.WithLogger(l), gldap.WithDisablePanicRecovery())
+	require.NoError(err)
+
+	r, err := gldap.NewMux()
+	require.NoError(err)
+
+	got := make(chan *gldap.PasswordModifyRequestValue, 1)
+	err = r.ExtendedOperation(func(w *gldap.ResponseWriter, req *gldap.Request) {
+		resp := req.NewExtendedResponse(gldap.WithResponseCode(gldap.ResultProtocolError))
+		defer func() {
+			_ = w.Write(resp)
+		}()
+		m, err := req.GetExtendedOperationMessage()
+		if err != nil {
+			return
+		}
+		v, err := gldap.DecodePasswordModifyRequestValue(m.ByteValue)
+		if err != nil {
+			return
+		}
+		got <- v
+		resp.SetResultCode(gldap.ResultSuccess)
+	}, gldap.ExtendedOperationPasswordModify)
+	require.NoError(err)
+
+	require.NoError(s.Router(r))
+	go func() { require.NoError(s.Run(fmt.Sprintf(":%d", port))) }()
+	defer func() { require.NoError(s.Stop()) }()
+	time.Sleep(1 * time.Second)
+
+	conn, err := ldap.DialURL(fmt.Sprintf("ldap://localhost:%d", port))
+	require.NoError(err)
+	defer conn.Close()
+
+	_, err = conn.PasswordModify(ldap.NewPasswordModifyRequest("uid=alice,ou=people,dc=example,dc=org", "old-password", "new-password"))
+	require.NoError(err)
+	assert.Equal(&gldap.PasswordModifyRequestValue{
+		UserIdentity: "uid=alice,ou=people,dc=example,dc=org",
+		OldPassword:  "old-password",
+		NewPassword:  "new-password",
+	}, <-got)
+}
+
+func Test_Start_WhoAmI(t *testing.T) {
+	t.Parallel()
+	assert, require := assert.New(t), require.New(t)
+	port := testdirectory.FreePort(t)
+
+	l := hclog.New(&hclog.LoggerOptions{
+		Name:  "whoami-logger",
+		Level: hclog.Error,
+	})
+	s, err := gldap.NewServer(gldap.WithLogger(l), gldap.WithDisablePanicRecovery())
+	require.NoError(err)
+
+	const aliceDN = "uid=alice,ou=people,dc=example,dc=org"
+	r, err := gldap.NewMux(gldap.WithSupportedControls(gldap.ControlTypePaging))
+	require.NoError(err)
+	err = r.Bind(func(w *gldap.ResponseWriter, req *gldap.Request) {
+		resp := req.NewBindResponse(gldap.WithResponseCode(gldap.ResultInvalidCredentials))
+		defer func() {
+			_ = w.Write(resp)
+		}()
+		m, err := req.GetSimpleBindMessage()
+		if err != nil {
+			return
+		}
+		if m.Password == "" || (m.UserName == aliceDN && m.Password == "fido") {
+			resp.SetResultCode(gldap.ResultSuccess)
+		}
+	})
+	require.NoError(err)
+	require.NoError(r.ExtendedOperation(gldap.NewWhoAmIHandler(), gldap.ExtendedOperationWhoAmI))
+
+	require.NoError(s.Router(r))
+	go func() { require.NoError(s.Run(fmt.Sprintf(":%d", port))) }()
+	defer func() { require.NoError(s.Stop()) }()
+	time.Sleep(1 * time.Second)
+
+	conn, err := ldap.DialURL(fmt.Sprintf("ldap://localhost:%d", port))
+	require.NoError(err)
+	defer conn.Close()
+
+	whoAmI := func() string {
+		t.Helper()
+		res, err := conn.WhoAmI(nil)
+		require.NoError(err)
+		return res.AuthzID
+	}
+
+	// anonymous before any bind
+	assert.Empty(whoAmI())
+
+	require.NoError(conn.Bind(aliceDN, "fido"))
+	assert.Equal("dn:"+aliceDN, whoAmI())
+
+	// a failed rebind resets the connection to anonymous
+	require.Error(conn.Bind(aliceDN, "bad-password"))
+	assert.Empty(whoAmI())
+
+	require.NoError(conn.Bind(aliceDN, "fido"))
+	assert.Equal("dn:"+aliceDN, whoAmI())
+
+	// an anonymous rebind resets the identity too
+	require.NoError(conn.UnauthenticatedBind(aliceDN))
+	assert.Empty(whoAmI())
+
+	// a rebind rejected for an unsupported critical control resets the
+	// identity
+	require.NoError(conn.Bind(aliceDN, "fido"))
+	assert.Equal("dn:"+aliceDN, whoAmI())
+	_, err = conn.SimpleBind(&ldap.SimpleBindRequest{
+		Username: aliceDN,
+		Password: "fido",
+		Controls: []ldap.Control{ldap.NewControlString("1.2.3.4", true, "")},
+	})
+	require.Error(err)
+	assert.True(ldap.IsErrorWithCode(err, ldap.LDAPResultUnavailableCriticalExtension))
+	assert.Empty(whoAmI())
+
+	// a rebind without a matching route resets the identity
+	require.NoError(conn.Bind(aliceDN, "fido"))
+	assert.Equal("dn:"+aliceDN, whoAmI())
+	err = conn.ExternalBind()
+	require.Error(err)
+	assert.True(ldap.IsErrorWithCode(err, ldap.LDAPResultUnwillingToPerform))
+	assert.Empty(whoAmI())
+}
+
+func Test_Start_CriticalControls(t *testing.T) {
+	t.Parallel()
+	assert, require := assert.New(t), require.New(t)
+	port := testdirectory.FreePort(t)
+
+	l := hclog.New(&hclog.LoggerOptions{
+		Name:  "critical-controls-logger",
+		Level: hclog.Error,
+	})
+	s, err := gldap.NewServer(gldap.WithLogger(l), gldap.WithDisablePanicRecovery())
+	require.NoError(err)
+
+	r, err := gldap.NewMux(gldap.WithSupportedControls(gldap.ControlTypePaging, gldap.ControlTypeManageDsaIT))
+	require.NoError(err)
+	require.NoError(r.RootDSE(map[string][]string{"namingContexts": {"dc=example,dc=org"}}))
+	require.NoError(r.Search(func(w *gldap.ResponseWriter, req *gldap.Request) {
+		_ = w.Write(req.NewSearchDoneResponse(gldap.WithResponseCode(gldap.ResultSuccess)))
+	}))
+	require.NoError(r.Delete(func(w *gldap.ResponseWriter, req *gldap.Request) {
+		_ = w.Write(req.NewResponse(gldap.WithApplicationCode(gldap.ApplicationDelResponse), gldap.WithResponseCode(gldap.ResultSuccess)))
+	}))
+
+	require.NoError(s.Router(r))
+	go func() { require.NoError(s.Run(fmt.Sprintf(":%d", port))) }()
+	defer func() { require.NoError(s.Stop()) }()
+	time.Sleep(1 * time.Second)
+
+	conn, err := ldap.DialURL(fmt.Sprintf("ldap://localhost:%d", port))
+	require.NoError(err)
+	defer conn.Close()
+
+	search := func(controls ...ldap.Control) (*ldap.SearchResult, error) {
+		t.Helper()
+		return conn.Search(ldap.NewSearchRequest("dc=example,dc=org", ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 0, 0, false, "(objectClass=*)", nil, controls))
+	}
+
+	// unsupported controls which aren't critical are ignored
+	_, err = search(&ldap.ControlString{ControlType: "1.2.3.4"})
+	require.NoError(err)
+
+	// supported critical controls are served by the handler
+	_, err = search(ldap.NewControlManageDsaIT(true))
+	require.NoError(err)
+
+	// unsupported critical controls are rejected
+	_, err = search(&ldap.ControlString{ControlType: "1.2.3.4", Criticality: true})
+	require.Error(err)
+	assert.True(ldap.IsErrorWithCode(err, ldap.LDAPResultUnavailableCriticalExtension))
+	assert.Contains(err.Error(), "unsupported critical control: 1.2.3.4")
+
+	delReq := ldap.NewDelRequest("uid=alice,dc=example,dc=org", []ldap.Control{&ldap.ControlString{ControlType: "1.2.3.4", Criticality: true}})
+	err = conn.Del(delReq)
+	require.Error(err)
+	assert.True(ldap.IsErrorWithCode(err, ldap.LDAPResultUnavailableCriticalExtension))
+	require.NoError(conn.Del(ldap.NewDelRequest("uid=alice,dc=example,dc=org", nil)))
+
+	// the root DSE advertises the supported controls
+	res, err := conn.Search(ldap.NewSearchRequest("", ldap.ScopeBaseObject, ldap.NeverDerefAliases, 0, 0, false, "(objectClass=*)", nil, nil))
+	require.NoError(err)
+	require.Len(res.Entries, 1)
+	assert.Equal([]string{gldap.ControlTypePaging, gldap.ControlTypeManageDsaIT}, res.Entries[0].GetAttributeValues("supportedControl"))
+	assert.Equal([]string{"dc=example,dc=org"}, res.Entries[0].GetAttributeValues("namingContexts"))
+	assert.Equal([]string{"3"}, res.Entries[0].GetAttributeValues("supportedLDAPVersion"))
+}
+
+func Test_Start_BinaryValues(t *testing.T) {
+	t.Parallel()
+	assert, require := assert.New(t), require.New(t)
+	port := testdirectory.FreePort(t)
+
+	l := hclog.New(&hclog.LoggerOptions{
+		Name:  "binary-values-logger",
+		Level: hclog.Error,
+	})
+	s, err := gldap.NewServer(gldap.WithLogger(l), gldap.WithDisablePanicRecovery())
+	require.NoError(err)
+
+	sid := []byte{0x01, 0x05, 0x00, 0x00, 0x00, 0x00, 0x00, 0x05, 0x15, 0x00, 0x00, 0x00, 0xff, 0xfe}
+	photo := []byte{0xff, 0xd8, 0xff, 0xe0, 0x00, 0x10, 'J', 'F', 'I', 'F'}
+
+	var (
+		mu            sync.Mutex
+		gotAddValues  [][]byte
+		gotModValues  [][]byte
+		gotModStrings []string
+	)
+	r, err := gldap.NewMux()
+	require.NoError(err)
+	require.NoError(r.Add(func(w *gldap.ResponseWriter, req *gldap.Request) {
+		m, err := req.GetAddMessage()
+		require.NoError(err)
+		mu.Lock()
+		gotAddValues = m.Attributes[0].ByteValues
+		mu.Unlock()
+		_ = w.Write(req.NewResponse(gldap.WithApplicationCode(gldap.ApplicationAddResponse), gldap.WithResponseCode(gldap.ResultSuccess)))
+	}))
+	require.NoError(r.Modify(func(w *gldap.ResponseWriter, req *gldap.Request) {
+		m, err := req.GetModifyMessage()
+		require.NoError(err)
+		mu.Lock()
+		gotModValues = m.Changes[0].Modification.ByteValues
+		gotModStrings = m.Changes[0].Modification.Vals
+		mu.Unlock()
+		_ = w.Write(req.NewModifyResponse(gldap.WithResponseCode(gldap.ResultSuccess)))
+	}))
+	require.NoError(r.Search(func(w *gldap.ResponseWriter, req *gldap.Request) {
+		entry := req.NewSearchResponseEntry("cn=alice,dc=example,dc=org")
+		entry.AddAttribute("cn", []string{"alice"})
+		entry.AddBinaryAttribute("objectSid", [][]byte{sid})
+		_ = w.Write(entry)
+		_ = w.Write(req.NewSearchDoneResponse(gldap.WithResponseCode(gldap.ResultSuccess)))
+	}))
+
+	require.NoError(s.Router(r))
+	go func() { require.NoError(s.Run(fmt.Sprintf(":%d", port))) }()
+	defer func() { require.NoError(s.Stop()) }()
+	time.Sleep(1 * time.Second)
+
+	conn, err := ldap.DialURL(fmt.Sprintf("ldap://localhost:%d", port))
+	require.NoError(err)
+	defer conn.Close()
+
+	addReq := ldap.NewAddRequest("cn=alice,dc=example,dc=org", nil)
+	addReq.Attribute("objectSid", []string{string(sid)})
+	require.NoError(conn.Add(addReq))
+
+	modReq := ldap.NewModifyRequest("cn=alice,dc=example,dc=org", nil)
+	modReq.Replace("jpegPhoto", []string{string(photo), "second"})
+	require.NoError(conn.Modify(modReq))
+
+	mu.Lock()
+	assert.Equal([][]byte{sid}, gotAddValues)
+	assert.Equal([][]byte{photo, []byte("second")}, gotModValues)
+	assert.Equal([]string{string(photo), "second"}, gotModStrings)
+	mu.Unlock()
+
+	res, err := conn.Search(ldap.NewSearchRequest("dc=example,dc=org", ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 0, 0, false, "(cn=alice)", nil, nil))
+	require.NoError(err)
+	require.Len(res.Entries, 1)
+	assert.Equal(sid, res.Entries[0].GetRawAttributeValue("objectSid"))
+	assert.Equal("alice", res.Entries[0].GetAttributeValue("cn"))
+}
+
+func Test_Start_RangedAttributes(t *testing.T) {
+	t.Parallel()
+	assert, require := assert.New(t), require.New(t)
+	port := testdirectory.FreePort(t)
+
+	l := hclog.New(&hclog.LoggerOptions{
+		Name:  "ranged-attributes-logger",
+		Level: hclog.Error,
+	})
+	s, err := gldap.NewServer(gldap.WithLogger(l), gldap.WithDisablePanicRecovery())
+	require.NoError(err)
+
+	const maxValueRange = 1500
+	members := make([]string, 0, 3500)
+	for i := 0; i < cap(members); i++ {
+		members = append(members, fmt.Sprintf("cn=user%d,dc=example,dc=org", i))
+	}
+
+	r, err := gldap.NewMux()
+	require.NoError(err)
+	require.NoError(r.Search(func(w *gldap.ResponseWriter, req *gldap.Request) {
+		m, err := req.GetSearchMessage()
+		require.NoError(err)
+		valueRange, _ := m.AttributeRange("member")
+		entry := req.NewSearchResponseEntry("cn=admin,dc=example,dc=org")
+		entry.AddAttribute("cn", []string{"admin"})
+		require.NoError(entry.AddRangedAttribute("member", members, valueRange, gldap.WithMaxValueRange(maxValueRange)))
+		_ = w.Write(entry)
+		_ = w.Write(req.NewSearchDoneResponse(gldap.WithResponseCode(gldap.ResultSuccess)))
+	}))
+
+	require.NoError(s.Router(r))
+	go func() { require.NoError(s.Run(fmt.Sprintf(":%d", port))) }()
+	defer func() { require.NoError(s.Stop()) }()
+	time.Sleep(1 * time.Second)
+
+	conn, err := ldap.DialURL(fmt.Sprintf("ldap://localhost:%d", port))
+	require.NoError(err)
+	defer conn.Close()
+
+	// page through the members the way Active Directory clients do, until
+	// the server returns a range ending with "*"
+	var got, gotNames []string
+	attribute := "member"
+	for {
+		res, err := conn.Search(ldap.NewSearchRequest("cn=admin,dc=example,dc=org", ldap.ScopeBaseObject, ldap.NeverDerefAliases, 0, 0, false, "(objectClass=*)", []string{attribute}, nil))
+		require.NoError(err)
+		require.Len(res.Entries, 1)
+		var found *ldap.EntryAttribute
+		for _, a := range res.Entries[0].Attributes {
+			if strings.HasPrefix(a.Name, "member") {
+				found = a
+			}
+		}
+		require.NotNil(found)
+		got = append(got, found.Values...)
+		gotNames = append(gotNames, found.Name)
+		d, err := gldap.ParseAttributeDescription(found.Name)
+		require.NoError(err)
+		require.NotNil(d.Range)
+		if d.Range.High == gldap.AttributeRangeEnd {
+			break
+		}
+		attribute = fmt.Sprintf("member;range=%d-*", d.Range.High+1)
+	}
+	assert.Equal([]string{"member;range=0-1499", "member;range=1500-2999", "member;range=3000-*"}, gotNames)
+	assert.Equal(members, got)
+}
+
+func Test_Start_PagedResults(t *testing.T) {
+	t.Parallel()
+	port := testdirectory.FreePort(t)
+
+	l := hclog.New(&hclog.LoggerOptions{
+		Name:  "paged-results-logger",
+		Level: hclog.Error,
+	})
+	s, err := gldap.NewServer(gldap.WithLogger(l), gldap.WithDisablePanicRecovery())
+	require.NoError(t, err)
+
+	entries := make([]*gldap.Entry, 0, 25)
+	for i := 0; i < cap(entries); i++ {
+		entries = append(entries, gldap.NewEntry(fmt.Sprintf("cn=user%d,dc=example,dc=org", i), map[string][]string{"cn": {fmt.Sprintf("user%d", i)}}))
+	}
+
+	r, err := gldap.NewMux()
+	require.NoError(t, err)
+	require.NoError(t, r.Search(func(w *gldap.ResponseWriter, req *gldap.Request) {
+		m, err := req.GetSearchMessage()
+		require.NoError(t, err)
+		paging, ok := m.PagingControl()
+		if !ok {
+			for _, e := range entries {
+				_ = w.Write(req.NewSearchResponseEntry(e.DN, gldap.WithAttributes(map[string][]string{"cn": e.GetAttributeValues("cn")})))
+			}
+			_ = w.Write(req.NewSearchDoneResponse(gldap.WithResponseCode(gldap.ResultSuccess)))
+			return
+		}
+		if err := req.WritePage(w, gldap.NewEntryIterator(entries), paging); err != nil {
+			_ = w.Write(req.NewSearchDoneResponse(gldap.WithResponseCode(gldap.ResultUnwillingToPerform)))
+		}
+	}))
+
+	require.NoError(t, s.Router(r))
+	go func() { require.NoError(t, s.Run(fmt.Sprintf(":%d", port))) }()
+	defer func() { require.NoError(t, s.Stop()) }()
+	time.Sleep(1 * time.Second)
+
+	conn, err := ldap.DialURL(fmt.Sprintf("ldap://localhost:%d", port))
+	require.NoError(t, err)
+	defer conn.Close()
+
+	searchReq := ldap.NewSearchRequest("dc=example,dc=org", ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 0, 0, false, "(cn=*)", []string{"cn"}, nil)
+
+	t.Run("all-pages", func(t *testing.T) {
+		assert, require := assert.New(t), require.New(t)
+		res, err := conn.SearchWithPaging(searchReq, 10)
+		require.NoError(err)
+		require.Len(res.Entries, len(entries))
+		for i, e := range res.Entries {
+			assert.Equal(entries[i].DN, e.DN)
+			assert.Equal(entries[i].GetAttributeValues("cn"), e.GetAttributeValues("cn"))
+		}
+	})
+	t.Run("single-page", func(t *testing.T) {
+		assert, require := assert.New(t), require.New(t)
+		pagingReq := *searchReq
+		pagingReq.Controls = []ldap.Control{ldap.NewControlPaging(10)}
+		res, err := conn.Search(&pagingReq)
+		require.NoError(err)
+		assert.Len(res.Entries, 10)
+		paging, ok := ldap.FindControl(res.Controls, ldap.ControlTypePaging).(*ldap.ControlPaging)
+		require.True(ok)
+		assert.NotEmpty(paging.Cookie)
+	})
+	t.Run("unknown-cookie", func(t *testing.T) {
+		assert, require := assert.New(t), require.New(t)
+		paging := ldap.NewControlPaging(10)
+		paging.SetCookie([]byte("unknown"))
+		pagingReq := *searchReq
+		pagingReq.Controls = []ldap.Control{paging}
+		_, err := conn.Search(&pagingReq)
+		require.Error(err)
+		assert.True(ldap.IsErrorWithCode(err, ldap.LDAPResultUnwillingToPerform))
+	})
+}
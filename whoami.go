@@ -0,0 +1,24 @@
+// Copyright (c) Jim Lambert
+// SPDX-License-Identifier: MIT
+
+package gldap
+
+// NewWhoAmIHandler creates a HandlerFunc for ExtendedOperationWhoAmI requests,
+// which responds with the authorization identity of the request's connection
+// (see: Request.AuthzID()).  The identity is empty for anonymous connections.
+// It's intended to be registered using:
+// Mux.ExtendedOperation(NewWhoAmIHandler(), ExtendedOperationWhoAmI)
+//
+// see: https://datatracker.ietf.org/doc/html/rfc4532
+func NewWhoAmIHandler() HandlerFunc {
+	const op = "gldap.NewWhoAmIHandler"
+	return func(w *ResponseWriter, r *Request) {
+		resp := r.NewExtendedResponse(WithResponseCode(ResultSuccess))
+		// the response value is always included, even when it's empty for an
+		// anonymous connection.
+		resp.SetResponseValue(append([]byte{}, r.AuthzID()...))
+		if err := w.Write(resp); err != nil {
+			w.logger.Error("error writing response", "op", op, "conn", w.connID, "requestID", w.requestID, "err", err)
+		}
+	}
+}